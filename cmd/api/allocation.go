@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// checkAllocation returns the weeks in which changed, the assignments as a
+// change would leave them, take their resources over capacity. moved, if not
+// nil, is a request whose dates the change moves, as it would be after the
+// change.
+//
+// The resources are locked first, so models must be the transaction that
+// makes the change: another check of the same resources then waits until
+// that transaction ends and sees what it wrote.
+func (app *application) checkAllocation(ctx context.Context, models *data.Models, changed []*data.ResourceAssignment, moved *data.ResourceRequest) ([]*data.AllocationConflict, error) {
+	var resourceIDs []int64
+	for _, a := range changed {
+		if a.Counts() {
+			resourceIDs = append(resourceIDs, a.ResourceID)
+		}
+	}
+
+	if len(resourceIDs) == 0 {
+		return nil, nil
+	}
+
+	err := models.Resources.LockForUpdate(ctx, resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := models.Resources.GetByIDs(ctx, resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	capacities := make(map[int64]int64, len(resources))
+	for _, resource := range resources {
+		capacities[resource.ID] = resource.CapacityHours
+	}
+
+	existing, err := models.ResourceAssignments.GetAllForResources(ctx, resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	type assignmentKey struct{ requestID, resourceID int64 }
+
+	replaced := make(map[assignmentKey]bool, len(changed))
+	for _, a := range changed {
+		replaced[assignmentKey{a.ResourceRequestID, a.ResourceID}] = true
+	}
+
+	assignments := append([]*data.ResourceAssignment{}, changed...)
+	for _, a := range existing {
+		if !replaced[assignmentKey{a.ResourceRequestID, a.ResourceID}] {
+			assignments = append(assignments, a)
+		}
+	}
+
+	var requestIDs []int64
+	for _, a := range assignments {
+		requestIDs = append(requestIDs, a.ResourceRequestID)
+	}
+
+	loaded, err := models.ResourceRequests.GetByIDs(ctx, requestIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make(map[int64]*data.ResourceRequest, len(loaded))
+	for _, rr := range loaded {
+		requests[rr.ID] = rr
+	}
+	if moved != nil {
+		requests[moved.ID] = moved
+	}
+
+	return data.Overallocations(assignments, changed, requests, capacities), nil
+}
+
+// checkRequestAllocation returns the weeks in which moving rr to its new
+// dates takes the resources assigned to it over capacity.
+func (app *application) checkRequestAllocation(ctx context.Context, models *data.Models, rr *data.ResourceRequest) ([]*data.AllocationConflict, error) {
+	assignments, err := models.ResourceAssignments.GetAllForRequest(ctx, rr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.checkAllocation(ctx, models, assignments, rr)
+}
+
+// readForce reads the force parameter, which applies a change that
+// over-allocates a resource when conflicts are configured to reject it.
+func (app *application) readForce(r *http.Request, v *validator.Validator) bool {
+	return app.readBool(r.URL.Query(), "force", false, v)
+}
+
+// errOverAllocated rolls back a change causing conflicts it may not cause.
+var errOverAllocated = errors.New("over-allocated")
+
+// allowAllocation reports whether a change causing conflicts may go ahead:
+// it may when there are none, when conflicts only warn, or when the caller
+// forces it.
+func (app *application) allowAllocation(conflicts []*data.AllocationConflict, force bool) bool {
+	return len(conflicts) == 0 || app.cfg.allocation.conflicts == "warn" || force
+}
+
+// withConflicts returns a change's response with the conflicts it was
+// allowed to cause, if any. env itself, which may also be published, is left
+// as it is.
+func withConflicts(env envelope, conflicts []*data.AllocationConflict) envelope {
+	if len(conflicts) == 0 {
+		return env
+	}
+
+	out := envelope{"conflicts": conflicts}
+	for key, value := range env {
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+func TestAllocationConflicts(t *testing.T) {
+	const assignment = `{"resourceId": 1, "hoursPerWeek": 20, "status": "accepted"}`
+
+	// Each case gives resource 1 the capacity in resource, if any, assigns
+	// it 30 hours a week to request 1 and then makes the request given,
+	// which assigns it 20 more to request 2 in a week the two share.
+	tests := []struct {
+		name     string
+		args     []string
+		resource string
+		method   string
+		target   string
+		body     string
+		status   int
+		// capacity is the capacity the conflict reports, if there is one.
+		capacity int64
+		created  bool
+	}{
+		{
+			name:   "rejected",
+			method: http.MethodPost, target: "/v1/requests/2/assignments", body: assignment,
+			status: http.StatusConflict, capacity: 40,
+		},
+		{
+			name:   "forced",
+			method: http.MethodPost, target: "/v1/requests/2/assignments?force=true", body: assignment,
+			status: http.StatusCreated, capacity: 40, created: true,
+		},
+		{
+			name:   "warned",
+			args:   []string{"-allocation-conflicts", "warn"},
+			method: http.MethodPost, target: "/v1/requests/2/assignments", body: assignment,
+			status: http.StatusCreated, capacity: 40, created: true,
+		},
+		{
+			name:   "rejected in a batch",
+			method: http.MethodPost, target: "/v1/batch", body: batchBody(`{"op": "create", "type": "assignment", "id": 2, "data": ` + assignment + `}`),
+			status: http.StatusConflict, capacity: 40,
+		},
+		{
+			name:   "forced in a batch",
+			method: http.MethodPost, target: "/v1/batch?force=true", body: batchBody(`{"op": "create", "type": "assignment", "id": 2, "data": ` + assignment + `}`),
+			status: http.StatusOK, capacity: 40, created: true,
+		},
+		{
+			name:     "within the resource's capacity",
+			resource: `, "capacityHours": 50`,
+			method:   http.MethodPost, target: "/v1/requests/2/assignments", body: assignment,
+			status: http.StatusCreated, created: true,
+		},
+		{
+			name:     "over the resource's capacity",
+			args:     []string{"-allocation-capacity", "60"},
+			resource: `, "capacityHours": 45`,
+			method:   http.MethodPost, target: "/v1/requests/2/assignments", body: assignment,
+			status: http.StatusConflict, capacity: 45,
+		},
+		{
+			name:   "within the default capacity",
+			args:   []string{"-allocation-capacity", "50"},
+			method: http.MethodPost, target: "/v1/requests/2/assignments", body: assignment,
+			status: http.StatusCreated, created: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, tt.args...)
+			h := app.routes()
+
+			seedResources(t, h, `{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"`+tt.resource+`}`)
+			mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-13", 40, `"NSX"`), http.StatusCreated)
+			mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Globex", "2026-11-09", "2026-11-20", 40, `"NSX"`), http.StatusCreated)
+			mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 1, "hoursPerWeek": 30, "status": "accepted"}`, http.StatusCreated)
+
+			rr := mustDo(t, h, tt.method, tt.target, tt.body, tt.status)
+
+			var got struct {
+				Type      string                     `json:"type"`
+				Conflicts []*data.AllocationConflict `json:"conflicts"`
+				Results   []struct {
+					Conflicts []*data.AllocationConflict `json:"conflicts"`
+				} `json:"results"`
+			}
+			decodeJSON(t, rr, &got)
+
+			conflicts := got.Conflicts
+			if len(got.Results) > 0 {
+				conflicts = got.Results[0].Conflicts
+			}
+
+			switch {
+			case tt.capacity == 0 && len(conflicts) > 0:
+				t.Errorf("conflicts %s, want none", rr.Body)
+			case tt.capacity != 0 && len(conflicts) != 1:
+				t.Errorf("conflicts %s, want one in the week of 9 November", rr.Body)
+			case tt.capacity != 0:
+				c := conflicts[0]
+				if c.ResourceID != 1 || c.Week.Format("2006-01-02") != "2026-11-09" || c.AllocatedHours != 50 || c.CapacityHours != tt.capacity {
+					t.Errorf("conflict %+v, want resource 1 at 50 of %d hours in the week of 9 November", c, tt.capacity)
+				}
+			}
+
+			if tt.status == http.StatusConflict && got.Type != problemOverAllocated {
+				t.Errorf("problem type %q, want %q", got.Type, problemOverAllocated)
+			}
+
+			_, err := app.models.ResourceAssignments.Get(context.Background(), 2, 1)
+			switch {
+			case tt.created && err != nil:
+				t.Errorf("getting the assignment: %v", err)
+			case !tt.created && !errors.Is(err, data.ErrNotFound):
+				t.Errorf("rejected assignment: error %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMoveRequestAllocation(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	seedResources(t, h, `{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female", "capacityHours": 40}`)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-13", 40, `"NSX"`), http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Globex", "2026-11-16", "2026-11-27", 40, `"NSX"`), http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 1, "hoursPerWeek": 30, "status": "accepted"}`, http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/2/assignments", `{"resourceId": 1, "hoursPerWeek": 20, "status": "accepted"}`, http.StatusCreated)
+
+	// Starting request 2 a week earlier runs both assignments in the week
+	// of 9 November.
+	move := `{"startDate": "2026-11-09T00:00:00Z"}`
+
+	mustDo(t, h, http.MethodPatch, "/v1/requests/2", move, http.StatusConflict)
+
+	rr, err := app.models.ResourceRequests.Get(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rr.StartDate.Format("2006-01-02"); got != "2026-11-16" {
+		t.Errorf("rejected move left request 2 starting %s, want 2026-11-16", got)
+	}
+
+	mustDo(t, h, http.MethodPatch, "/v1/requests/2?force=true", move, http.StatusOK)
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// assignmentInput is the body that assigns a resource to a request. Hours
+// default to the request's, and the assignment starts as a proposal unless it
+// is made already accepted.
+type assignmentInput struct {
+	ResourceID   int64  `json:"resourceId"`
+	HoursPerWeek *int64 `json:"hoursPerWeek"`
+	Status       string `json:"status"`
+}
+
+func (input assignmentInput) assignment(rr *data.ResourceRequest, proposedBy string) *data.ResourceAssignment {
+	status := input.Status
+	if status == "" {
+		status = data.AssignmentProposed
+	}
+
+	assignment := &data.ResourceAssignment{
+		ResourceRequestID: rr.ID,
+		ResourceID:        input.ResourceID,
+		HoursPerWeek:      rr.HoursPerWeek,
+		Status:            status,
+		ProposedBy:        proposedBy,
+	}
+
+	if input.HoursPerWeek != nil {
+		assignment.HoursPerWeek = *input.HoursPerWeek
+	}
+
+	return assignment
+}
+
+// validateNewAssignmentStatus checks that an assignment is created as a
+// proposal or accepted outright; it reaches any other status by transition.
+func validateNewAssignmentStatus(v *validator.Validator, assignment *data.ResourceAssignment) {
+	v.Check(validator.PermittedValue(assignment.Status, data.AssignmentProposed, data.AssignmentAccepted), "status", "must be proposed or accepted")
+}
+
+// validateStaffing checks that rr can take a new assignment, which it only
+// can while open, partially filled or filled.
+func validateStaffing(v *validator.Validator, rr *data.ResourceRequest) {
+	v.Check(rr.Staffing(), "request", "must be open, partially filled or filled to assign resources to it; it is "+rr.Status)
+}
+
+// assignmentUpdate is the body that updates an assignment. Only the fields
+// present change; the status changes by transition.
+type assignmentUpdate struct {
+	HoursPerWeek *int64 `json:"hoursPerWeek"`
+	Completed    *bool  `json:"completed"`
+}
+
+func (input assignmentUpdate) apply(assignment *data.ResourceAssignment) {
+	if input.HoursPerWeek != nil {
+		assignment.HoursPerWeek = *input.HoursPerWeek
+	}
+
+	if input.Completed != nil {
+		assignment.Completed = *input.Completed
+	}
+}
+
+func (app *application) handleCreateAssignment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		rr, err := app.models.ResourceRequests.Get(r.Context(), requestID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		var input assignmentInput
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		assignment := input.assignment(rr, app.contextGetUser(r))
+
+		v := validator.New()
+
+		force := app.readForce(r, v)
+
+		data.ValidateResourceAssignment(v, *assignment)
+		validateNewAssignmentStatus(v, assignment)
+		validateStaffing(v, rr)
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		resource, err := app.models.Resources.Get(r.Context(), assignment.ResourceID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				v.AddError("resourceId", "does not exist")
+				app.failedValidationResponse(w, r, v.Errors)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		var (
+			conflicts     []*data.AllocationConflict
+			statusChanged envelope
+		)
+
+		err = app.models.WithTx(r.Context(), func(tx *data.Models) error {
+			conflicts, err = app.checkAllocation(r.Context(), tx, []*data.ResourceAssignment{assignment}, nil)
+			if err != nil {
+				return err
+			}
+
+			if !app.allowAllocation(conflicts, force) {
+				return errOverAllocated
+			}
+
+			moved, err := tx.ResourceAssignments.Insert(r.Context(), assignment)
+			if err != nil {
+				return err
+			}
+
+			statusChanged, err = fillTransitionEvent(r.Context(), tx, moved)
+			return err
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, errOverAllocated):
+				app.overAllocatedResponse(w, r, conflicts)
+			case errors.Is(err, data.ErrDuplicateAssignment):
+				v.AddError("resourceId", "is already assigned to this request")
+				app.failedValidationResponse(w, r, v.Errors)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		app.publishEvent(data.EventAssignmentCreated, envelope{"assignment": assignment})
+		if statusChanged != nil {
+			app.publishEvent(data.EventRequestStatusChanged, statusChanged)
+		}
+
+		if assignment.Status == data.AssignmentAccepted {
+			app.notifyAssignment(resource, rr, assignment)
+		}
+
+		err = app.writeJSON(w, http.StatusCreated, withConflicts(envelope{"assignment": assignment}, conflicts), nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleListAssignments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		_, err = app.models.ResourceRequests.Get(r.Context(), requestID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		assignments, err := app.models.ResourceAssignments.GetAllForRequest(r.Context(), requestID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"assignments": assignments}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleShowAssignment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assignment, ok := app.readAssignment(w, r)
+		if !ok {
+			return
+		}
+
+		err := app.writeJSON(w, http.StatusOK, envelope{"assignment": assignment}, lastModified(assignment.UpdatedAt))
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// readAssignment returns the assignment named in the URL, writing a not found
+// response if there is none.
+func (app *application) readAssignment(w http.ResponseWriter, r *http.Request) (*data.ResourceAssignment, bool) {
+	requestID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	resourceID, err := app.readNamedIDParam(r, "resourceId")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	assignment, err := app.models.ResourceAssignments.Get(r.Context(), requestID, resourceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return assignment, true
+}
+
+func (app *application) handleUpdateAssignment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assignment, ok := app.readAssignment(w, r)
+		if !ok {
+			return
+		}
+
+		var input assignmentUpdate
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		input.apply(assignment)
+
+		v := validator.New()
+
+		force := app.readForce(r, v)
+
+		if data.ValidateResourceAssignment(v, *assignment); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		var (
+			conflicts     []*data.AllocationConflict
+			statusChanged envelope
+		)
+
+		err = app.models.WithTx(r.Context(), func(tx *data.Models) error {
+			conflicts, err = app.checkAllocation(r.Context(), tx, []*data.ResourceAssignment{assignment}, nil)
+			if err != nil {
+				return err
+			}
+
+			if !app.allowAllocation(conflicts, force) {
+				return errOverAllocated
+			}
+
+			moved, err := tx.ResourceAssignments.Update(r.Context(), assignment)
+			if err != nil {
+				return err
+			}
+
+			statusChanged, err = fillTransitionEvent(r.Context(), tx, moved)
+			return err
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, errOverAllocated):
+				app.overAllocatedResponse(w, r, conflicts)
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		app.publishEvent(data.EventAssignmentUpdated, envelope{"assignment": assignment})
+		if statusChanged != nil {
+			app.publishEvent(data.EventRequestStatusChanged, statusChanged)
+		}
+
+		err = app.writeJSON(w, http.StatusOK, withConflicts(envelope{"assignment": assignment}, conflicts), nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleDeleteAssignment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		resourceID, err := app.readNamedIDParam(r, "resourceId")
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		var statusChanged envelope
+
+		err = app.models.WithTx(r.Context(), func(tx *data.Models) error {
+			moved, err := tx.ResourceAssignments.Delete(r.Context(), requestID, resourceID)
+			if err != nil {
+				return err
+			}
+
+			statusChanged, err = fillTransitionEvent(r.Context(), tx, moved)
+			return err
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		app.publishEvent(data.EventAssignmentDeleted, envelope{"requestId": requestID, "resourceId": resourceID})
+		if statusChanged != nil {
+			app.publishEvent(data.EventRequestStatusChanged, statusChanged)
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+func (app *application) handleTransitionAssignment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assignment, ok := app.readAssignment(w, r)
+		if !ok {
+			return
+		}
+
+		var input struct {
+			Status  string `json:"status"`
+			Comment string `json:"comment"`
+			// Version, when given, must match the assignment's, so that a
+			// client only moves the assignment it last saw.
+			Version *int64 `json:"version"`
+		}
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if input.Version != nil && *input.Version != assignment.Version {
+			app.editConflictResponse(w, r)
+			return
+		}
+
+		v := validator.New()
+
+		force := app.readForce(r, v)
+
+		if data.ValidateAssignmentTransition(v, assignment.Status, input.Status, input.Comment); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		// Accepting an assignment makes it count towards its resource's
+		// capacity.
+		accepted := *assignment
+		accepted.Status = input.Status
+
+		var conflicts []*data.AllocationConflict
+		var transition *data.AssignmentTransition
+		var statusChanged envelope
+
+		err = app.models.WithTx(r.Context(), func(tx *data.Models) error {
+			conflicts, err = app.checkAllocation(r.Context(), tx, []*data.ResourceAssignment{&accepted}, nil)
+			if err != nil {
+				return err
+			}
+
+			if !app.allowAllocation(conflicts, force) {
+				return errOverAllocated
+			}
+
+			var moved *data.RequestTransition
+
+			transition, moved, err = tx.ResourceAssignments.Transition(r.Context(), assignment, input.Status, input.Comment, app.contextGetUser(r))
+			if err != nil {
+				return err
+			}
+
+			statusChanged, err = fillTransitionEvent(r.Context(), tx, moved)
+			return err
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, errOverAllocated):
+				app.overAllocatedResponse(w, r, conflicts)
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w, r)
+			case errors.Is(err, data.ErrInvalidTransition):
+				app.invalidTransitionResponse(w, r, assignment.Status, input.Status, data.NextAssignmentStatuses(assignment.Status))
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		env := envelope{"assignment": assignment, "transition": transition}
+
+		app.publishEvent(data.EventAssignmentStatusChanged, env)
+		if statusChanged != nil {
+			app.publishEvent(data.EventRequestStatusChanged, statusChanged)
+		}
+
+		if assignment.Status == data.AssignmentAccepted {
+			app.notifyAcceptedAssignment(r, assignment)
+		}
+
+		err = app.writeJSON(w, http.StatusOK, withConflicts(env, conflicts), nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleListAssignmentTransitions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assignment, ok := app.readAssignment(w, r)
+		if !ok {
+			return
+		}
+
+		transitions, err := app.models.ResourceAssignments.GetTransitions(r.Context(), assignment.ResourceRequestID, assignment.ResourceID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"transitions": transitions}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+func TestFillStatusEvents(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	sub, _, _ := app.events.subscribe(0, func(kind string) bool { return kind == data.EventRequestStatusChanged })
+
+	// moves drains the request.status_changed events published so far,
+	// checking each payload's request agrees with its transition.
+	moves := func(t *testing.T) []string {
+		t.Helper()
+
+		got := []string{}
+		for {
+			select {
+			case e := <-sub.ch:
+				var payload struct {
+					Data struct {
+						Request    data.ResourceRequest   `json:"request"`
+						Transition data.RequestTransition `json:"transition"`
+					} `json:"data"`
+				}
+				if err := json.Unmarshal(e.body, &payload); err != nil {
+					t.Fatal(err)
+				}
+
+				request, transition := payload.Data.Request, payload.Data.Transition
+				if request.ID != transition.RequestID || request.Status != transition.To {
+					t.Errorf("request %d in status %s published with transition %+v", request.ID, request.Status, transition)
+				}
+				got = append(got, transition.From+" > "+transition.To)
+			default:
+				return got
+			}
+		}
+	}
+
+	seedResources(t, h,
+		`{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"}`,
+		`{"id": 2, "firstName": "Bob", "lastName": "Babbage", "position": "Consultant", "clearance": "None", "active": true, "sex": "Male"}`,
+	)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-27", 40, `"NSX"`), http.StatusCreated)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		want   []string
+	}{
+		{"accepted assignment created", http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 1, "hoursPerWeek": 20, "status": "accepted"}`, http.StatusCreated, []string{"open > partially_filled"}},
+		{"proposal created", http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 2, "hoursPerWeek": 20}`, http.StatusCreated, []string{}},
+		{"proposal accepted", http.MethodPost, "/v1/requests/1/assignments/2/transitions", `{"status": "accepted"}`, http.StatusOK, []string{"partially_filled > filled"}},
+		{"assignment hours reduced", http.MethodPatch, "/v1/requests/1/assignments/1", `{"hoursPerWeek": 10}`, http.StatusOK, []string{"filled > partially_filled"}},
+		{"request hours reduced", http.MethodPatch, "/v1/requests/1", `{"hoursPerWeek": 30}`, http.StatusOK, []string{"partially_filled > filled"}},
+		{"assignment deleted", http.MethodDelete, "/v1/requests/1/assignments/2", "", http.StatusOK, []string{"filled > partially_filled"}},
+		{"assignment deleted in a batch", http.MethodPost, "/v1/batch", batchBody(`{"op": "delete", "type": "assignment", "id": 1, "resourceId": 1}`), http.StatusOK, []string{"partially_filled > open"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mustDo(t, h, tt.method, tt.target, tt.body, tt.status)
+
+			if got := moves(t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("status changes %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateAssignmentRequestStatus(t *testing.T) {
+	// Each case creates request 1 for 40 hours a week, as a draft or open,
+	// has resource 2 fill the given hours and then moves it by hand.
+	tests := []struct {
+		status      string
+		draft       bool
+		fill        int
+		transitions []string
+		allow       bool
+	}{
+		{status: data.RequestDraft, draft: true},
+		{status: data.RequestOpen, allow: true},
+		{status: data.RequestPartiallyFilled, fill: 20, allow: true},
+		{status: data.RequestFilled, fill: 40, allow: true},
+		{status: data.RequestInDelivery, fill: 40, transitions: []string{`{"status": "in_delivery"}`}},
+		{status: data.RequestClosed, fill: 40, transitions: []string{`{"status": "in_delivery"}`, `{"status": "closed"}`}},
+		{status: data.RequestOnHold, transitions: []string{`{"status": "on_hold", "reason": "budget"}`}},
+		{status: data.RequestCancelled, transitions: []string{`{"status": "cancelled", "reason": "lost"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			app := newTestApplication(t)
+			h := app.routes()
+
+			seedResources(t, h,
+				`{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"}`,
+				`{"id": 2, "firstName": "Bob", "lastName": "Babbage", "position": "Consultant", "clearance": "None", "active": true, "sex": "Male"}`,
+			)
+
+			body := requestBody("Acme", "2026-11-02", "2026-11-27", 40, `"NSX"`)
+			if tt.draft {
+				body = strings.TrimSuffix(body, "}") + `, "status": "draft"}`
+			}
+			mustDo(t, h, http.MethodPost, "/v1/requests", body, http.StatusCreated)
+
+			if tt.fill > 0 {
+				mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", fmt.Sprintf(`{"resourceId": 2, "hoursPerWeek": %d, "status": "accepted"}`, tt.fill), http.StatusCreated)
+			}
+			for _, transition := range tt.transitions {
+				mustDo(t, h, http.MethodPost, "/v1/requests/1/transitions", transition, http.StatusOK)
+			}
+
+			rr := mustDo(t, h, http.MethodGet, "/v1/requests/1", "", http.StatusOK)
+			var shown struct {
+				Request data.ResourceRequest `json:"request"`
+			}
+			decodeJSON(t, rr, &shown)
+			if shown.Request.Status != tt.status {
+				t.Fatalf("request status %s after setup, want %s", shown.Request.Status, tt.status)
+			}
+
+			assignment := `{"resourceId": 1, "hoursPerWeek": 1}`
+			batch := batchBody(`{"op": "create", "type": "assignment", "id": 1, "data": ` + assignment + `}`)
+
+			if tt.allow {
+				mustDo(t, h, http.MethodPost, "/v1/batch", batch, http.StatusOK)
+				mustDo(t, h, http.MethodDelete, "/v1/requests/1/assignments/1", "", http.StatusOK)
+				mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", assignment, http.StatusCreated)
+				return
+			}
+
+			for _, c := range []struct{ target, body, field string }{
+				{"/v1/requests/1/assignments", assignment, "request"},
+				{"/v1/batch", batch, "operations[0].request"},
+			} {
+				rr := mustDo(t, h, http.MethodPost, c.target, c.body, http.StatusUnprocessableEntity)
+
+				var p problem
+				decodeJSON(t, rr, &p)
+				if _, ok := p.Errors[c.field]; !ok {
+					t.Errorf("POST %s errors %v, want one for %s", c.target, p.Errors, c.field)
+				}
+			}
+
+			if _, err := app.models.ResourceAssignments.Get(context.Background(), 1, 1); !errors.Is(err, data.ErrNotFound) {
+				t.Errorf("assignment to a %s request: error %v, want ErrNotFound", tt.status, err)
+			}
+		})
+	}
+}
+
+func TestTransitionAssignmentInvalid(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	seedResources(t, h, `{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"}`)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-27", 40, `"NSX"`), http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 1, "status": "accepted"}`, http.StatusCreated)
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments/1/transitions", `{"status": "shortlisted"}`, http.StatusUnprocessableEntity)
+
+	var p problem
+	decodeJSON(t, rr, &p)
+	if want := "cannot change from accepted to shortlisted; it can change to withdrawn"; p.Errors["status"] != want {
+		t.Errorf("status error %q, want %q", p.Errors["status"], want)
+	}
+}
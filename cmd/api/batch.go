@@ -0,0 +1,571 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// maxBatchOperations bounds how much work, and how long a transaction, one
+// batch may ask for.
+const maxBatchOperations = 100
+
+// batchID is an id in a batch operation: either a number or "$name", naming
+// the record created by an earlier operation with that ref.
+type batchID struct {
+	id  int64
+	ref string
+}
+
+func (b *batchID) UnmarshalJSON(js []byte) error {
+	var s string
+	if err := json.Unmarshal(js, &s); err == nil {
+		if !strings.HasPrefix(s, "$") || len(s) < 2 {
+			return errors.New(`body contains an id that is neither a number nor "$ref"`)
+		}
+		b.ref = s[1:]
+		return nil
+	}
+
+	return json.Unmarshal(js, &b.id)
+}
+
+// batchOperation is one create, update or delete. Ids mirror the paths of the
+// equivalent single requests: id is the resource's or the request's, and an
+// assignment is named by its request's id and its resourceId.
+type batchOperation struct {
+	Op         string          `json:"op"`
+	Type       string          `json:"type"`
+	Ref        string          `json:"ref"`
+	ID         *batchID        `json:"id"`
+	ResourceID *batchID        `json:"resourceId"`
+	Data       json.RawMessage `json:"data"`
+
+	// input is Data decoded into the body the single request would take.
+	input any
+}
+
+// batchFailure is why a batch was rolled back: the operation at index failed
+// with err.
+type batchFailure struct {
+	index int
+	err   error
+}
+
+func (f *batchFailure) Error() string {
+	return fmt.Sprintf("operation %d: %v", f.index, f.err)
+}
+
+func (f *batchFailure) Unwrap() error {
+	return f.err
+}
+
+// batchInvalid is a failed operation's validation errors.
+type batchInvalid map[string]string
+
+func (e batchInvalid) Error() string {
+	return "invalid operation"
+}
+
+// batchOverAllocated is the conflicts of an operation that would
+// over-allocate resources.
+type batchOverAllocated []*data.AllocationConflict
+
+func (e batchOverAllocated) Error() string {
+	return "operation over-allocates resources"
+}
+
+// batch runs a list of operations in one transaction, remembering the ids
+// refs name and what to publish once the transaction commits.
+type batch struct {
+	app    *application
+	models *data.Models
+	refs   map[string]int64
+	user   string
+	// force applies operations that over-allocate resources.
+	force   bool
+	results []envelope
+	after   []func()
+}
+
+func (app *application) handleBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Operations []*batchOperation `json:"operations"`
+		}
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		v := validator.New()
+
+		force := app.readForce(r, v)
+
+		if validateBatch(v, input.Operations); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		b := &batch{app: app, refs: make(map[string]int64), user: app.contextGetUser(r), force: force}
+
+		err = app.models.WithTx(r.Context(), func(tx *data.Models) error {
+			b.models = tx
+
+			for i, op := range input.Operations {
+				if err := b.run(r.Context(), op); err != nil {
+					return &batchFailure{index: i, err: err}
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			var failure *batchFailure
+			if !errors.As(err, &failure) {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			prefix := fmt.Sprintf("operations[%d].", failure.index)
+
+			var invalid batchInvalid
+			var overAllocated batchOverAllocated
+			switch {
+			case errors.As(err, &invalid):
+				errs := make(map[string]string, len(invalid))
+				for field, message := range invalid {
+					errs[prefix+field] = message
+				}
+				app.failedValidationResponse(w, r, errs)
+			case errors.Is(err, data.ErrNotFound):
+				app.failedValidationResponse(w, r, map[string]string{prefix + "id": "does not exist"})
+			case errors.As(err, &overAllocated):
+				app.problemResponse(w, r, problem{
+					Type:      problemOverAllocated,
+					Title:     "Over-allocated",
+					Status:    http.StatusConflict,
+					Detail:    fmt.Sprintf("operation %d would assign resources more hours than they have in some weeks; repeat the batch with force=true to apply it anyway", failure.index),
+					Conflicts: overAllocated,
+				})
+			case errors.Is(err, data.ErrEditConflict):
+				app.problemResponse(w, r, problem{
+					Type:   problemEditConflict,
+					Title:  "Edit conflict",
+					Status: http.StatusConflict,
+					Detail: fmt.Sprintf("operation %d could not be applied due to an edit conflict, please try again", failure.index),
+				})
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		for _, publish := range b.after {
+			publish()
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"results": b.results}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// validateBatch checks the shape of every operation and decodes its data, so
+// that a malformed batch is rejected before any of it runs.
+func validateBatch(v *validator.Validator, ops []*batchOperation) {
+	v.Check(len(ops) > 0, "operations", "must contain at least one operation")
+	v.Check(len(ops) <= maxBatchOperations, "operations", fmt.Sprintf("must not contain more than %d operations", maxBatchOperations))
+
+	refs := make(map[string]bool)
+
+	for i, op := range ops {
+		key := func(field string) string {
+			return fmt.Sprintf("operations[%d].%s", i, field)
+		}
+
+		if op == nil {
+			v.AddError(key("op"), "must be provided")
+			continue
+		}
+
+		knownOp := validator.PermittedValue(op.Op, "create", "update", "delete")
+		knownType := validator.PermittedValue(op.Type, "resource", "request", "assignment")
+
+		v.Check(knownOp, key("op"), "must be one of create, update or delete")
+		v.Check(knownType, key("type"), "must be one of resource, request or assignment")
+		if !knownOp || !knownType {
+			continue
+		}
+
+		checkID := func(field string, id *batchID) {
+			switch {
+			case id == nil:
+				v.AddError(key(field), "must be provided")
+			case id.ref != "":
+				v.Check(refs[id.ref], key(field), "must name the ref of an earlier create")
+			default:
+				v.Check(id.id > 0, key(field), "must be a positive integer")
+			}
+		}
+
+		// Created resources and requests take their ids from their data or
+		// the database; everything else is addressed by id, and assignments
+		// by their resource too.
+		if op.Op == "create" && op.Type != "assignment" {
+			v.Check(op.ID == nil, key("id"), "must not be provided for a create")
+		} else {
+			checkID("id", op.ID)
+		}
+		if op.Type == "assignment" && op.Op != "create" {
+			checkID("resourceId", op.ResourceID)
+		} else {
+			v.Check(op.ResourceID == nil, key("resourceId"), "must only be provided to update or delete an assignment")
+		}
+
+		if op.Ref != "" {
+			v.Check(op.Op == "create" && op.Type != "assignment", key("ref"), "can only name a created resource or request")
+			v.Check(!refs[op.Ref], key("ref"), "must be unique")
+			refs[op.Ref] = true
+		}
+
+		switch op.Op + " " + op.Type {
+		case "create resource":
+			op.input = &resourceInput{}
+		case "update resource":
+			op.input = &resourceUpdate{}
+		case "create request":
+			op.input = &requestInput{}
+		case "update request":
+			op.input = &requestUpdate{}
+		case "create assignment":
+			op.input = &assignmentInput{}
+		case "update assignment":
+			op.input = &assignmentUpdate{}
+		default:
+			v.Check(len(op.Data) == 0, key("data"), "must not be provided for a delete")
+			continue
+		}
+
+		if len(op.Data) == 0 {
+			v.AddError(key("data"), "must be provided")
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(op.Data))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(op.input); err != nil {
+			message := err.Error()
+			if field, ok := strings.CutPrefix(message, "json: unknown field "); ok {
+				message = "contains unknown key " + field
+			} else {
+				var typeErr *json.UnmarshalTypeError
+				if errors.As(err, &typeErr) && typeErr.Field != "" {
+					message = fmt.Sprintf("contains incorrect JSON type for field %q", typeErr.Field)
+				}
+			}
+			v.AddError(key("data"), message)
+		}
+	}
+}
+
+func (b *batch) id(id *batchID) int64 {
+	if id.ref != "" {
+		return b.refs[id.ref]
+	}
+	return id.id
+}
+
+// run applies one operation the way its single request would, with the same
+// validation and the same events once the batch commits.
+func (b *batch) run(ctx context.Context, op *batchOperation) error {
+	result := envelope{"op": op.Op, "type": op.Type, "status": http.StatusOK}
+
+	switch op.Op + " " + op.Type {
+	case "create resource":
+		resource := op.input.(*resourceInput).resource(int64(b.app.cfg.allocation.capacity))
+
+		if err := checkOperation(func(v *validator.Validator) { data.ValidateResource(v, resource) }); err != nil {
+			return err
+		}
+
+		if _, err := b.models.Resources.Get(ctx, resource.ID); err == nil {
+			return batchInvalid{"data.id": "is already in use"}
+		} else if !errors.Is(err, data.ErrNotFound) {
+			return err
+		}
+
+		if err := b.models.Resources.Insert(ctx, &resource); err != nil {
+			return err
+		}
+
+		b.later(data.EventResourceCreated, envelope{"resource": resource})
+		result["status"] = http.StatusCreated
+		result["resource"] = resource
+
+		if op.Ref != "" {
+			b.refs[op.Ref] = resource.ID
+		}
+
+	case "update resource":
+		resource, err := b.models.Resources.Get(ctx, b.id(op.ID))
+		if err != nil {
+			return err
+		}
+
+		wasActive := resource.Active
+		op.input.(*resourceUpdate).apply(resource)
+
+		if err := checkOperation(func(v *validator.Validator) { data.ValidateResource(v, *resource) }); err != nil {
+			return err
+		}
+
+		if err := b.models.Resources.Update(ctx, resource); err != nil {
+			return err
+		}
+
+		b.later(data.EventResourceUpdated, envelope{"resource": resource})
+		if wasActive && !resource.Active {
+			b.later(data.EventResourceDeactivated, envelope{"resource": resource})
+		}
+		result["resource"] = resource
+
+	case "delete resource":
+		id := b.id(op.ID)
+		if err := b.models.Resources.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		b.later(data.EventResourceDeleted, envelope{"resourceId": id})
+		result["resourceId"] = id
+
+	case "create request":
+		rr := op.input.(*requestInput).request()
+
+		if err := checkOperation(func(v *validator.Validator) {
+			data.ValidateResourceRequest(v, *rr)
+			validateNewRequestStatus(v, rr)
+		}); err != nil {
+			return err
+		}
+
+		if err := b.models.ResourceRequests.Insert(ctx, rr); err != nil {
+			return err
+		}
+
+		b.later(data.EventRequestCreated, envelope{"request": rr})
+		result["status"] = http.StatusCreated
+		result["request"] = rr
+
+		if op.Ref != "" {
+			b.refs[op.Ref] = rr.ID
+		}
+
+	case "update request":
+		rr, err := b.models.ResourceRequests.Get(ctx, b.id(op.ID))
+		if err != nil {
+			return err
+		}
+
+		start, end := rr.StartDate, rr.EndDate
+
+		op.input.(*requestUpdate).apply(rr)
+
+		if err := checkOperation(func(v *validator.Validator) { data.ValidateResourceRequest(v, *rr) }); err != nil {
+			return err
+		}
+
+		if !rr.StartDate.Equal(start) || !rr.EndDate.Equal(end) {
+			conflicts, err := b.app.checkRequestAllocation(ctx, b.models, rr)
+			if err != nil {
+				return err
+			}
+			if err := b.allowAllocation(result, conflicts); err != nil {
+				return err
+			}
+		}
+
+		moved, err := b.models.ResourceRequests.Update(ctx, rr)
+		if err != nil {
+			return err
+		}
+
+		b.later(data.EventRequestUpdated, envelope{"request": rr})
+		if moved != nil {
+			b.later(data.EventRequestStatusChanged, envelope{"request": rr, "transition": moved})
+		}
+		result["request"] = rr
+
+	case "delete request":
+		id := b.id(op.ID)
+		if err := b.models.ResourceRequests.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		b.later(data.EventRequestDeleted, envelope{"requestId": id})
+		result["requestId"] = id
+
+	case "create assignment":
+		rr, err := b.models.ResourceRequests.Get(ctx, b.id(op.ID))
+		if err != nil {
+			return err
+		}
+
+		v := validator.New()
+		if validateStaffing(v, rr); !v.Valid() {
+			return batchInvalid(v.Errors)
+		}
+
+		assignment := op.input.(*assignmentInput).assignment(rr, b.user)
+
+		if err := checkOperation(func(v *validator.Validator) {
+			data.ValidateResourceAssignment(v, *assignment)
+			validateNewAssignmentStatus(v, assignment)
+		}); err != nil {
+			return err
+		}
+
+		resource, err := b.models.Resources.Get(ctx, assignment.ResourceID)
+		if err != nil {
+			if errors.Is(err, data.ErrNotFound) {
+				return batchInvalid{"data.resourceId": "does not exist"}
+			}
+			return err
+		}
+
+		if _, err := b.models.ResourceAssignments.Get(ctx, rr.ID, resource.ID); err == nil {
+			return batchInvalid{"data.resourceId": "is already assigned to this request"}
+		} else if !errors.Is(err, data.ErrNotFound) {
+			return err
+		}
+
+		if err := b.checkAllocation(ctx, result, assignment); err != nil {
+			return err
+		}
+
+		moved, err := b.models.ResourceAssignments.Insert(ctx, assignment)
+		if err != nil {
+			return err
+		}
+
+		b.later(data.EventAssignmentCreated, envelope{"assignment": assignment})
+		if err := b.laterFillTransition(ctx, moved); err != nil {
+			return err
+		}
+		if assignment.Status == data.AssignmentAccepted {
+			b.after = append(b.after, func() { b.app.notifyAssignment(resource, rr, assignment) })
+		}
+		result["status"] = http.StatusCreated
+		result["assignment"] = assignment
+
+	case "update assignment":
+		assignment, err := b.models.ResourceAssignments.Get(ctx, b.id(op.ID), b.id(op.ResourceID))
+		if err != nil {
+			return err
+		}
+
+		op.input.(*assignmentUpdate).apply(assignment)
+
+		if err := checkOperation(func(v *validator.Validator) { data.ValidateResourceAssignment(v, *assignment) }); err != nil {
+			return err
+		}
+
+		if err := b.checkAllocation(ctx, result, assignment); err != nil {
+			return err
+		}
+
+		moved, err := b.models.ResourceAssignments.Update(ctx, assignment)
+		if err != nil {
+			return err
+		}
+
+		b.later(data.EventAssignmentUpdated, envelope{"assignment": assignment})
+		if err := b.laterFillTransition(ctx, moved); err != nil {
+			return err
+		}
+		result["assignment"] = assignment
+
+	case "delete assignment":
+		requestID, resourceID := b.id(op.ID), b.id(op.ResourceID)
+		moved, err := b.models.ResourceAssignments.Delete(ctx, requestID, resourceID)
+		if err != nil {
+			return err
+		}
+
+		b.later(data.EventAssignmentDeleted, envelope{"requestId": requestID, "resourceId": resourceID})
+		if err := b.laterFillTransition(ctx, moved); err != nil {
+			return err
+		}
+		result["requestId"] = requestID
+		result["resourceId"] = resourceID
+	}
+
+	b.results = append(b.results, result)
+
+	return nil
+}
+
+// checkAllocation fails the operation if assignment, as it would be after
+// the operation, over-allocates its resource and the batch may not.
+func (b *batch) checkAllocation(ctx context.Context, result envelope, assignment *data.ResourceAssignment) error {
+	conflicts, err := b.app.checkAllocation(ctx, b.models, []*data.ResourceAssignment{assignment}, nil)
+	if err != nil {
+		return err
+	}
+	return b.allowAllocation(result, conflicts)
+}
+
+// allowAllocation fails an operation causing conflicts unless they only
+// warn or the batch is forced, and otherwise adds them to its result.
+func (b *batch) allowAllocation(result envelope, conflicts []*data.AllocationConflict) error {
+	if !b.app.allowAllocation(conflicts, b.force) {
+		return batchOverAllocated(conflicts)
+	}
+	if len(conflicts) > 0 {
+		result["conflicts"] = conflicts
+	}
+	return nil
+}
+
+// later queues an event to publish if the batch commits.
+func (b *batch) later(kind string, payload envelope) {
+	b.after = append(b.after, func() { b.app.publishEvent(kind, payload) })
+}
+
+// laterFillTransition queues the request.status_changed event for moved, the
+// transition an assignment change made, if there was one.
+func (b *batch) laterFillTransition(ctx context.Context, moved *data.RequestTransition) error {
+	payload, err := fillTransitionEvent(ctx, b.models, moved)
+	if err != nil || payload == nil {
+		return err
+	}
+
+	b.later(data.EventRequestStatusChanged, payload)
+	return nil
+}
+
+// checkOperation runs check and returns its errors, keyed under data, as the
+// operation's error.
+func checkOperation(check func(v *validator.Validator)) error {
+	v := validator.New()
+	if check(v); v.Valid() {
+		return nil
+	}
+
+	invalid := make(batchInvalid, len(v.Errors))
+	for field, message := range v.Errors {
+		invalid["data."+field] = message
+	}
+	return invalid
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+// subscribeAll returns a function listing the kinds of the events published
+// since it was called.
+func subscribeAll(app *application) func() []string {
+	sub, _, _ := app.events.subscribe(0, func(string) bool { return true })
+
+	return func() []string {
+		kinds := []string{}
+		for {
+			select {
+			case e := <-sub.ch:
+				kinds = append(kinds, e.kind)
+			default:
+				return kinds
+			}
+		}
+	}
+}
+
+// batchBody is the JSON for a batch of the given operations.
+func batchBody(ops ...string) string {
+	return `{"operations": [` + strings.Join(ops, ", ") + `]}`
+}
+
+const batchResource = `{"op": "create", "type": "resource", "data": {"id": 7, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"}}`
+
+func TestBatch(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+	events := subscribeAll(app)
+
+	body := batchBody(
+		batchResource,
+		`{"op": "create", "type": "request", "ref": "acme", "data": {"customer": "Acme", "startDate": "2026-11-02T00:00:00Z", "endDate": "2026-11-27T00:00:00Z", "hoursPerWeek": 40, "skills": ["NSX"]}}`,
+		`{"op": "create", "type": "assignment", "id": "$acme", "data": {"resourceId": 7, "hoursPerWeek": 40, "status": "accepted"}}`,
+		`{"op": "update", "type": "assignment", "id": "$acme", "resourceId": 7, "data": {"hoursPerWeek": 20}}`,
+		`{"op": "update", "type": "request", "id": "$acme", "data": {"customer": "Acme Bank"}}`,
+	)
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/batch", body, http.StatusOK)
+
+	var result struct {
+		Results []struct {
+			Status     int                      `json:"status"`
+			Request    *data.ResourceRequest    `json:"request"`
+			Assignment *data.ResourceAssignment `json:"assignment"`
+		} `json:"results"`
+	}
+	decodeJSON(t, rr, &result)
+
+	var statuses []int
+	for _, r := range result.Results {
+		statuses = append(statuses, r.Status)
+	}
+	if want := []int{201, 201, 201, 200, 200}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses %v, want %v", statuses, want)
+	}
+
+	requestID := result.Results[1].Request.ID
+	if requestID < 1 {
+		t.Fatalf("created request id %d", requestID)
+	}
+	if got := result.Results[2].Assignment.ResourceRequestID; got != requestID {
+		t.Errorf("$acme resolved to request %d, want %d", got, requestID)
+	}
+
+	assignment, err := app.models.ResourceAssignments.Get(context.Background(), requestID, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assignment.HoursPerWeek != 20 {
+		t.Errorf("assignment hours %d, want 20", assignment.HoursPerWeek)
+	}
+
+	saved, err := app.models.ResourceRequests.Get(context.Background(), requestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Customer != "Acme Bank" || saved.Status != data.RequestPartiallyFilled {
+		t.Errorf("request customer %q status %s, want %q %s", saved.Customer, saved.Status, "Acme Bank", data.RequestPartiallyFilled)
+	}
+
+	want := []string{
+		data.EventResourceCreated,
+		data.EventRequestCreated,
+		data.EventAssignmentCreated,
+		data.EventAssignmentUpdated,
+		data.EventRequestUpdated,
+	}
+	if got := events(); !containsInOrder(got, want) {
+		t.Errorf("events %v, want %v in order", got, want)
+	}
+}
+
+// containsInOrder reports whether want is a subsequence of got.
+func containsInOrder(got, want []string) bool {
+	for _, kind := range got {
+		if len(want) > 0 && kind == want[0] {
+			want = want[1:]
+		}
+	}
+	return len(want) == 0
+}
+
+func TestBatchFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		query  string
+		status int
+		errors map[string]string
+		detail string
+	}{
+		{
+			name: "later operation not found",
+			body: batchBody(
+				batchResource,
+				`{"op": "update", "type": "resource", "id": 99, "data": {"firstName": "Bob"}}`,
+			),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"operations[1].id": "does not exist"},
+		},
+		{
+			name: "later operation invalid",
+			body: batchBody(
+				batchResource,
+				`{"op": "create", "type": "request", "data": {"customer": "", "startDate": "2026-11-02T00:00:00Z", "endDate": "2026-11-27T00:00:00Z", "hoursPerWeek": 40, "skills": ["NSX"]}}`,
+			),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"operations[1].data.customer": "must be provided"},
+		},
+		{
+			name: "duplicate in a later operation",
+			body: batchBody(
+				batchResource,
+				strings.Replace(batchResource, `"Ada"`, `"Bob"`, 1),
+			),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"operations[1].data.id": "is already in use"},
+		},
+		{
+			name: "later operation over-allocates",
+			body: batchBody(
+				batchResource,
+				`{"op": "create", "type": "request", "ref": "a", "data": {"customer": "Acme", "startDate": "2026-11-02T00:00:00Z", "endDate": "2026-11-27T00:00:00Z", "hoursPerWeek": 40, "skills": ["NSX"]}}`,
+				`{"op": "create", "type": "request", "ref": "b", "data": {"customer": "Globex", "startDate": "2026-11-16T00:00:00Z", "endDate": "2026-12-11T00:00:00Z", "hoursPerWeek": 40, "skills": ["NSX"]}}`,
+				`{"op": "create", "type": "assignment", "id": "$a", "data": {"resourceId": 7, "hoursPerWeek": 30, "status": "accepted"}}`,
+				`{"op": "create", "type": "assignment", "id": "$b", "data": {"resourceId": 7, "hoursPerWeek": 30, "status": "accepted"}}`,
+			),
+			status: http.StatusConflict,
+			detail: "operation 4 would assign resources more hours",
+		},
+		{
+			name:   "unknown ref",
+			body:   batchBody(`{"op": "update", "type": "request", "id": "$missing", "data": {"customer": "Acme"}}`),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"operations[0].id": "must name the ref of an earlier create"},
+		},
+		{
+			name: "ref used before it is created",
+			body: batchBody(
+				`{"op": "create", "type": "assignment", "id": "$later", "data": {"resourceId": 7, "hoursPerWeek": 20}}`,
+				`{"op": "create", "type": "request", "ref": "later", "data": {"customer": "Acme", "startDate": "2026-11-02T00:00:00Z", "endDate": "2026-11-27T00:00:00Z", "hoursPerWeek": 40, "skills": ["NSX"]}}`,
+			),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"operations[0].id": "must name the ref of an earlier create"},
+		},
+		{
+			name: "malformed operations",
+			body: batchBody(
+				`{"op": "rename", "type": "resource"}`,
+				`{"op": "delete", "type": "request", "id": 1, "data": {}}`,
+				`{"op": "create", "type": "resource", "data": {"id": 8, "colour": "blue"}}`,
+			),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{
+				"operations[0].op":   "must be one of create, update or delete",
+				"operations[1].data": "must not be provided for a delete",
+				"operations[2].data": `contains unknown key "colour"`,
+			},
+		},
+		{
+			name:   "invalid force",
+			body:   batchBody(batchResource),
+			query:  "?force=sometimes",
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"force": "must be true or false"},
+		},
+		{
+			name:   "no operations",
+			body:   batchBody(),
+			status: http.StatusUnprocessableEntity,
+			errors: map[string]string{"operations": "must contain at least one operation"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			h := app.routes()
+			events := subscribeAll(app)
+
+			rr := mustDo(t, h, http.MethodPost, "/v1/batch"+tt.query, tt.body, tt.status)
+
+			var p problem
+			decodeJSON(t, rr, &p)
+
+			for field, message := range tt.errors {
+				if p.Errors[field] != message {
+					t.Errorf("errors[%q] = %q, want %q (all: %v)", field, p.Errors[field], message, p.Errors)
+				}
+			}
+			if !strings.Contains(p.Detail, tt.detail) {
+				t.Errorf("detail %q, want it to contain %q", p.Detail, tt.detail)
+			}
+
+			// Nothing the batch did before failing is kept or announced.
+			if _, err := app.models.Resources.Get(context.Background(), 7); !errors.Is(err, data.ErrNotFound) {
+				t.Errorf("resource 7 after rollback: error %v, want ErrNotFound", err)
+			}
+			requests, _, err := app.models.ResourceRequests.GetAll(context.Background(), "", nil, nil, data.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(requests) != 0 {
+				t.Errorf("%d requests after rollback, want none", len(requests))
+			}
+			if got := events(); len(got) != 0 {
+				t.Errorf("events %v published for a rolled back batch", got)
+			}
+		})
+	}
+}
+
+func TestBatchForce(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	body := batchBody(
+		batchResource,
+		`{"op": "create", "type": "request", "ref": "a", "data": {"customer": "Acme", "startDate": "2026-11-02T00:00:00Z", "endDate": "2026-11-27T00:00:00Z", "hoursPerWeek": 40, "skills": ["NSX"]}}`,
+		`{"op": "create", "type": "assignment", "id": "$a", "data": {"resourceId": 7, "hoursPerWeek": 50, "status": "accepted"}}`,
+	)
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/batch?force=true", body, http.StatusOK)
+
+	var result struct {
+		Results []struct {
+			Conflicts []*data.AllocationConflict `json:"conflicts"`
+		} `json:"results"`
+	}
+	decodeJSON(t, rr, &result)
+
+	if len(result.Results) != 3 || len(result.Results[2].Conflicts) == 0 {
+		t.Errorf("results %s, want the forced assignment's conflicts", rr.Body)
+	}
+}
+
+func TestBatchLimit(t *testing.T) {
+	resource := func(id int) string {
+		return fmt.Sprintf(`{"op": "create", "type": "resource", "data": {"id": %d, "firstName": "R", "lastName": "%d", "position": "Consultant", "clearance": "None", "active": true, "sex": "Unknown"}}`, id, id)
+	}
+
+	for _, n := range []int{maxBatchOperations, maxBatchOperations + 1} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			app := newTestApplication(t)
+			h := app.routes()
+
+			ops := make([]string, n)
+			for i := range ops {
+				ops[i] = resource(i + 1)
+			}
+
+			rr := do(t, h, http.MethodPost, "/v1/batch", batchBody(ops...))
+
+			if n <= maxBatchOperations {
+				if rr.Code != http.StatusOK {
+					t.Fatalf("status %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+				}
+				return
+			}
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var p problem
+			decodeJSON(t, rr, &p)
+
+			if want := fmt.Sprintf("must not contain more than %d operations", maxBatchOperations); p.Errors["operations"] != want {
+				t.Errorf("errors %v, want operations: %q", p.Errors, want)
+			}
+			if _, err := app.models.Resources.Get(context.Background(), 1); !errors.Is(err, data.ErrNotFound) {
+				t.Errorf("resource 1 created by a rejected batch: error %v", err)
+			}
+		})
+	}
+}
+
+func TestBatchPublishesAfterCommit(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	// A batch holds the memory store's lock until it commits, so reading the
+	// created resource from inside publish only returns once the batch has
+	// committed. If publish ran first, the read would wait for it forever.
+	committed := make(chan error, 1)
+	sub, _, _ := app.events.subscribe(0, func(kind string) bool {
+		if kind != data.EventResourceCreated {
+			return false
+		}
+
+		read := make(chan error, 1)
+		go func() {
+			_, err := app.models.Resources.Get(context.Background(), 7)
+			read <- err
+		}()
+
+		select {
+		case err := <-read:
+			committed <- err
+		case <-time.After(time.Second):
+			committed <- errors.New("event published while the batch's transaction was open")
+		}
+		return true
+	})
+	defer app.events.unsubscribe(sub)
+
+	mustDo(t, h, http.MethodPost, "/v1/batch", batchBody(batchResource), http.StatusOK)
+
+	select {
+	case err := <-committed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatal("no resource.created event published")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cachePolicy says how clients may cache a route's GET responses.
+type cachePolicy struct {
+	// control is the Cache-Control header sent with successful responses.
+	control string
+	// validate adds an ETag to successful responses and answers
+	// If-None-Match and If-Modified-Since with 304 Not Modified.
+	validate bool
+}
+
+var (
+	// cacheReference lets clients reuse reference data for a minute before
+	// revalidating it.
+	cacheReference = cachePolicy{control: "private, max-age=60", validate: true}
+	// cacheRevalidate has clients check every time, which costs little once
+	// unchanged responses come back empty.
+	cacheRevalidate = cachePolicy{control: "private, no-cache", validate: true}
+	// cacheSpec covers documents that only change when the API is deployed.
+	cacheSpec = cachePolicy{control: "public, max-age=3600", validate: true}
+	cacheNone = cachePolicy{control: "no-store"}
+)
+
+// routeCachePolicies gives the cache policy of each GET route by pattern.
+// Routes without one, such as the event stream, set their own headers.
+var routeCachePolicies = map[string]cachePolicy{
+	"/v1/healthz":      cacheNone,
+	"/v1/readyz":       cacheNone,
+	"/v1/healthcheck":  cacheNone,
+	"/metrics":         cacheNone,
+	"/v1/openapi.json": cacheSpec,
+	"/v1/docs":         cacheSpec,
+	"/v1/graphql":      cacheNone,
+
+	"/v1/positions":      cacheReference,
+	"/v1/positions/:id":  cacheReference,
+	"/v1/clearances":     cacheReference,
+	"/v1/clearances/:id": cacheReference,
+
+	"/v1/resources":                                        cacheRevalidate,
+	"/v1/resources/:id":                                    cacheRevalidate,
+	"/v1/requests":                                         cacheRevalidate,
+	"/v1/requests/:id":                                     cacheRevalidate,
+	"/v1/requests/:id/transitions":                         cacheRevalidate,
+	"/v1/requests/:id/assignments":                         cacheRevalidate,
+	"/v1/requests/:id/assignments/:resourceId":             cacheRevalidate,
+	"/v1/requests/:id/assignments/:resourceId/transitions": cacheRevalidate,
+	"/v1/views":                                            cacheRevalidate,
+	"/v1/views/:id":                                        cacheRevalidate,
+	"/v1/webhooks":                                         cacheRevalidate,
+	"/v1/webhooks/:id":                                     cacheRevalidate,
+	"/v1/webhooks/:id/deliveries":                          cacheRevalidate,
+}
+
+// conditionalGET applies each route's cache policy to GET requests. For
+// routes that validate, the response is buffered so that its ETag can be
+// computed from the body; a request whose If-None-Match matches it, or
+// failing that whose If-Modified-Since is no earlier than the Last-Modified
+// the handler set, gets 304 Not Modified instead of the body. Only handlers
+// showing a single timestamped record set Last-Modified: a list's newest
+// change says nothing of the records deleted from it.
+func (app *application) conditionalGET(router *patternRouter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy, ok := routeCachePolicies[routePattern(router, r)]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !policy.validate {
+			w.Header().Set("Cache-Control", policy.control)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		if buf.status != http.StatusOK {
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(buf.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+		w.Header().Set("Cache-Control", policy.control)
+		w.Header().Set("ETag", etag)
+
+		if notModified(r, etag, w.Header().Get("Last-Modified")) {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.body.Bytes())
+	})
+}
+
+// notModified evaluates a GET request's preconditions against the response's
+// validators. If-None-Match takes precedence over If-Modified-Since, as RFC
+// 9110 requires, and compares tags weakly.
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified == "" {
+		return false
+	}
+
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+// lastModified returns the Last-Modified header for a record updated at t,
+// for passing to writeJSON.
+func lastModified(t time.Time) http.Header {
+	return http.Header{"Last-Modified": []string{t.UTC().Format(http.TimeFormat)}}
+}
+
+// bufferedResponse holds a handler's status and body until conditionalGET
+// decides what to send. Headers go straight to the real response.
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
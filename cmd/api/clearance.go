@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
@@ -30,7 +31,7 @@ func (app *application) handleCreateClearance() http.HandlerFunc {
 			return
 		}
 
-		err = app.models.Clearances.Insert(clearance)
+		err = app.models.Clearances.Insert(r.Context(), clearance)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
@@ -42,3 +43,128 @@ func (app *application) handleCreateClearance() http.HandlerFunc {
 		}
 	}
 }
+
+func (app *application) handleListClearances() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clearances, err := app.models.Clearances.GetAll(r.Context())
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"clearances": clearances}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleShowClearance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		clearance, err := app.models.Clearances.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"clearance": clearance}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleUpdateClearance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		clearance, err := app.models.Clearances.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		var input struct {
+			Description *string `json:"description"`
+		}
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if input.Description != nil {
+			clearance.Description = *input.Description
+		}
+
+		v := validator.New()
+
+		if data.ValidateDescription(v, clearance.Description); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.Clearances.Update(r.Context(), *clearance)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"clearance": clearance}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleDeleteClearance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		err = app.models.Clearances.Delete(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
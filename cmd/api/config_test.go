@@ -0,0 +1,271 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapEnv returns a lookupEnv backed by env.
+func mapEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	file := writeConfigFile(t, "dashboard.yaml", `
+port: 9001
+env: production
+smtp:
+  host: mail.file
+  port: 2525
+webhook-max-attempts: 7
+sse:
+  heartbeat: 20s
+`)
+
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		port        int
+		envName     string
+		smtpHost    string
+		smtpPort    int
+		maxAttempts int
+		heartbeat   time.Duration
+		sources     map[string]string
+	}{
+		{
+			name:        "defaults",
+			port:        8086,
+			envName:     "development",
+			smtpPort:    25,
+			maxAttempts: 5,
+			heartbeat:   15 * time.Second,
+			sources:     map[string]string{"port": "", "env": "", "smtp-host": ""},
+		},
+		{
+			name:        "file over defaults",
+			args:        []string{"-config", file},
+			port:        9001,
+			envName:     "production",
+			smtpHost:    "mail.file",
+			smtpPort:    2525,
+			maxAttempts: 7,
+			heartbeat:   20 * time.Second,
+			sources:     map[string]string{"port": "file", "smtp-host": "file", "sse-heartbeat": "file", "sse-retry": ""},
+		},
+		{
+			name:        "file named by the environment",
+			env:         map[string]string{"DASHBOARD_CONFIG": file},
+			port:        9001,
+			envName:     "production",
+			smtpHost:    "mail.file",
+			smtpPort:    2525,
+			maxAttempts: 7,
+			heartbeat:   20 * time.Second,
+			sources:     map[string]string{"port": "file"},
+		},
+		{
+			name: "environment over file",
+			args: []string{"-config", file},
+			env: map[string]string{
+				"DASHBOARD_PORT":                 "9002",
+				"DASHBOARD_SMTP_HOST":            "mail.env",
+				"DASHBOARD_WEBHOOK_MAX_ATTEMPTS": "8",
+			},
+			port:        9002,
+			envName:     "production",
+			smtpHost:    "mail.env",
+			smtpPort:    2525,
+			maxAttempts: 8,
+			heartbeat:   20 * time.Second,
+			sources:     map[string]string{"port": "env", "smtp-host": "env", "smtp-port": "file", "env": "file"},
+		},
+		{
+			name: "flags over environment and file",
+			args: []string{"-config", file, "-port", "9003", "-smtp-host", "mail.flag", "-sse-heartbeat", "5s"},
+			env: map[string]string{
+				"DASHBOARD_PORT":      "9002",
+				"DASHBOARD_SMTP_HOST": "mail.env",
+			},
+			port:        9003,
+			envName:     "production",
+			smtpHost:    "mail.flag",
+			smtpPort:    2525,
+			maxAttempts: 7,
+			heartbeat:   5 * time.Second,
+			sources:     map[string]string{"port": "flag", "smtp-host": "flag", "sse-heartbeat": "flag", "webhook-max-attempts": "file"},
+		},
+		{
+			name:        "flag set to its default still wins",
+			args:        []string{"-config", file, "-port", "8086"},
+			env:         map[string]string{"DASHBOARD_PORT": "9002"},
+			port:        8086,
+			envName:     "production",
+			smtpHost:    "mail.file",
+			smtpPort:    2525,
+			maxAttempts: 7,
+			heartbeat:   20 * time.Second,
+			sources:     map[string]string{"port": "flag"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(append([]string{"api"}, tt.args...), mapEnv(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.port != tt.port {
+				t.Errorf("port = %d, want %d", cfg.port, tt.port)
+			}
+			if cfg.env != tt.envName {
+				t.Errorf("env = %q, want %q", cfg.env, tt.envName)
+			}
+			if cfg.smtp.host != tt.smtpHost {
+				t.Errorf("smtp-host = %q, want %q", cfg.smtp.host, tt.smtpHost)
+			}
+			if cfg.smtp.port != tt.smtpPort {
+				t.Errorf("smtp-port = %d, want %d", cfg.smtp.port, tt.smtpPort)
+			}
+			if cfg.webhooks.maxAttempts != tt.maxAttempts {
+				t.Errorf("webhook-max-attempts = %d, want %d", cfg.webhooks.maxAttempts, tt.maxAttempts)
+			}
+			if cfg.sse.heartbeat != tt.heartbeat {
+				t.Errorf("sse-heartbeat = %s, want %s", cfg.sse.heartbeat, tt.heartbeat)
+			}
+
+			for name, want := range tt.sources {
+				if got := cfg.sources[name]; got != want {
+					t.Errorf("source of %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+		want string
+	}{
+		{"invalid flag value", []string{"-port", "http"}, nil, "", "invalid value"},
+		{"invalid environment value", nil, map[string]string{"DASHBOARD_PORT": "http"}, "", "DASHBOARD_PORT"},
+		{"unknown file setting", nil, nil, "colour: blue\n", `unknown setting "colour"`},
+		{"invalid file value", nil, nil, "port: http\n", `invalid value for "port"`},
+		{"failed validation", []string{"-port", "70000"}, nil, "", "port must be between 1 and 65535"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfigFile(t, "dashboard.yaml", tt.file)}, args...)
+			}
+
+			_, err := loadConfig(append([]string{"api"}, args...), mapEnv(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("loadConfig error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		want    []string
+		secrets []string
+	}{
+		{
+			name: "URL dsn",
+			args: []string{
+				"-dsn", "postgres://dashboard:hunter2@db/dashboard?sslmode=disable",
+				"-smtp-username", "mailer",
+				"-smtp-password", "s3cr3t-smtp",
+			},
+			env: map[string]string{"DASHBOARD_AUTH_TOKENS": "alice=tok-alice bob=tok-bob"},
+			want: []string{
+				"dsn: postgres://dashboard:xxxxx@db/dashboard?sslmode=disable # flag",
+				"smtp-password: xxxxx # flag",
+				"auth-tokens: alice=xxxxx bob=xxxxx # env",
+				"smtp-username: mailer # flag",
+			},
+			secrets: []string{"hunter2", "s3cr3t-smtp", "tok-alice", "tok-bob"},
+		},
+		{
+			name:    "key-value dsn",
+			args:    []string{"-dsn", "host=db user=dashboard password='hunter 2' dbname=dashboard"},
+			want:    []string{"password=xxxxx dbname=dashboard"},
+			secrets: []string{"hunter"},
+		},
+		{
+			name:    "tokens from a file",
+			args:    []string{"-store", "memory"},
+			file:    "auth-tokens:\n  carol: tok-carol\n  dave: tok-dave\n",
+			want:    []string{"auth-tokens: carol=xxxxx dave=xxxxx # file"},
+			secrets: []string{"tok-carol", "tok-dave"},
+		},
+		{
+			name: "empty secrets",
+			args: []string{"-store", "memory", "-dsn", ""},
+			want: []string{"smtp-password: # default", "auth-tokens: # default"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"api", "-print-config"}, tt.args...)
+			if tt.file != "" {
+				args = append(args, "-config", writeConfigFile(t, "dashboard.yaml", tt.file))
+			}
+
+			cfg, err := loadConfig(args, mapEnv(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cfg.printConfig {
+				t.Fatal("printConfig not set")
+			}
+
+			var out strings.Builder
+			if err := cfg.print(&out); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, out.String())
+				}
+			}
+			for _, secret := range tt.secrets {
+				if strings.Contains(out.String(), secret) {
+					t.Errorf("output contains secret %q:\n%s", secret, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/jsonlog"
+)
+
+type contextKey string
+
+const (
+	requestIDContextKey = contextKey("requestID")
+	loggerContextKey    = contextKey("logger")
+	userContextKey      = contextKey("user")
+)
+
+func (app *application) contextSetRequestID(r *http.Request, id string) *http.Request {
+	ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey).(string)
+	return id
+}
+
+func (app *application) contextSetLogger(r *http.Request, logger *jsonlog.Logger) *http.Request {
+	ctx := context.WithValue(r.Context(), loggerContextKey, logger)
+	return r.WithContext(ctx)
+}
+
+// contextGetLogger returns the request-scoped logger, falling back to the
+// application logger for requests that didn't pass through requestLogger.
+func (app *application) contextGetLogger(r *http.Request) *jsonlog.Logger {
+	logger, ok := r.Context().Value(loggerContextKey).(*jsonlog.Logger)
+	if !ok {
+		return app.logger
+	}
+	return logger
+}
+
+func (app *application) contextSetUser(r *http.Request, user string) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser returns the user identified by identifyUser, or "" for
+// anonymous requests.
+func (app *application) contextGetUser(r *http.Request) string {
+	user, _ := r.Context().Value(userContextKey).(string)
+	return user
+}
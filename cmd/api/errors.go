@@ -1,41 +1,138 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
 )
 
 func (app *application) errorLog(r *http.Request, err error) {
-	app.logger.PrintError(err, map[string]string{
-		"request-method": r.Method,
+	app.contextGetLogger(r).PrintError(err, map[string]any{
+		"request_method": r.Method,
 		"request_url":    r.URL.String(),
 	})
 }
 
-func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
-	env := envelope{"error": message}
-	err := app.writeJSON(w, status, env, nil)
+// problem is an RFC 7807 problem details object. Every error response from
+// the API is one of these, served as application/problem+json.
+type problem struct {
+	// Type identifies the kind of problem. It is "about:blank" when the
+	// status code says all there is to say.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that failed.
+	Instance string `json:"instance,omitempty"`
+	// Errors maps each invalid field to what is wrong with it.
+	Errors map[string]string `json:"errors,omitempty"`
+	// Conflicts lists the over-allocated weeks behind an over-allocation
+	// problem.
+	Conflicts []*data.AllocationConflict `json:"conflicts,omitempty"`
+	// CurrentStatus and AllowedStatuses describe the record behind an
+	// invalid transition problem: where it is and where it can move to.
+	CurrentStatus   string   `json:"currentStatus,omitempty"`
+	AllowedStatuses []string `json:"allowedStatuses,omitempty"`
+	RequestID       string   `json:"requestId,omitempty"`
+}
+
+const (
+	problemValidationFailed  = "urn:dashboard:problem:validation-failed"
+	problemEditConflict      = "urn:dashboard:problem:edit-conflict"
+	problemPatchTestFailed   = "urn:dashboard:problem:patch-test-failed"
+	problemOverAllocated     = "urn:dashboard:problem:over-allocated"
+	problemInvalidTransition = "urn:dashboard:problem:invalid-transition"
+)
+
+// problemResponse completes p from the request and writes it. Type and Title
+// default to "about:blank" and the status text.
+func (app *application) problemResponse(w http.ResponseWriter, r *http.Request, p problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = r.URL.Path
+	p.RequestID = app.contextGetRequestID(r)
+
+	js, err := json.Marshal(p)
 	if err != nil {
 		app.errorLog(r, err)
 		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	w.Write(append(js, '\n'))
+}
+
+func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	app.problemResponse(w, r, problem{Status: status, Detail: detail})
 }
 
+// statusClientClosedRequest is the non-standard status nginx uses for
+// requests abandoned by the client. It is only ever logged.
+const statusClientClosedRequest = 499
+
 func (app *application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		app.timeoutResponse(w, r, err)
+		return
+	case errors.Is(err, context.Canceled):
+		app.canceledResponse(w, r, err)
+		return
+	}
+
 	app.errorLog(r, err)
 
 	message := "the server encountered a problem and could not process the request"
 	app.errorResponse(w, r, http.StatusInternalServerError, message)
 }
 
+func (app *application) timeoutResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorLog(r, err)
+
+	message := "the server timed out while processing the request"
+	app.errorResponse(w, r, http.StatusGatewayTimeout, message)
+}
+
+// canceledResponse handles work abandoned because its context was cancelled,
+// either by the server shutting down or by the client going away.
+func (app *application) canceledResponse(w http.ResponseWriter, r *http.Request, err error) {
+	if app.shuttingDown() {
+		app.errorLog(r, err)
+
+		w.Header().Set("Retry-After", "5")
+		message := "the server is shutting down, please try again"
+		app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+		return
+	}
+
+	app.contextGetLogger(r).PrintWarn("client closed request", map[string]any{
+		"request_method": r.Method,
+		"request_url":    r.URL.String(),
+		"status":         statusClientClosedRequest,
+	})
+
+	// Nobody is listening, but record the status for the access log and metrics.
+	w.WriteHeader(statusClientClosedRequest)
+}
+
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
 	message := "the requested resource could not be found"
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
+	app.errorResponse(w, r, http.StatusNotFound, message)
 }
 
 func (app *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
 	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
+	app.errorResponse(w, r, http.StatusMethodNotAllowed, message)
 }
 
 func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
@@ -43,12 +140,47 @@ func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Reques
 }
 
 func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	app.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
+	app.problemResponse(w, r, problem{
+		Type:   problemValidationFailed,
+		Title:  "Validation failed",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "one or more fields are invalid",
+		Errors: errors,
+	})
 }
 
 func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
-	message := "unable to update the record due to an edit conflict, please try again"
-	app.errorResponse(w, r, http.StatusConflict, message)
+	app.problemResponse(w, r, problem{
+		Type:   problemEditConflict,
+		Title:  "Edit conflict",
+		Status: http.StatusConflict,
+		Detail: "unable to update the record due to an edit conflict, please try again",
+	})
+}
+
+// overAllocatedResponse rejects a change that would assign resources more
+// hours than they have, listing the weeks it would over-allocate.
+func (app *application) overAllocatedResponse(w http.ResponseWriter, r *http.Request, conflicts []*data.AllocationConflict) {
+	app.problemResponse(w, r, problem{
+		Type:      problemOverAllocated,
+		Title:     "Over-allocated",
+		Status:    http.StatusConflict,
+		Detail:    "the change would assign resources more hours than they have in some weeks; repeat it with force=true to make it anyway",
+		Conflicts: conflicts,
+	})
+}
+
+// invalidTransitionResponse rejects moving a record in status current to
+// status to, naming the statuses it can move to instead.
+func (app *application) invalidTransitionResponse(w http.ResponseWriter, r *http.Request, current, to string, allowed []string) {
+	app.problemResponse(w, r, problem{
+		Type:            problemInvalidTransition,
+		Title:           "Invalid transition",
+		Status:          http.StatusUnprocessableEntity,
+		Detail:          fmt.Sprintf("cannot change from %s to %s", current, to),
+		CurrentStatus:   current,
+		AllowedStatuses: allowed,
+	})
 }
 
 func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
@@ -61,12 +193,6 @@ func (app *application) invalidCredentialsResponse(w http.ResponseWriter, r *htt
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
 }
 
-func (app *application) invalidAuthenticationHeader(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("WWW-Authenticate", "Bearer")
-	message := "invalid or missin token"
-	app.errorResponse(w, r, http.StatusUnauthorized, message)
-}
-
 func (app *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	message := "must be authenticated to access this resource"
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
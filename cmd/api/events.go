@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+const subscriberBufferSize = 64
+
+type eventPayload struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+type event struct {
+	id   uint64
+	kind string
+	body []byte
+}
+
+type eventSubscriber struct {
+	ch     chan event
+	accept func(kind string) bool
+}
+
+// eventBroker fans published events out to the connected SSE clients and
+// keeps the most recent ones so that reconnecting clients can resume from
+// their Last-Event-ID.
+type eventBroker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	replay      []event
+	replaySize  int
+	subscribers map[*eventSubscriber]struct{}
+}
+
+func newEventBroker(replaySize int) *eventBroker {
+	return &eventBroker{
+		replaySize:  replaySize,
+		subscribers: make(map[*eventSubscriber]struct{}),
+	}
+}
+
+func (b *eventBroker) publish(kind string, body []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e := event{id: b.lastID, kind: kind, body: body}
+
+	if b.replaySize > 0 {
+		if len(b.replay) == b.replaySize {
+			b.replay = b.replay[1:]
+		}
+		b.replay = append(b.replay, e)
+	}
+
+	for sub := range b.subscribers {
+		if !sub.accept(kind) {
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			// The client isn't keeping up; drop it and let it resume from the
+			// replay buffer when it reconnects.
+			close(sub.ch)
+			delete(b.subscribers, sub)
+		}
+	}
+}
+
+// subscribe registers a subscriber and returns the buffered events after
+// lastID that it accepts. complete is false when events after lastID have
+// already been evicted from the replay buffer, or lastID is unknown.
+func (b *eventBroker) subscribe(lastID uint64, accept func(string) bool) (sub *eventSubscriber, backlog []event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &eventSubscriber{
+		ch:     make(chan event, subscriberBufferSize),
+		accept: accept,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastID == 0 {
+		return sub, nil, true
+	}
+
+	oldest := b.lastID + 1
+	if len(b.replay) > 0 {
+		oldest = b.replay[0].id
+	}
+	complete = lastID <= b.lastID && lastID+1 >= oldest
+
+	for _, e := range b.replay {
+		if e.id > lastID && accept(e.kind) {
+			backlog = append(backlog, e)
+		}
+	}
+
+	return sub, backlog, complete
+}
+
+func (b *eventBroker) subscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+func (b *eventBroker) unsubscribe(sub *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		close(sub.ch)
+		delete(b.subscribers, sub)
+	}
+}
+
+// publishEvent broadcasts an event to SSE clients and, for the event types
+// webhooks may subscribe to, queues webhook deliveries.
+func (app *application) publishEvent(kind string, payload any) {
+	body, err := json.Marshal(eventPayload{
+		Event:      kind,
+		OccurredAt: time.Now().UTC(),
+		Data:       payload,
+	})
+	if err != nil {
+		app.logger.PrintError(err, map[string]any{"event": kind})
+		return
+	}
+
+	app.events.publish(kind, body)
+
+	if validator.PermittedValue(kind, data.WebhookEvents...) {
+		app.queueWebhooks(kind, body)
+	}
+}
+
+// eventFilter builds a matcher from the "types" query parameter. Each entry is
+// either an exact event type such as "resource.updated" or a topic wildcard
+// such as "request.*".
+func eventFilter(types []string, v *validator.Validator) func(string) bool {
+	if len(types) == 0 {
+		return func(string) bool { return true }
+	}
+
+	exact := make(map[string]bool)
+	var prefixes []string
+
+	for _, t := range types {
+		switch {
+		case validator.PermittedValue(t, data.Events...):
+			exact[t] = true
+		case strings.HasSuffix(t, ".*") && isEventTopic(strings.TrimSuffix(t, "*")):
+			prefixes = append(prefixes, strings.TrimSuffix(t, "*"))
+		default:
+			v.AddError("types", fmt.Sprintf("unknown event type %q", t))
+		}
+	}
+
+	return func(kind string) bool {
+		if exact[kind] {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(kind, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isEventTopic(prefix string) bool {
+	for _, e := range data.Events {
+		if strings.HasPrefix(e, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeEvent(w http.ResponseWriter, e event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.id, e.kind, e.body)
+	return err
+}
+
+func (app *application) handleEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v := validator.New()
+
+		qs := r.URL.Query()
+
+		accept := eventFilter(app.readCSV(qs, "types", []string{}), v)
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = qs.Get("lastEventId")
+		}
+
+		var lastID uint64
+		if lastEventID != "" {
+			id, err := strconv.ParseUint(lastEventID, 10, 64)
+			if err != nil {
+				v.AddError("lastEventId", "must be a positive integer")
+			}
+			lastID = id
+		}
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+
+		// The stream outlives the server's WriteTimeout, so lift the deadline
+		// for this connection only.
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		sub, backlog, complete := app.events.subscribe(lastID, accept)
+		defer app.events.unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", app.cfg.sse.retry.Milliseconds())
+
+		if !complete {
+			// Tell the client it has missed events and should refetch its state.
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+
+		for _, e := range backlog {
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(app.cfg.sse.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-app.shutdown:
+				return
+			case e, ok := <-sub.ch:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+var (
+	resourceFieldSafelist = []string{"resourceId", "firstName", "lastName", "position", "clearance", "specialties", "certifications", "active", "sex", "email", "capacityHours"}
+	requestFieldSafelist  = []string{"id", "customer", "startDate", "endDate", "hoursPerWeek", "skills", "projectID", "engagementID", "createdAt", "updatedAt", "version", "status", "statusChangedAt"}
+
+	resourceIncludeSafelist = []string{"assignments", "currentRequest"}
+	requestIncludeSafelist  = []string{"assignments"}
+)
+
+// validateInclude checks that every related record in include may be
+// embedded.
+func validateInclude(v *validator.Validator, include []string, safelist []string) {
+	for _, name := range include {
+		if !validator.PermittedValue(name, safelist...) {
+			v.AddError("include", "invalid include value "+name)
+			return
+		}
+	}
+	v.Check(validator.Unique(include), "include", "must not contain duplicate values")
+}
+
+// projectRecords returns the JSON form of each record in records, a slice,
+// keeping only idField and the given fields, or every field when fields is
+// empty.
+func projectRecords(records any, idField string, fields []string) ([]map[string]any, error) {
+	js, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	var projected []map[string]any
+	if err := dec.Decode(&projected); err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return projected, nil
+	}
+
+	for _, record := range projected {
+		for field := range record {
+			if field != idField && !validator.PermittedValue(field, fields...) {
+				delete(record, field)
+			}
+		}
+	}
+
+	return projected, nil
+}
+
+// includeForResources embeds the related records named in include into
+// records, the JSON forms of resources in the same order.
+func (app *application) includeForResources(ctx context.Context, resources []*data.Resource, records []map[string]any, include []string) error {
+	if len(include) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(resources))
+	for i, resource := range resources {
+		ids[i] = resource.ID
+	}
+
+	assignments, err := app.models.ResourceAssignments.GetAllForResources(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byResource := make(map[int64][]*data.ResourceAssignment)
+	for _, a := range assignments {
+		byResource[a.ResourceID] = append(byResource[a.ResourceID], a)
+	}
+
+	if validator.PermittedValue("assignments", include...) {
+		for i, resource := range resources {
+			embedded := byResource[resource.ID]
+			if embedded == nil {
+				embedded = []*data.ResourceAssignment{}
+			}
+			records[i]["assignments"] = embedded
+		}
+	}
+
+	if validator.PermittedValue("currentRequest", include...) {
+		var requestIDs []int64
+		for _, a := range assignments {
+			if a.Counts() {
+				requestIDs = append(requestIDs, a.ResourceRequestID)
+			}
+		}
+
+		requests, err := app.models.ResourceRequests.GetByIDs(ctx, requestIDs)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[int64]*data.ResourceRequest, len(requests))
+		for _, rr := range requests {
+			byID[rr.ID] = rr
+		}
+
+		now := time.Now()
+		for i, resource := range resources {
+			records[i]["currentRequest"] = currentRequest(byResource[resource.ID], byID, now)
+		}
+	}
+
+	return nil
+}
+
+// currentRequest returns the open request that one of assignments is
+// delivering at now, or nil. A resource on more than one is reported on the
+// one that started first.
+func currentRequest(assignments []*data.ResourceAssignment, requests map[int64]*data.ResourceRequest, now time.Time) *data.ResourceRequest {
+	var current *data.ResourceRequest
+
+	for _, a := range assignments {
+		rr, ok := requests[a.ResourceRequestID]
+		if !ok || !a.Counts() || rr.Ended() {
+			continue
+		}
+		if now.Before(rr.StartDate) || !now.Before(rr.EndDate.AddDate(0, 0, 1)) {
+			continue
+		}
+		if current == nil || rr.StartDate.Before(current.StartDate) {
+			current = rr
+		}
+	}
+
+	return current
+}
+
+// includeForRequests embeds the related records named in include into
+// records, the JSON forms of requests in the same order.
+func (app *application) includeForRequests(ctx context.Context, requests []*data.ResourceRequest, records []map[string]any, include []string) error {
+	if !validator.PermittedValue("assignments", include...) {
+		return nil
+	}
+
+	ids := make([]int64, len(requests))
+	for i, rr := range requests {
+		ids[i] = rr.ID
+	}
+
+	assignments, err := app.models.ResourceAssignments.GetAllForRequests(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byRequest := make(map[int64][]*data.ResourceAssignment)
+	for _, a := range assignments {
+		byRequest[a.ResourceRequestID] = append(byRequest[a.ResourceRequestID], a)
+	}
+
+	for i, rr := range requests {
+		embedded := byRequest[rr.ID]
+		if embedded == nil {
+			embedded = []*data.ResourceAssignment{}
+		}
+		records[i]["assignments"] = embedded
+	}
+
+	return nil
+}
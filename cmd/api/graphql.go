@@ -0,0 +1,550 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/filter"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+const graphqlContextKey = contextKey("graphql")
+
+// graphqlRequest is what resolvers need from the HTTP request they serve.
+type graphqlRequest struct {
+	r       *http.Request
+	loaders *graphqlLoaders
+}
+
+func graphqlRequestFrom(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlContextKey).(*graphqlRequest)
+}
+
+// graphqlValidationError carries field errors the same way the REST API's
+// validation problems do, under the error's extensions.
+type graphqlValidationError map[string]string
+
+func (e graphqlValidationError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for key, msg := range e {
+		msgs = append(msgs, key+" "+msg)
+	}
+	sort.Strings(msgs)
+
+	return "invalid arguments: " + strings.Join(msgs, "; ")
+}
+
+func (e graphqlValidationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   "VALIDATION_FAILED",
+		"errors": map[string]string(e),
+	}
+}
+
+// graphqlError turns a store error into one fit for the response: not found
+// becomes a null result, and anything unexpected is logged and reported
+// without its details, as serverErrorResponse does.
+func (app *application) graphqlError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, data.ErrNotFound):
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return errors.New("the server timed out while processing your request")
+	case errors.Is(err, context.Canceled):
+		return errors.New("the request was canceled")
+	}
+
+	app.errorLog(graphqlRequestFrom(ctx).r, err)
+
+	return errors.New("the server encountered a problem and could not process your request")
+}
+
+// graphqlThunk adapts a loader thunk to the signature graphql-go resolves
+// lazily.
+func graphqlThunk[V any](app *application, ctx context.Context, thunk func() (V, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		v, err := thunk()
+		if err != nil {
+			return nil, app.graphqlError(ctx, err)
+		}
+		return v, nil
+	}
+}
+
+// graphqlFilters reads and validates the pagination arguments shared by the
+// list fields, with the same defaults and limits as the REST query string.
+func graphqlFilters(args map[string]interface{}, safelist []string, v *validator.Validator) data.Filters {
+	f := data.Filters{
+		Page:         args["page"].(int),
+		PageSize:     args["pageSize"].(int),
+		Sort:         args["sort"].(string),
+		SortSafelist: safelist,
+	}
+
+	data.ValidateFilters(v, f)
+
+	return f
+}
+
+func stringList(arg interface{}) []string {
+	values := []string{}
+	list, _ := arg.([]interface{})
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// pageArgs are the pagination arguments of a list field.
+func pageArgs(defaultSort string, safelist []string) graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"page": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 1,
+		},
+		"pageSize": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 20,
+			Description:  "At most 100.",
+		},
+		"sort": &graphql.ArgumentConfig{
+			Type:         graphql.String,
+			DefaultValue: defaultSort,
+			Description:  "One of " + strings.Join(safelist, ", ") + ".",
+		},
+	}
+}
+
+func withArgs(base graphql.FieldConfigArgument, extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	for name, arg := range extra {
+		base[name] = arg
+	}
+	return base
+}
+
+var (
+	resourceSortSafelist = []string{"id", "first_name", "last_name", "-id", "-first_name", "-last_name"}
+	requestSortSafelist  = []string{"id", "customer", "start_date", "end_date", "-id", "-customer", "-start_date", "-end_date"}
+)
+
+// graphqlSchema builds the read-only schema served at /v1/graphql.
+func (app *application) graphqlSchema() (graphql.Schema, error) {
+	idArg := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	metadataType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Metadata",
+		Description: "Pagination details of a list; every field is 0 when the list is empty.",
+		Fields: graphql.Fields{
+			"currentPage":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"pageSize":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"firstPage":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"lastPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"totalRecords": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	positionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Position",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	clearanceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Clearance",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	var resourceType, requestType, assignmentType *graphql.Object
+
+	resourceType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Resource",
+		Description: "A person who can be staffed.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"firstName": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"lastName":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"position": &graphql.Field{
+					Type: positionType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := p.Source.(*data.Resource)
+						return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.positions.load(p.Context, r.Position)), nil
+					},
+				},
+				"clearance": &graphql.Field{
+					Type: clearanceType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := p.Source.(*data.Resource)
+						return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.clearances.load(p.Context, r.Clearance)), nil
+					},
+				},
+				"specialties":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				"certifications": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				"active":         &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"sex":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"email":          &graphql.Field{Type: graphql.String},
+				"capacityHours":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "The hours a week this resource can be assigned before their assignments conflict."},
+				"assignments": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(assignmentType))),
+					Description: "The requests this resource is assigned to.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := p.Source.(*data.Resource)
+						return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.assignmentsByResource.load(p.Context, r.ID)), nil
+					},
+				},
+			}
+		}),
+	})
+
+	requestType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "ResourceRequest",
+		Description: "A customer's request for staff.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"customer":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"startDate":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"endDate":         &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"hoursPerWeek":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"skills":          &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				"projectID":       &graphql.Field{Type: graphql.String},
+				"engagementID":    &graphql.Field{Type: graphql.String},
+				"createdAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"updatedAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"version":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"status":          &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "One of draft, open, partially_filled, filled, in_delivery, closed, cancelled or on_hold."},
+				"statusChangedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"assignments": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(assignmentType))),
+					Description: "The resources assigned to this request.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						rr := p.Source.(*data.ResourceRequest)
+						return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.assignmentsByRequest.load(p.Context, rr.ID)), nil
+					},
+				},
+			}
+		}),
+	})
+
+	assignmentType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Assignment",
+		Description: "A resource assigned to a request.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"requestId": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*data.ResourceAssignment).ResourceRequestID, nil
+					},
+				},
+				"resourceId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"request": &graphql.Field{
+					Type: requestType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						a := p.Source.(*data.ResourceAssignment)
+						return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.requests.load(p.Context, a.ResourceRequestID)), nil
+					},
+				},
+				"resource": &graphql.Field{
+					Type: resourceType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						a := p.Source.(*data.ResourceAssignment)
+						return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.resources.load(p.Context, a.ResourceID)), nil
+					},
+				},
+				"hoursPerWeek":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"completed":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"status":          &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "One of proposed, shortlisted, customer-interview, accepted, rejected or withdrawn."},
+				"statusChangedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"proposedBy":      &graphql.Field{Type: graphql.String},
+				"createdAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"updatedAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"version":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			}
+		}),
+	})
+
+	resourcePageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ResourcePage",
+		Fields: graphql.Fields{
+			"items":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(resourceType)))},
+			"metadata": &graphql.Field{Type: graphql.NewNonNull(metadataType)},
+		},
+	})
+
+	requestPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ResourceRequestPage",
+		Fields: graphql.Fields{
+			"items":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(requestType)))},
+			"metadata": &graphql.Field{Type: graphql.NewNonNull(metadataType)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"resource": &graphql.Field{
+				Type: resourceType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, ok := graphqlID(p.Args["id"])
+					if !ok {
+						return nil, nil
+					}
+					return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.resources.load(p.Context, id)), nil
+				},
+			},
+			"resources": &graphql.Field{
+				Type: graphql.NewNonNull(resourcePageType),
+				Args: withArgs(pageArgs("id", resourceSortSafelist), graphql.FieldConfigArgument{
+					"specialties":    &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Only resources with all of these specialties."},
+					"certifications": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Only resources with all of these certifications."},
+					"active":         &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true, Description: "false lists only inactive resources."},
+					"filter":         &graphql.ArgumentConfig{Type: graphql.String, Description: "A filter expression, as taken by GET /v1/resources?filter=."},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					v := validator.New()
+					filters := graphqlFilters(p.Args, resourceSortSafelist, v)
+
+					var where *filter.Filter
+					if expr, _ := p.Args["filter"].(string); expr != "" {
+						var err error
+						where, err = data.ParseResourceFilter(expr)
+						if err != nil {
+							v.AddError("filter", err.Error())
+						}
+					}
+
+					if !v.Valid() {
+						return nil, graphqlValidationError(v.Errors)
+					}
+
+					resources, metadata, err := app.models.Resources.GetAll(p.Context, stringList(p.Args["specialties"]), stringList(p.Args["certifications"]), p.Args["active"].(bool), where, filters)
+					if err != nil {
+						return nil, app.graphqlError(p.Context, err)
+					}
+
+					return map[string]interface{}{"items": resources, "metadata": metadata}, nil
+				},
+			},
+			"position": &graphql.Field{
+				Type: positionType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, ok := graphqlID(p.Args["id"])
+					if !ok {
+						return nil, nil
+					}
+
+					position, err := app.models.Positions.Get(p.Context, id)
+					if err != nil {
+						return nil, app.graphqlError(p.Context, err)
+					}
+					return position, nil
+				},
+			},
+			"positions": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(positionType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					positions, err := app.models.Positions.GetAll(p.Context)
+					if err != nil {
+						return nil, app.graphqlError(p.Context, err)
+					}
+					return positions, nil
+				},
+			},
+			"clearance": &graphql.Field{
+				Type: clearanceType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, ok := graphqlID(p.Args["id"])
+					if !ok {
+						return nil, nil
+					}
+
+					clearance, err := app.models.Clearances.Get(p.Context, id)
+					if err != nil {
+						return nil, app.graphqlError(p.Context, err)
+					}
+					return clearance, nil
+				},
+			},
+			"clearances": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(clearanceType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					clearances, err := app.models.Clearances.GetAll(p.Context)
+					if err != nil {
+						return nil, app.graphqlError(p.Context, err)
+					}
+					return clearances, nil
+				},
+			},
+			"request": &graphql.Field{
+				Type: requestType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, ok := graphqlID(p.Args["id"])
+					if !ok {
+						return nil, nil
+					}
+					return graphqlThunk(app, p.Context, graphqlRequestFrom(p.Context).loaders.requests.load(p.Context, id)), nil
+				},
+			},
+			"requests": &graphql.Field{
+				Type: graphql.NewNonNull(requestPageType),
+				Args: withArgs(pageArgs("id", requestSortSafelist), graphql.FieldConfigArgument{
+					"customer": &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "", Description: "Only requests whose customer contains every one of these words."},
+					"skills":   &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Only requests needing all of these skills."},
+					"status":   &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Only requests in one of these statuses."},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					v := validator.New()
+					filters := graphqlFilters(p.Args, requestSortSafelist, v)
+					statuses := stringList(p.Args["status"])
+					for _, status := range statuses {
+						v.Check(validator.PermittedValue(status, data.RequestStatuses...), "status", "must contain only valid request statuses")
+					}
+					if !v.Valid() {
+						return nil, graphqlValidationError(v.Errors)
+					}
+
+					requests, metadata, err := app.models.ResourceRequests.GetAll(p.Context, p.Args["customer"].(string), stringList(p.Args["skills"]), statuses, filters)
+					if err != nil {
+						return nil, app.graphqlError(p.Context, err)
+					}
+
+					return map[string]interface{}{"items": requests, "metadata": metadata}, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// graphqlID parses an ID argument. Ids that are not positive integers match
+// nothing.
+func graphqlID(arg interface{}) (int64, bool) {
+	s, _ := arg.(string)
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, false
+	}
+	return id, true
+}
+
+func (app *application) handleGraphQL() http.HandlerFunc {
+	schema, err := app.graphqlSchema()
+	if err != nil {
+		panic(err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Query         string                 `json:"query"`
+			OperationName string                 `json:"operationName"`
+			Variables     map[string]interface{} `json:"variables"`
+		}
+
+		if r.Method == http.MethodGet {
+			qs := r.URL.Query()
+
+			input.Query = qs.Get("query")
+			input.OperationName = qs.Get("operationName")
+
+			if vars := qs.Get("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &input.Variables); err != nil {
+					app.badRequestResponse(w, r, errors.New("variables must be a JSON object"))
+					return
+				}
+			}
+		} else {
+			err := app.readJSON(w, r, &input)
+			if err != nil {
+				app.badRequestResponse(w, r, err)
+				return
+			}
+		}
+
+		v := validator.New()
+
+		if v.Check(input.Query != "", "query", "must be provided"); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(input.Query)})})
+		if err == nil {
+			cost := analyzeQuery(schema, doc, input.Variables)
+
+			var limitErr *graphqlLimitError
+			switch {
+			case cost.depth > app.cfg.graphql.maxDepth:
+				limitErr = &graphqlLimitError{"QUERY_TOO_DEEP", fmt.Sprintf("query depth %d exceeds the limit of %d", cost.depth, app.cfg.graphql.maxDepth)}
+			case cost.complexity > app.cfg.graphql.maxComplexity:
+				limitErr = &graphqlLimitError{"QUERY_TOO_COMPLEX", fmt.Sprintf("query complexity %d exceeds the limit of %d", cost.complexity, app.cfg.graphql.maxComplexity)}
+			}
+
+			if limitErr != nil {
+				err := app.writeJSON(w, http.StatusBadRequest, envelope{"errors": []gqlerrors.FormattedError{limitErr.formatted()}}, nil)
+				if err != nil {
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), graphqlContextKey, &graphqlRequest{
+			r:       r,
+			loaders: app.newGraphQLLoaders(),
+		})
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  input.Query,
+			VariableValues: input.Variables,
+			OperationName:  input.OperationName,
+			Context:        ctx,
+		})
+
+		env := envelope{"data": result.Data}
+		status := http.StatusOK
+
+		if result.HasErrors() {
+			env["errors"] = result.Errors
+
+			// Errors with no data at all mean the query never ran: it failed
+			// to parse or validate.
+			if result.Data == nil {
+				delete(env, "data")
+				status = http.StatusBadRequest
+			}
+		}
+
+		err = app.writeJSON(w, status, env, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// defaultListCost is how many items a list with no pageSize argument is
+// assumed to hold when estimating a query's complexity.
+const defaultListCost = 10
+
+// graphqlLimitError reports a query rejected by the depth or complexity
+// limits before it runs.
+type graphqlLimitError struct {
+	code    string
+	message string
+}
+
+func (e graphqlLimitError) formatted() gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    e.message,
+		Locations:  []location.SourceLocation{},
+		Extensions: map[string]interface{}{"code": e.code},
+	}
+}
+
+// queryCost is the shape of a query: how deeply it nests and roughly how
+// many fields it will resolve.
+type queryCost struct {
+	depth      int
+	complexity int
+}
+
+// analyzeQuery measures every operation in doc, so the limits hold whichever
+// one the client asks to run. Fields the schema doesn't know are left for
+// graphql-go's validation to report.
+func analyzeQuery(schema graphql.Schema, doc *ast.Document, variables map[string]interface{}) queryCost {
+	a := queryAnalyzer{
+		schema:    schema,
+		variables: variables,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		visiting:  make(map[string]bool),
+	}
+
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok && frag.Name != nil {
+			a.fragments[frag.Name.Value] = frag
+		}
+	}
+
+	var total queryCost
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		var root *graphql.Object
+		switch op.Operation {
+		case ast.OperationTypeQuery:
+			root = schema.QueryType()
+		case ast.OperationTypeMutation:
+			root = schema.MutationType()
+		}
+		if root == nil {
+			continue
+		}
+
+		cost := a.selectionSet(root, op.SelectionSet)
+		if cost.depth > total.depth {
+			total.depth = cost.depth
+		}
+		if cost.complexity > total.complexity {
+			total.complexity = cost.complexity
+		}
+	}
+
+	return total
+}
+
+type queryAnalyzer struct {
+	schema    graphql.Schema
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	visiting  map[string]bool
+}
+
+func (a *queryAnalyzer) selectionSet(parent graphql.Type, set *ast.SelectionSet) queryCost {
+	var total queryCost
+	if set == nil {
+		return total
+	}
+
+	add := func(c queryCost) {
+		if c.depth > total.depth {
+			total.depth = c.depth
+		}
+		total.complexity += c.complexity
+	}
+
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			add(a.field(parent, sel))
+
+		case *ast.InlineFragment:
+			typ := parent
+			if sel.TypeCondition != nil && sel.TypeCondition.Name != nil {
+				if t := a.schema.Type(sel.TypeCondition.Name.Value); t != nil {
+					typ = t
+				}
+			}
+			add(a.selectionSet(typ, sel.SelectionSet))
+
+		case *ast.FragmentSpread:
+			if sel.Name == nil {
+				continue
+			}
+			name := sel.Name.Value
+
+			// A fragment that spreads itself is invalid and graphql-go
+			// rejects it; just don't loop forever measuring it.
+			frag, ok := a.fragments[name]
+			if !ok || a.visiting[name] {
+				continue
+			}
+
+			typ := parent
+			if frag.TypeCondition != nil && frag.TypeCondition.Name != nil {
+				if t := a.schema.Type(frag.TypeCondition.Name.Value); t != nil {
+					typ = t
+				}
+			}
+
+			a.visiting[name] = true
+			add(a.selectionSet(typ, frag.SelectionSet))
+			delete(a.visiting, name)
+		}
+	}
+
+	return total
+}
+
+// field measures one field: it adds a level of depth and costs one, plus the
+// cost of its selections for every item it may return.
+func (a *queryAnalyzer) field(parent graphql.Type, f *ast.Field) queryCost {
+	cost := queryCost{depth: 1, complexity: 1}
+	if f.Name == nil || strings.HasPrefix(f.Name.Value, "__") {
+		return cost
+	}
+
+	obj, ok := parent.(*graphql.Object)
+	if !ok {
+		return cost
+	}
+
+	def, ok := obj.Fields()[f.Name.Value]
+	if !ok {
+		return cost
+	}
+
+	named, isList := unwrapType(def.Type)
+
+	multiplier := 1
+	if n, ok := a.pageSize(def, f); ok {
+		multiplier = n
+	} else if isList && !(f.Name.Value == "items" && strings.HasSuffix(obj.Name(), "Page")) {
+		// A page's items are already counted by the pageSize of the field
+		// that returned the page.
+		multiplier = defaultListCost
+	}
+
+	children := a.selectionSet(named, f.SelectionSet)
+	cost.depth += children.depth
+	cost.complexity = multiplier * (1 + children.complexity)
+
+	return cost
+}
+
+// pageSize returns the pageSize a field will be resolved with, if it takes
+// one: the argument as given, the variable it names or its default.
+func (a *queryAnalyzer) pageSize(def *graphql.FieldDefinition, f *ast.Field) (int, bool) {
+	var arg *graphql.Argument
+	for _, candidate := range def.Args {
+		if candidate.Name() == "pageSize" {
+			arg = candidate
+		}
+	}
+	if arg == nil {
+		return 0, false
+	}
+
+	size, _ := arg.DefaultValue.(int)
+
+	for _, given := range f.Arguments {
+		if given.Name == nil || given.Name.Value != "pageSize" {
+			continue
+		}
+
+		switch value := given.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				size = n
+			}
+		case *ast.Variable:
+			if value.Name == nil {
+				break
+			}
+			switch n := a.variables[value.Name.Value].(type) {
+			case float64:
+				size = int(n)
+			case int:
+				size = n
+			}
+		}
+	}
+
+	// Sizes the resolver will reject cost nothing; they never run.
+	if size < 0 {
+		size = 0
+	}
+
+	return size, true
+}
+
+func unwrapType(t graphql.Type) (graphql.Type, bool) {
+	isList := false
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			isList = true
+			t = wrapped.OfType
+		default:
+			return t, isList
+		}
+	}
+}
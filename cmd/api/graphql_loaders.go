@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+// batchLoader coalesces the lookups made while one level of a GraphQL query
+// resolves. load only queues its key and returns a thunk; graphql-go runs
+// the thunks of a level after every resolver at that level has been called,
+// so the first thunk fetches all the queued keys in one call.
+type batchLoader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	queued  map[K]bool
+	pending []K
+	results map[K]V
+	errs    map[K]error
+}
+
+func newBatchLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+func (l *batchLoader[K, V]) load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	_, done := l.results[key]
+	if !done && l.errs[key] == nil && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+
+			results, err := l.fetch(ctx, keys)
+			for _, k := range keys {
+				delete(l.queued, k)
+				if err != nil {
+					l.errs[k] = err
+					continue
+				}
+				l.results[k] = results[k]
+			}
+		}
+
+		return l.results[key], l.errs[key]
+	}
+}
+
+// graphqlLoaders holds the loaders for one GraphQL request, so results are
+// shared across the whole query but never between requests.
+type graphqlLoaders struct {
+	resources             *batchLoader[int64, *data.Resource]
+	requests              *batchLoader[int64, *data.ResourceRequest]
+	assignmentsByRequest  *batchLoader[int64, []*data.ResourceAssignment]
+	assignmentsByResource *batchLoader[int64, []*data.ResourceAssignment]
+	positions             *batchLoader[string, *data.Position]
+	clearances            *batchLoader[string, *data.Clearance]
+}
+
+func (app *application) newGraphQLLoaders() *graphqlLoaders {
+	return &graphqlLoaders{
+		resources: newBatchLoader(func(ctx context.Context, ids []int64) (map[int64]*data.Resource, error) {
+			resources, err := app.models.Resources.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[int64]*data.Resource, len(resources))
+			for _, r := range resources {
+				byID[r.ID] = r
+			}
+			return byID, nil
+		}),
+
+		requests: newBatchLoader(func(ctx context.Context, ids []int64) (map[int64]*data.ResourceRequest, error) {
+			requests, err := app.models.ResourceRequests.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[int64]*data.ResourceRequest, len(requests))
+			for _, rr := range requests {
+				byID[rr.ID] = rr
+			}
+			return byID, nil
+		}),
+
+		assignmentsByRequest: newBatchLoader(func(ctx context.Context, ids []int64) (map[int64][]*data.ResourceAssignment, error) {
+			assignments, err := app.models.ResourceAssignments.GetAllForRequests(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byRequest := make(map[int64][]*data.ResourceAssignment, len(ids))
+			for _, a := range assignments {
+				byRequest[a.ResourceRequestID] = append(byRequest[a.ResourceRequestID], a)
+			}
+			return byRequest, nil
+		}),
+
+		assignmentsByResource: newBatchLoader(func(ctx context.Context, ids []int64) (map[int64][]*data.ResourceAssignment, error) {
+			assignments, err := app.models.ResourceAssignments.GetAllForResources(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byResource := make(map[int64][]*data.ResourceAssignment, len(ids))
+			for _, a := range assignments {
+				byResource[a.ResourceID] = append(byResource[a.ResourceID], a)
+			}
+			return byResource, nil
+		}),
+
+		// Resources refer to positions and clearances by name, and both
+		// tables are small, so one query fetches the whole table.
+		positions: newBatchLoader(func(ctx context.Context, titles []string) (map[string]*data.Position, error) {
+			positions, err := app.models.Positions.GetAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			byTitle := make(map[string]*data.Position, len(positions))
+			for _, p := range positions {
+				byTitle[p.Title] = p
+			}
+			return byTitle, nil
+		}),
+
+		clearances: newBatchLoader(func(ctx context.Context, descriptions []string) (map[string]*data.Clearance, error) {
+			clearances, err := app.models.Clearances.GetAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			byDescription := make(map[string]*data.Clearance, len(clearances))
+			for _, c := range clearances {
+				byDescription[c.Description] = c
+			}
+			return byDescription, nil
+		}),
+	}
+}
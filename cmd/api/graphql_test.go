@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+func TestAnalyzeQuery(t *testing.T) {
+	app := newTestApplication(t)
+
+	schema, err := app.graphqlSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		variables  string
+		depth      int
+		complexity int
+	}{
+		{
+			name:       "single field",
+			query:      `{ resource(id: 1) { firstName } }`,
+			depth:      2,
+			complexity: 2,
+		},
+		{
+			name:       "default page size",
+			query:      `{ resources { items { id } } }`,
+			depth:      3,
+			complexity: 20 * 3,
+		},
+		{
+			name:       "page size argument",
+			query:      `{ resources(pageSize: 5) { items { id firstName } } }`,
+			depth:      3,
+			complexity: 5 * 4,
+		},
+		{
+			name:       "page size variable",
+			query:      `query ($n: Int) { resources(pageSize: $n) { items { id } } }`,
+			variables:  `{"n": 2}`,
+			depth:      3,
+			complexity: 2 * 3,
+		},
+		{
+			name:       "page size variable not given",
+			query:      `query ($n: Int) { resources(pageSize: $n) { items { id } } }`,
+			depth:      3,
+			complexity: 20 * 3,
+		},
+		{
+			name:       "negative page size",
+			query:      `{ resources(pageSize: -1) { items { id } } }`,
+			depth:      3,
+			complexity: 0,
+		},
+		{
+			name:       "nested list without page size",
+			query:      `{ resources(pageSize: 2) { items { assignments { request { customer } } } } }`,
+			depth:      5,
+			complexity: 2 * (1 + 1*(1+10*(1+1*(1+1)))),
+		},
+		{
+			name:       "fragment spread",
+			query:      `{ resource(id: 1) { ...names } } fragment names on Resource { firstName lastName }`,
+			depth:      2,
+			complexity: 3,
+		},
+		{
+			name:       "fragment spread in a list",
+			query:      `{ resources(pageSize: 3) { items { ...names } } } fragment names on Resource { firstName lastName }`,
+			depth:      3,
+			complexity: 3 * (1 + 1*(1+2)),
+		},
+		{
+			name:       "self-referencing fragment",
+			query:      `{ resource(id: 1) { ...loop } } fragment loop on Resource { firstName ...loop }`,
+			depth:      2,
+			complexity: 2,
+		},
+		{
+			name:       "inline fragment",
+			query:      `{ resource(id: 1) { ... on Resource { firstName position { title } } } }`,
+			depth:      3,
+			complexity: 1 + 1 + 2,
+		},
+		{
+			name:       "introspection field",
+			query:      `{ __typename resource(id: 1) { __typename } }`,
+			depth:      2,
+			complexity: 1 + 2,
+		},
+		{
+			name:       "costliest of several operations",
+			query:      `query a { resource(id: 1) { firstName } } query b { requests(pageSize: 4) { items { customer } } }`,
+			depth:      3,
+			complexity: 4 * 3,
+		},
+		{
+			name:       "unknown field",
+			query:      `{ resource(id: 1) { nickname { length } } }`,
+			depth:      2,
+			complexity: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(tt.query)})})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var variables map[string]interface{}
+			if tt.variables != "" {
+				if err := json.Unmarshal([]byte(tt.variables), &variables); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cost := analyzeQuery(schema, doc, variables)
+			if cost.depth != tt.depth {
+				t.Errorf("depth = %d, want %d", cost.depth, tt.depth)
+			}
+			if cost.complexity != tt.complexity {
+				t.Errorf("complexity = %d, want %d", cost.complexity, tt.complexity)
+			}
+		})
+	}
+}
+
+func TestBatchLoader(t *testing.T) {
+	ctx := context.Background()
+
+	var calls [][]int
+	loader := newBatchLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		calls = append(calls, append([]int{}, keys...))
+
+		values := make(map[int]string, len(keys))
+		for _, k := range keys {
+			if k != 404 {
+				values[k] = string(rune('a' + k))
+			}
+		}
+		return values, nil
+	})
+
+	// One level of a query: every load is queued before any thunk runs.
+	thunks := []func() (string, error){
+		loader.load(ctx, 1),
+		loader.load(ctx, 2),
+		loader.load(ctx, 1),
+		loader.load(ctx, 404),
+	}
+
+	var got []string
+	for _, thunk := range thunks {
+		value, err := thunk()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, value)
+	}
+
+	if want := []string{"b", "c", "b", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values %q, want %q", got, want)
+	}
+	if want := [][]int{{1, 2, 404}}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("fetch calls %v, want %v", calls, want)
+	}
+
+	// The next level reuses what is loaded and fetches only the rest.
+	thunks = []func() (string, error){
+		loader.load(ctx, 2),
+		loader.load(ctx, 3),
+	}
+	for _, thunk := range thunks {
+		if _, err := thunk(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := [][]int{{1, 2, 404}, {3}}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("fetch calls %v, want %v", calls, want)
+	}
+}
+
+func TestBatchLoaderError(t *testing.T) {
+	ctx := context.Background()
+	errFetch := errors.New("fetch failed")
+
+	calls := 0
+	loader := newBatchLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		calls++
+		return nil, errFetch
+	})
+
+	first, second := loader.load(ctx, 1), loader.load(ctx, 2)
+
+	for _, thunk := range []func() (string, error){first, second, loader.load(ctx, 1)} {
+		if _, err := thunk(); !errors.Is(err, errFetch) {
+			t.Errorf("error = %v, want %v", err, errFetch)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("fetched %d times, want once", calls)
+	}
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	seedResources(t, h,
+		`{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"}`,
+		`{"id": 2, "firstName": "Bob", "lastName": "Babbage", "position": "Consultant", "clearance": "None", "active": true, "sex": "Male"}`,
+	)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-27", 40, `"NSX"`), http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Globex", "2026-12-07", "2026-12-18", 20, `"vSAN"`), http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 1, "hoursPerWeek": 20, "status": "accepted"}`, http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/2/assignments", `{"resourceId": 1, "hoursPerWeek": 20}`, http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 2, "hoursPerWeek": 20}`, http.StatusCreated)
+
+	query := `{"query": "query ($n: Int) { resources(pageSize: $n, sort: \"first_name\") { metadata { totalRecords } items { firstName assignments { status request { customer status } } } } }", "variables": {"n": 5}}`
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/graphql", query, http.StatusOK)
+
+	var body struct {
+		Data struct {
+			Resources struct {
+				Metadata struct {
+					TotalRecords int `json:"totalRecords"`
+				} `json:"metadata"`
+				Items []struct {
+					FirstName   string `json:"firstName"`
+					Assignments []struct {
+						Status  string `json:"status"`
+						Request struct {
+							Customer string `json:"customer"`
+							Status   string `json:"status"`
+						} `json:"request"`
+					} `json:"assignments"`
+				} `json:"items"`
+			} `json:"resources"`
+		} `json:"data"`
+		Errors []any `json:"errors"`
+	}
+	decodeJSON(t, rr, &body)
+
+	if len(body.Errors) > 0 {
+		t.Fatalf("errors: %v", body.Errors)
+	}
+
+	resources := body.Data.Resources
+	if resources.Metadata.TotalRecords != 2 || len(resources.Items) != 2 {
+		t.Fatalf("got %d of %d resources, want 2 of 2", len(resources.Items), resources.Metadata.TotalRecords)
+	}
+
+	var got []string
+	for _, r := range resources.Items {
+		for _, a := range r.Assignments {
+			got = append(got, r.FirstName+" "+a.Status+" "+a.Request.Customer+" "+a.Request.Status)
+		}
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"Ada accepted Acme partially_filled",
+		"Ada proposed Globex open",
+		"Bob proposed Acme partially_filled",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("assignments %q, want %q", got, want)
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	app := newTestApplication(t, "-graphql-max-depth", "3", "-graphql-max-complexity", "50")
+	h := app.routes()
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"within limits", `{"query": "{ resources(pageSize: 5) { items { id } } }"}`, ""},
+		{"too deep", `{"query": "{ resources(pageSize: 1) { items { position { title } } } }"}`, "QUERY_TOO_DEEP"},
+		{"too complex", `{"query": "{ resources { items { id } } }"}`, "QUERY_TOO_COMPLEX"},
+		{"too complex through a variable", `{"query": "query ($n: Int) { resources(pageSize: $n) { items { id } } }", "variables": {"n": 100}}`, "QUERY_TOO_COMPLEX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := do(t, h, http.MethodPost, "/v1/graphql", tt.query)
+
+			if tt.code == "" {
+				if rr.Code != http.StatusOK {
+					t.Fatalf("status %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+				}
+				return
+			}
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want %d: %s", rr.Code, http.StatusBadRequest, rr.Body)
+			}
+
+			var body struct {
+				Errors []struct {
+					Extensions struct {
+						Code string `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			decodeJSON(t, rr, &body)
+
+			if len(body.Errors) != 1 || body.Errors[0].Extensions.Code != tt.code {
+				t.Errorf("errors %s, want one with code %s", rr.Body, tt.code)
+			}
+		})
+	}
+}
@@ -1,8 +1,115 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
-func (app *application) handleHealthcheck() http.HandlerFunc {
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+// healthCheck is a named readiness probe. check should return promptly once
+// ctx is done; a check that overruns is reported as failed either way.
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+type healthResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func (app *application) addHealthCheck(name string, check func(ctx context.Context) error) {
+	app.healthChecks = append(app.healthChecks, healthCheck{name: name, check: check})
+}
+
+// registerHealthChecks installs the readiness checks for the configured
+// dependencies. db is nil when running on the in-memory store.
+func (app *application) registerHealthChecks(db *sql.DB) {
+	if db != nil {
+		app.addHealthCheck("database", db.PingContext)
+		app.addHealthCheck("migrations", func(ctx context.Context) error {
+			return data.CheckSchemaVersion(ctx, db)
+		})
+	}
+
+	app.addHealthCheck("backgroundQueue", func(ctx context.Context) error {
+		if depth := app.backgroundTasks.Load(); depth > int64(app.cfg.health.maxBackgroundTasks) {
+			return fmt.Errorf("%d background tasks queued, limit is %d", depth, app.cfg.health.maxBackgroundTasks)
+		}
+		return nil
+	})
+
+	if app.mailer != nil {
+		app.addHealthCheck("smtp", func(ctx context.Context) error {
+			return app.mailer.Ping()
+		})
+	}
+}
+
+// runHealthChecks runs every check concurrently, each bounded by the
+// configured timeout, and reports whether all of them passed.
+func (app *application) runHealthChecks(ctx context.Context) (map[string]healthResult, bool) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]healthResult, len(app.healthChecks))
+		healthy = true
+	)
+
+	for _, hc := range app.healthChecks {
+		wg.Add(1)
+		go func(hc healthCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, app.cfg.health.timeout)
+			defer cancel()
+
+			start := time.Now()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- hc.check(ctx)
+			}()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			result := healthResult{
+				Status:    "pass",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[hc.name] = result
+			if err != nil {
+				healthy = false
+			}
+		}(hc)
+	}
+
+	wg.Wait()
+
+	return results, healthy
+}
+
+// handleHealthz reports liveness: the process is up and serving requests.
+func (app *application) handleHealthz() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		env := envelope{
 			"status": "available",
@@ -18,3 +125,29 @@ func (app *application) handleHealthcheck() http.HandlerFunc {
 		}
 	}
 }
+
+// handleReadyz reports whether the server should receive traffic. It fails
+// as soon as shutdown begins so load balancers drain the instance first.
+func (app *application) handleReadyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.shuttingDown() {
+			err := app.writeJSON(w, http.StatusServiceUnavailable, envelope{"status": "shutting down"}, nil)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		checks, healthy := app.runHealthChecks(r.Context())
+
+		status, code := "ready", http.StatusOK
+		if !healthy {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+
+		err := app.writeJSON(w, code, envelope{"status": status, "checks": checks}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
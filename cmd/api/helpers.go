@@ -1,10 +1,14 @@
 package main
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -17,15 +21,76 @@ import (
 type envelope map[string]any
 
 func (app *application) readIDParam(r *http.Request) (int64, error) {
+	return app.readNamedIDParam(r, "id")
+}
+
+func (app *application) readNamedIDParam(r *http.Request, name string) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
 
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
 	if err != nil || id < 1 {
-		return 0, errors.New("invalid id parameter")
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 	return id, nil
 }
 
+// patternRouter is an httprouter.Router that also keeps the pattern each
+// route was registered with, split into segments, so that middleware outside
+// the router can label requests by route.
+type patternRouter struct {
+	*httprouter.Router
+	patterns map[string][][]string
+}
+
+func newPatternRouter() *patternRouter {
+	return &patternRouter{
+		Router:   httprouter.New(),
+		patterns: make(map[string][][]string),
+	}
+}
+
+func (pr *patternRouter) HandlerFunc(method, path string, handler http.HandlerFunc) {
+	pr.Router.HandlerFunc(method, path, handler)
+	pr.patterns[method] = append(pr.patterns[method], strings.Split(path, "/"))
+}
+
+// routePattern returns the pattern of the route matching r, such as
+// "/v1/resources/:id", or "" when no route matches. Segments are matched by
+// position, so a parameter whose value happens to equal a literal segment
+// elsewhere in the path doesn't change the result. httprouter rejects routes
+// whose literal and parameter segments overlap, so at most one pattern
+// matches.
+func routePattern(router *patternRouter, r *http.Request) string {
+	path := strings.Split(r.URL.Path, "/")
+
+	for _, pattern := range router.patterns[r.Method] {
+		if matchPattern(pattern, path) {
+			return strings.Join(pattern, "/")
+		}
+	}
+
+	return ""
+}
+
+func matchPattern(pattern, path []string) bool {
+	for i, segment := range pattern {
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			return i < len(path)
+		case i >= len(path):
+			return false
+		case strings.HasPrefix(segment, ":"):
+			if path[i] == "" {
+				return false
+			}
+		case segment != path[i]:
+			return false
+		}
+	}
+
+	return len(pattern) == len(path)
+}
+
 func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
 	maxBytes := 1024 * 1024 // 1 MB
 	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
@@ -133,10 +198,65 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+// userFromRequest resolves the bearer token in the Authorization header to
+// the user configured for it. It returns "" for requests without a known
+// token; it only identifies the caller and never refuses one.
+func (app *application) userFromRequest(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || scheme != "Bearer" || token == "" {
+		return ""
+	}
+
+	for user, expected := range app.cfg.auth.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			return user
+		}
+	}
+
+	return ""
+}
+
+// clientIP returns the address of the client, trusting X-Forwarded-For and
+// X-Real-IP only when the server is configured to run behind a proxy.
+func (app *application) clientIP(r *http.Request) string {
+	if app.cfg.accessLog.trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return realIP
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// shuttingDown reports whether graceful shutdown has begun.
+func (app *application) shuttingDown() bool {
+	select {
+	case <-app.shutdown:
+		return true
+	default:
+		return false
+	}
+}
+
 func (app *application) background(fn func()) {
 	app.wg.Add(1)
+	app.backgroundTasks.Add(1)
 	go func() {
 		defer app.wg.Done()
+		defer app.backgroundTasks.Add(-1)
 
 		defer func() {
 			if err := recover(); err != nil {
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutePattern(t *testing.T) {
+	router := newPatternRouter()
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/v1/resources"},
+		{http.MethodGet, "/v1/resources/:id"},
+		{http.MethodGet, "/v1/requests/:id/assignments/:resourceId"},
+		{http.MethodPost, "/v1/webhooks/:id/deliveries/:deliveryId/redeliver"},
+		{http.MethodGet, "/static/*file"},
+	} {
+		router.HandlerFunc(route.method, route.path, func(http.ResponseWriter, *http.Request) {})
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/v1/resources", "/v1/resources"},
+		{http.MethodGet, "/v1/resources/42", "/v1/resources/:id"},
+		{http.MethodGet, "/v1/resources/v1", "/v1/resources/:id"},
+		{http.MethodGet, "/v1/resources/resources", "/v1/resources/:id"},
+		{http.MethodGet, "/v1/requests/7/assignments/7", "/v1/requests/:id/assignments/:resourceId"},
+		{http.MethodGet, "/v1/requests/assignments/assignments/requests", "/v1/requests/:id/assignments/:resourceId"},
+		{http.MethodPost, "/v1/webhooks/3/deliveries/3/redeliver", "/v1/webhooks/:id/deliveries/:deliveryId/redeliver"},
+		{http.MethodGet, "/static/css/site.css", "/static/*file"},
+		{http.MethodGet, "/v1/resources/", ""},
+		{http.MethodGet, "/v1/resources/42/extra", ""},
+		{http.MethodGet, "/v1/unknown", ""},
+		{http.MethodDelete, "/v1/resources/42", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+
+			if got := routePattern(router, r); got != tt.want {
+				t.Errorf("routePattern = %q, want %q", got, tt.want)
+			}
+
+			handle, _, _ := router.Lookup(tt.method, tt.path)
+			if (handle != nil) != (tt.want != "") {
+				t.Errorf("httprouter matched %t, routePattern %q", handle != nil, tt.want)
+			}
+		})
+	}
+}
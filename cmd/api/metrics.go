@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/metrics"
+)
+
+type appMetrics struct {
+	registry *metrics.Registry
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	inFlight *metrics.Gauge
+}
+
+func (app *application) newMetrics(db *sql.DB) *appMetrics {
+	r := metrics.NewRegistry()
+
+	m := &appMetrics{
+		registry: r,
+		requests: metrics.NewCounterVec(r, "dashboard_http_requests_total", "Total HTTP requests by method, route and response status class.", "method", "route", "status_class"),
+		duration: metrics.NewHistogramVec(r, "dashboard_http_request_duration_seconds", "HTTP request latency by method and route.", metrics.DefBuckets, "method", "route"),
+		inFlight: metrics.NewGauge(r, "dashboard_http_requests_in_flight", "HTTP requests currently being served."),
+	}
+
+	metrics.NewGaugeFunc(r, "dashboard_background_goroutines", "Background goroutines tracked by the application wait group.", func() float64 {
+		return float64(app.backgroundTasks.Load())
+	})
+
+	metrics.NewGaugeFunc(r, "dashboard_sse_subscribers", "Connected event stream clients.", func() float64 {
+		return float64(app.events.subscriberCount())
+	})
+
+	if db != nil {
+		stats := func(fn func(sql.DBStats) float64) func() float64 {
+			return func() float64 { return fn(db.Stats()) }
+		}
+
+		metrics.NewGaugeFunc(r, "dashboard_db_max_open_connections", "Maximum number of open connections to the database.", stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+		metrics.NewGaugeFunc(r, "dashboard_db_open_connections", "Established connections to the database, both in use and idle.", stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+		metrics.NewGaugeFunc(r, "dashboard_db_in_use_connections", "Database connections currently in use.", stats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+		metrics.NewGaugeFunc(r, "dashboard_db_idle_connections", "Idle database connections.", stats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+		metrics.NewCounterFunc(r, "dashboard_db_wait_count_total", "Total number of connections waited for.", stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+		metrics.NewCounterFunc(r, "dashboard_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+		metrics.NewCounterFunc(r, "dashboard_db_max_idle_closed_total", "Total connections closed due to SetMaxIdleConns.", stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+		metrics.NewCounterFunc(r, "dashboard_db_max_idle_time_closed_total", "Total connections closed due to SetConnMaxIdleTime.", stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }))
+		metrics.NewCounterFunc(r, "dashboard_db_max_lifetime_closed_total", "Total connections closed due to SetConnMaxLifetime.", stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+	}
+
+	metrics.NewInfo(r, "dashboard_build_info", "Build information about the running API.", map[string]string{
+		"version":   version,
+		"goversion": runtime.Version(),
+	})
+
+	return m
+}
+
+// instrument records request counts, latencies and in-flight requests. Routes
+// are labelled by their registered pattern rather than the raw path so that
+// IDs don't explode the number of series.
+func (app *application) instrument(router *patternRouter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		app.metrics.inFlight.Inc()
+		defer app.metrics.inFlight.Dec()
+
+		rec := newStatusRecorder(w)
+
+		next.ServeHTTP(rec, r)
+
+		route := routePattern(router, r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		app.metrics.requests.Inc(r.Method, route, strconv.Itoa(rec.status/100)+"xx")
+		app.metrics.duration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+func (app *application) handleMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		_, err := app.metrics.registry.WriteTo(w)
+		if err != nil {
+			app.errorLog(r, err)
+		}
+	}
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
 )
 
+var requestIDRX = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
 func (app *application) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -18,6 +24,90 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
+// requestLogger tags each request with an ID and stores a logger carrying
+// that ID in the request context, so that anything logged while serving the
+// request can be correlated. A well-formed X-Request-ID from the client is
+// reused; otherwise a new ID is generated. Either way it is echoed back in the
+// response.
+func (app *application) requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validator.Matches(id, requestIDRX) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+
+		r = app.contextSetRequestID(r, id)
+		r = app.contextSetLogger(r, app.logger.With(map[string]any{"request_id": id}))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accessLog writes one entry per request through the request-scoped logger,
+// skipping any path listed in the access log exclusions.
+func (app *application) accessLog(router *patternRouter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if validator.PermittedValue(r.URL.Path, app.cfg.accessLog.exclude...) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := newStatusRecorder(w)
+
+		defer func() {
+			properties := map[string]any{
+				"method":      r.Method,
+				"route":       routePattern(router, r),
+				"path":        r.URL.Path,
+				"status":      rec.status,
+				"bytes":       rec.bytes,
+				"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+				"client_ip":   app.clientIP(r),
+			}
+
+			if user := app.contextGetUser(r); user != "" {
+				properties["user"] = user
+			}
+
+			app.contextGetLogger(r).PrintInfo("request completed", properties)
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// identifyUser stores the user named by a configured bearer token in the
+// request context, for the access log and for the records that note who made
+// a change. It is not authentication: requests without a known token carry
+// on anonymously.
+func (app *application) identifyUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		if user := app.userFromRequest(r); user != "" {
+			r = app.contextSetUser(r, user)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hsts tells browsers to use HTTPS for all future requests. It only applies
+// in production and when the server terminates TLS itself.
+func (app *application) hsts(next http.Handler) http.Handler {
+	if app.cfg.env != "production" || app.cfg.tls.certFile == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *application) enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Origin")
@@ -29,11 +119,12 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 			for i := range app.cfg.cors.trustedOrigins {
 				if origin == app.cfg.cors.trustedOrigins[i] {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 
 						w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-ID")
 
 						w.WriteHeader(http.StatusOK)
 						return
@@ -47,3 +138,36 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// statusRecorder captures the status code and body size written by the
+// wrapped handler. Unwrap lets http.ResponseController reach the underlying
+// writer for flushing and deadlines.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
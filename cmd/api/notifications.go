@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+const emailMaxAttempts = 3
+
+// sendEmail delivers a templated email, retrying with a growing delay. It
+// blocks, so callers on the request path should run it via app.background.
+func (app *application) sendEmail(recipients []string, templateFile string, data any) error {
+	var err error
+
+	for attempt := 1; attempt <= emailMaxAttempts; attempt++ {
+		err = app.mailer.Send(recipients, templateFile, data)
+		if err == nil {
+			return nil
+		}
+
+		if attempt < emailMaxAttempts {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+	}
+
+	return err
+}
+
+func (app *application) notifyAssignment(resource *data.Resource, rr *data.ResourceRequest, assignment *data.ResourceAssignment) {
+	if app.mailer == nil {
+		return
+	}
+
+	recipients := append([]string{}, app.cfg.notifications.staffingRecipients...)
+	if resource.Email != "" {
+		recipients = append(recipients, resource.Email)
+	}
+
+	if len(recipients) == 0 {
+		return
+	}
+
+	emailData := map[string]any{
+		"Resource":   *resource,
+		"Request":    *rr,
+		"Assignment": *assignment,
+	}
+
+	app.background(func() {
+		err := app.sendEmail(recipients, "assignment_created.tmpl", emailData)
+		if err != nil {
+			app.logger.PrintError(err, map[string]any{
+				"resource_request_id": rr.ID,
+				"resource_id":         resource.ID,
+			})
+		}
+	})
+}
+
+// notifyAcceptedAssignment sends the assignment email for an assignment that
+// has just been accepted. The assignment itself has already changed, so a
+// failure to look up its request or resource is only logged.
+func (app *application) notifyAcceptedAssignment(r *http.Request, assignment *data.ResourceAssignment) {
+	if app.mailer == nil {
+		return
+	}
+
+	rr, err := app.models.ResourceRequests.Get(r.Context(), assignment.ResourceRequestID)
+	if err != nil {
+		app.errorLog(r, err)
+		return
+	}
+
+	resource, err := app.models.Resources.Get(r.Context(), assignment.ResourceID)
+	if err != nil {
+		app.errorLog(r, err)
+		return
+	}
+
+	app.notifyAssignment(resource, rr, assignment)
+}
+
+// startUnfilledRequestNotifier periodically emails the staffing recipients
+// about open requests that are about to start with nobody assigned. It runs
+// until the server begins shutting down.
+func (app *application) startUnfilledRequestNotifier() {
+	if app.mailer == nil || len(app.cfg.notifications.staffingRecipients) == 0 {
+		return
+	}
+
+	app.background(func() {
+		ticker := time.NewTicker(app.cfg.notifications.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			app.notifyUnfilledRequests(context.Background())
+
+			select {
+			case <-app.shutdown:
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func (app *application) notifyUnfilledRequests(ctx context.Context) {
+	requests, err := app.models.ResourceRequests.GetUnfilledStartingBefore(ctx, time.Now().Add(app.cfg.notifications.unfilledLeadTime))
+	if err != nil {
+		app.logger.PrintError(err, nil)
+		return
+	}
+
+	for _, rr := range requests {
+		emailData := map[string]any{
+			"Request":        *rr,
+			"DaysUntilStart": int(time.Until(rr.StartDate).Hours()/24) + 1,
+		}
+
+		err := app.sendEmail(app.cfg.notifications.staffingRecipients, "request_unfilled.tmpl", emailData)
+		if err != nil {
+			app.logger.PrintError(err, map[string]any{"resource_request_id": rr.ID})
+			continue
+		}
+
+		err = app.models.ResourceRequests.MarkUnfilledNotified(ctx, rr.ID)
+		if err != nil {
+			app.logger.PrintError(err, map[string]any{"resource_request_id": rr.ID})
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/mailer"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/mailer/mailertest"
+)
+
+func TestSendEmailRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantErr      bool
+		wantRejected int
+		wantMessages int
+	}{
+		{"first attempt", 0, false, 0, 1},
+		{"after failures", emailMaxAttempts - 1, false, emailMaxAttempts - 1, 1},
+		{"attempts used up", emailMaxAttempts, true, emailMaxAttempts, 0},
+	}
+
+	data := map[string]any{
+		"Request": map[string]any{
+			"ID":        1,
+			"Customer":  "Acme",
+			"StartDate": time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC),
+		},
+		"DaysUntilStart": 3,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := mailertest.NewServer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+
+			sink.FailNext(tt.failures)
+
+			app := &application{
+				mailer: mailer.New(sink.Host(), sink.Port(), "", "", "no-reply@example.com", time.Second),
+			}
+
+			err = app.sendEmail([]string{"staffing@example.com"}, "request_unfilled.tmpl", data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendEmail error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if got := sink.Rejected(); got != tt.wantRejected {
+				t.Errorf("rejected %d attempts, want %d", got, tt.wantRejected)
+			}
+			if got := len(sink.Messages()); got != tt.wantMessages {
+				t.Errorf("delivered %d messages, want %d", got, tt.wantMessages)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type specRoute struct {
+	method string
+	path   string
+}
+
+// registeredRoutes extracts every mux.HandlerFunc(method, path, ...) call
+// from routes.go, with httprouter's :param segments rewritten as {param}.
+func registeredRoutes(t *testing.T) []specRoute {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "routes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	param := regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+	var routes []specRoute
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "HandlerFunc" && sel.Sel.Name != "Handler") {
+			return true
+		}
+
+		method, ok := call.Args[0].(*ast.SelectorExpr)
+		if !ok || !strings.HasPrefix(method.Sel.Name, "Method") {
+			return true
+		}
+
+		lit, ok := call.Args[1].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+
+		path, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		routes = append(routes, specRoute{
+			method: strings.ToLower(strings.TrimPrefix(method.Sel.Name, "Method")),
+			path:   param.ReplaceAllString(path, "{$1}"),
+		})
+
+		return true
+	})
+
+	if len(routes) == 0 {
+		t.Fatal("found no routes in routes.go")
+	}
+
+	return routes
+}
+
+func loadSpec(t *testing.T) map[string]any {
+	t.Helper()
+
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	return spec
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	spec := loadSpec(t)
+	paths, _ := spec["paths"].(map[string]any)
+
+	registered := make(map[specRoute]bool)
+
+	for _, route := range registeredRoutes(t) {
+		registered[route] = true
+
+		item, ok := paths[route.path].(map[string]any)
+		if !ok {
+			t.Errorf("%s %s is registered but missing from openapi.json", strings.ToUpper(route.method), route.path)
+			continue
+		}
+		if _, ok := item[route.method]; !ok {
+			t.Errorf("%s %s is registered but its method is missing from openapi.json", strings.ToUpper(route.method), route.path)
+		}
+	}
+
+	methods := []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+	for path, raw := range paths {
+		item, _ := raw.(map[string]any)
+		for _, method := range methods {
+			if _, ok := item[method]; ok && !registered[specRoute{method, path}] {
+				t.Errorf("%s %s is documented in openapi.json but not registered in routes.go", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	spec := loadSpec(t)
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				var target any = spec
+				for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					obj, _ := target.(map[string]any)
+					target = obj[key]
+				}
+				if target == nil {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	walk(spec)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/patch"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// patchable describes how a record's JSON form may be patched.
+type patchable struct {
+	// defaults fill in members the record omits or leaves null when empty,
+	// so that a patch can address them, e.g. to append to an empty list.
+	defaults map[string]any
+	// readOnly members must be left as they are.
+	readOnly []string
+}
+
+var (
+	resourcePatchable = patchable{
+		defaults: map[string]any{"specialties": []any{}, "certifications": []any{}, "email": ""},
+		readOnly: []string{"resourceId"},
+	}
+	requestPatchable = patchable{
+		defaults: map[string]any{"skills": []any{}, "projectID": "", "engagementID": ""},
+		readOnly: []string{"id", "projectID", "engagementID", "createdAt", "updatedAt", "version", "status", "statusChangedAt"},
+	}
+)
+
+// patchMediaType returns the patch format of r's body, or "" when the body
+// is the plain JSON the PATCH endpoints have always taken.
+func patchMediaType(r *http.Request) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case mergePatchType, jsonPatchType:
+		return mediaType
+	default:
+		return ""
+	}
+}
+
+// patchFieldError is a patch that left a member of the record invalid.
+type patchFieldError struct {
+	field   string
+	message string
+}
+
+func (e *patchFieldError) Error() string {
+	return e.field + " " + e.message
+}
+
+// applyPatch applies the merge patch or JSON Patch in r's body to record's
+// JSON form and decodes the result back into record, a pointer to a struct.
+// Members a merge patch sets to null, or a JSON Patch removes, are cleared.
+func (app *application) applyPatch(w http.ResponseWriter, r *http.Request, record any, p patchable) error {
+	maxBytes := 1024 * 1024 // 1 MB
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if err.Error() == "http: request body too large" {
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+		}
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errors.New("body must not be empty")
+	}
+
+	js, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	original, err := patch.Decode(js)
+	if err != nil {
+		return err
+	}
+
+	doc := original.(map[string]any)
+	for member, value := range p.defaults {
+		if v, ok := doc[member]; !ok || v == nil {
+			doc[member] = value
+		}
+	}
+
+	var patched any
+
+	switch patchMediaType(r) {
+	case mergePatchType:
+		mergePatch, err := patch.Decode(body)
+		if err != nil {
+			return fmt.Errorf("body contains badly formed JSON: %w", err)
+		}
+		if _, ok := mergePatch.(map[string]any); !ok {
+			return errors.New("body must be a JSON object")
+		}
+		patched = patch.Merge(doc, mergePatch)
+
+	case jsonPatchType:
+		ops, err := patch.DecodeOperations(body)
+		if err != nil {
+			if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+				return fmt.Errorf("body contains unknown key %s", field)
+			}
+			return fmt.Errorf("body must be a JSON array of patch operations: %w", err)
+		}
+		patched, err = patch.Apply(doc, ops)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, ok := patched.(map[string]any)
+	if !ok {
+		return &patchFieldError{field: "body", message: "must leave the record a JSON object"}
+	}
+
+	for _, member := range p.readOnly {
+		if !patch.Equal(doc[member], result[member]) {
+			return &patchFieldError{field: member, message: "cannot be changed"}
+		}
+	}
+
+	js, err = json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	// Start from the zero record so that removed members are cleared.
+	target := reflect.ValueOf(record).Elem()
+	target.Set(reflect.Zero(target.Type()))
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(record); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			return &patchFieldError{field: typeErr.Field, message: "has the wrong JSON type"}
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			return &patchFieldError{field: field, message: "is not a field of this record"}
+		default:
+			// Values such as malformed dates fail in the field's own
+			// decoder, which doesn't say which member it was.
+			return &patchFieldError{field: "body", message: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// patchErrorResponse reports why applyPatch failed: a failed test is a
+// conflict with the record's current state, a patch that doesn't fit the
+// record is a validation failure and anything else is a bad request.
+func (app *application) patchErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldErr *patchFieldError
+	var patchErr *patch.Error
+
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		app.problemResponse(w, r, problem{
+			Type:   problemPatchTestFailed,
+			Title:  "Patch test failed",
+			Status: http.StatusConflict,
+			Detail: err.Error(),
+		})
+	case errors.As(err, &fieldErr):
+		app.failedValidationResponse(w, r, map[string]string{fieldErr.field: fieldErr.message})
+	case errors.As(err, &patchErr):
+		app.failedValidationResponse(w, r, map[string]string{fmt.Sprintf("patch[%d]", patchErr.Index): patchErr.Err.Error()})
+	default:
+		app.badRequestResponse(w, r, err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
@@ -30,7 +31,7 @@ func (app *application) handleCreatePosition() http.HandlerFunc {
 			return
 		}
 
-		err = app.models.Positions.Insert(position)
+		err = app.models.Positions.Insert(r.Context(), position)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
@@ -42,3 +43,128 @@ func (app *application) handleCreatePosition() http.HandlerFunc {
 		}
 	}
 }
+
+func (app *application) handleListPositions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		positions, err := app.models.Positions.GetAll(r.Context())
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"positions": positions}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleShowPosition() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		position, err := app.models.Positions.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"position": position}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleUpdatePosition() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		position, err := app.models.Positions.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		var input struct {
+			Title *string `json:"title"`
+		}
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if input.Title != nil {
+			position.Title = *input.Title
+		}
+
+		v := validator.New()
+
+		if data.ValidateTitle(v, position.Title); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.Positions.Update(r.Context(), *position)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"position": position}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleDeletePosition() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		err = app.models.Positions.Delete(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
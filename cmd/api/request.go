@@ -0,0 +1,385 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// requestInput is the body that creates a resource request. Requests start
+// open unless they are created as drafts.
+type requestInput struct {
+	Customer      string    `json:"customer"`
+	StartDate     time.Time `json:"startDate"`
+	EndDate       time.Time `json:"endDate"`
+	HoursPerWeek  int64     `json:"hoursPerWeek"`
+	Skills        []string  `json:"skills"`
+	OpportunityID string    `json:"projectID"`
+	EngagementID  string    `json:"engagementID"`
+	Status        string    `json:"status"`
+}
+
+func (input requestInput) request() *data.ResourceRequest {
+	status := input.Status
+	if status == "" {
+		status = data.RequestOpen
+	}
+
+	return &data.ResourceRequest{
+		Customer:      input.Customer,
+		StartDate:     input.StartDate,
+		EndDate:       input.EndDate,
+		HoursPerWeek:  input.HoursPerWeek,
+		Skills:        input.Skills,
+		OpportunityID: input.OpportunityID,
+		EngagementID:  input.EngagementID,
+		Status:        status,
+	}
+}
+
+// validateNewRequestStatus checks that a request is created as a draft or
+// open; it reaches any other status by transition.
+func validateNewRequestStatus(v *validator.Validator, rr *data.ResourceRequest) {
+	v.Check(validator.PermittedValue(rr.Status, data.RequestDraft, data.RequestOpen), "status", "must be draft or open")
+}
+
+// requestUpdate is the body that updates a resource request. Only the
+// fields present change; the status changes by transition.
+type requestUpdate struct {
+	Customer     *string    `json:"customer"`
+	StartDate    *time.Time `json:"startDate"`
+	EndDate      *time.Time `json:"endDate"`
+	HoursPerWeek *int64     `json:"hoursPerWeek"`
+	Skills       []string   `json:"skills"`
+}
+
+func (input requestUpdate) apply(rr *data.ResourceRequest) {
+	if input.Customer != nil {
+		rr.Customer = *input.Customer
+	}
+
+	if input.StartDate != nil {
+		rr.StartDate = *input.StartDate
+	}
+
+	if input.EndDate != nil {
+		rr.EndDate = *input.EndDate
+	}
+
+	if input.HoursPerWeek != nil {
+		rr.HoursPerWeek = *input.HoursPerWeek
+	}
+
+	if input.Skills != nil {
+		rr.Skills = input.Skills
+	}
+}
+
+func (app *application) handleCreateResourceRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input requestInput
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		rr := input.request()
+
+		v := validator.New()
+
+		data.ValidateResourceRequest(v, *rr)
+		validateNewRequestStatus(v, rr)
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.ResourceRequests.Insert(r.Context(), rr)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		app.publishEvent(data.EventRequestCreated, envelope{"request": rr})
+
+		err = app.writeJSON(w, http.StatusCreated, envelope{"request": rr}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleShowResourceRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		v := validator.New()
+
+		include := app.readCSV(r.URL.Query(), "include", []string{})
+
+		if validateInclude(v, include, requestIncludeSafelist); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		rr, err := app.models.ResourceRequests.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if len(include) == 0 {
+			err = app.writeJSON(w, http.StatusOK, envelope{"request": rr}, lastModified(rr.UpdatedAt))
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		records, err := projectRecords([]*data.ResourceRequest{rr}, "id", nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.includeForRequests(r.Context(), []*data.ResourceRequest{rr}, records, include)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"request": records[0]}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleUpdateResourceRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		rr, err := app.models.ResourceRequests.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		start, end := rr.StartDate, rr.EndDate
+
+		if patchMediaType(r) != "" {
+			err = app.applyPatch(w, r, rr, requestPatchable)
+			if err != nil {
+				app.patchErrorResponse(w, r, err)
+				return
+			}
+		} else {
+			var input requestUpdate
+
+			err = app.readJSON(w, r, &input)
+			if err != nil {
+				app.badRequestResponse(w, r, err)
+				return
+			}
+
+			input.apply(rr)
+		}
+
+		v := validator.New()
+
+		force := app.readForce(r, v)
+
+		if data.ValidateResourceRequest(v, *rr); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		var conflicts []*data.AllocationConflict
+		var moved *data.RequestTransition
+
+		err = app.models.WithTx(r.Context(), func(tx *data.Models) error {
+			if !rr.StartDate.Equal(start) || !rr.EndDate.Equal(end) {
+				conflicts, err = app.checkRequestAllocation(r.Context(), tx, rr)
+				if err != nil {
+					return err
+				}
+
+				if !app.allowAllocation(conflicts, force) {
+					return errOverAllocated
+				}
+			}
+
+			moved, err = tx.ResourceRequests.Update(r.Context(), rr)
+			return err
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, errOverAllocated):
+				app.overAllocatedResponse(w, r, conflicts)
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		app.publishEvent(data.EventRequestUpdated, envelope{"request": rr})
+		if moved != nil {
+			app.publishEvent(data.EventRequestStatusChanged, envelope{"request": rr, "transition": moved})
+		}
+
+		err = app.writeJSON(w, http.StatusOK, withConflicts(envelope{"request": rr}, conflicts), nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleDeleteResourceRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		err = app.models.ResourceRequests.Delete(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		app.publishEvent(data.EventRequestDeleted, envelope{"requestId": id})
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// requestListInput is the query GET /v1/requests takes.
+type requestListInput struct {
+	Customer string
+	Skills   []string
+	Statuses []string
+	Include  []string
+	data.Filters
+}
+
+// requestViewParams are the list parameters a saved view can hold.
+var requestViewParams = []string{"customer", "skills", "status", "sort", "fields", "include", "page_size"}
+
+func (app *application) readRequestListInput(qs url.Values, v *validator.Validator) requestListInput {
+	var input requestListInput
+
+	input.Customer = app.readString(qs, "customer", "")
+	input.Skills = app.readCSV(qs, "skills", []string{})
+	input.Statuses = app.readCSV(qs, "status", []string{})
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "customer", "start_date", "end_date", "-id", "-customer", "-start_date", "-end_date"}
+	input.Filters.Fields = app.readCSV(qs, "fields", []string{})
+	input.Filters.FieldSafelist = requestFieldSafelist
+	input.Include = app.readCSV(qs, "include", []string{})
+
+	validateInclude(v, input.Include, requestIncludeSafelist)
+
+	for _, status := range input.Statuses {
+		v.Check(validator.PermittedValue(status, data.RequestStatuses...), "status", "must contain only valid request statuses")
+	}
+
+	data.ValidateFilters(v, input.Filters)
+
+	return input
+}
+
+func (app *application) handleListResourceRequests() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		qs := r.URL.Query()
+
+		view, ok := app.applyView(w, r, qs, data.ViewListRequests)
+		if !ok {
+			return
+		}
+
+		v := validator.New()
+
+		input := app.readRequestListInput(qs, v)
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		requests, metadata, err := app.models.ResourceRequests.GetAll(r.Context(), input.Customer, input.Skills, input.Statuses, input.Filters)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		env := envelope{"requests": requests, "metadata": metadata}
+
+		if len(input.Filters.Fields) > 0 || len(input.Include) > 0 {
+			records, err := projectRecords(requests, "id", input.Filters.Fields)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			err = app.includeForRequests(r.Context(), requests, records, input.Include)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			env["requests"] = records
+		}
+
+		if view != nil {
+			env["view"], err = app.summarizeView(r, view, func(since time.Time) (int, error) {
+				return app.models.ResourceRequests.CountUpdatedSince(r.Context(), input.Customer, input.Skills, input.Statuses, since)
+			})
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+		}
+
+		err = app.writeJSON(w, http.StatusOK, env, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// fillTransitionEvent returns the request.status_changed payload for moved, a
+// transition that a change to the request's assignments made, or nil if moved
+// is nil. The request is read through models so that a transaction sees its
+// own writes.
+func fillTransitionEvent(ctx context.Context, models *data.Models, moved *data.RequestTransition) (envelope, error) {
+	if moved == nil {
+		return nil, nil
+	}
+
+	rr, err := models.ResourceRequests.Get(ctx, moved.RequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope{"request": rr, "transition": moved}, nil
+}
+
+func (app *application) handleTransitionRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		var input struct {
+			Status string `json:"status"`
+			Reason string `json:"reason"`
+			// Version, when given, must match the request's, so that a
+			// client only moves the request it last saw.
+			Version *int64 `json:"version"`
+		}
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		rr, err := app.models.ResourceRequests.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if input.Version != nil && *input.Version != rr.Version {
+			app.editConflictResponse(w, r)
+			return
+		}
+
+		v := validator.New()
+
+		if data.ValidateTransition(v, rr.Status, input.Status, input.Reason); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		transition, err := app.models.ResourceRequests.Transition(r.Context(), rr, input.Status, input.Reason, app.contextGetUser(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w, r)
+			case errors.Is(err, data.ErrInvalidTransition):
+				app.invalidTransitionResponse(w, r, rr.Status, input.Status, data.NextStatuses(rr.Status))
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		env := envelope{"request": rr, "transition": transition}
+
+		app.publishEvent(data.EventRequestStatusChanged, env)
+
+		err = app.writeJSON(w, http.StatusOK, env, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleListRequestTransitions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		_, err = app.models.ResourceRequests.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		transitions, err := app.models.ResourceRequests.GetTransitions(r.Context(), id)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"transitions": transitions}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+// requestBody is the JSON for a new request from start to end, both given
+// as dates.
+func requestBody(customer, start, end string, hours int, skills string) string {
+	return fmt.Sprintf(`{"customer": %q, "startDate": "%sT00:00:00Z", "endDate": "%sT00:00:00Z", "hoursPerWeek": %d, "skills": [%s]}`,
+		customer, start, end, hours, skills)
+}
+
+func TestListResourceRequests(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	for _, body := range []string{
+		requestBody("Acme Bank", "2026-11-02", "2026-12-18", 40, `"NSX"`),
+		requestBody("Globex", "2026-10-26", "2026-11-27", 20, `"vSAN"`),
+		requestBody("Acme Insurance", "2027-01-04", "2027-03-26", 40, `"NSX", "vSAN"`),
+		requestBody("Initech", "2026-12-07", "2027-02-05", 16, `"NSX"`),
+	} {
+		mustDo(t, h, http.MethodPost, "/v1/requests", body, http.StatusCreated)
+	}
+	mustDo(t, h, http.MethodPost, "/v1/requests/4/transitions", `{"status": "on_hold", "reason": "budget"}`, http.StatusOK)
+
+	tests := []struct {
+		name     string
+		query    string
+		want     []int64
+		metadata data.Metadata
+	}{
+		{
+			name:     "defaults",
+			want:     []int64{1, 2, 3, 4},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 4},
+		},
+		{
+			name:     "customer",
+			query:    "?customer=acme",
+			want:     []int64{1, 3},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 2},
+		},
+		{
+			name:     "skills",
+			query:    "?skills=NSX,vSAN",
+			want:     []int64{3},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 1},
+		},
+		{
+			name:     "status",
+			query:    "?status=on_hold",
+			want:     []int64{4},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 1},
+		},
+		{
+			name:     "sorted by start date",
+			query:    "?sort=start_date",
+			want:     []int64{2, 1, 4, 3},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 4},
+		},
+		{
+			name:     "sorted by end date, descending, second page",
+			query:    "?sort=-end_date&page_size=3&page=2",
+			want:     []int64{2},
+			metadata: data.Metadata{CurrentPage: 2, PageSize: 3, FirstPage: 1, LastPage: 2, TotalRecords: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := mustDo(t, h, http.MethodGet, "/v1/requests"+tt.query, "", http.StatusOK)
+
+			var body struct {
+				Requests []data.ResourceRequest `json:"requests"`
+				Metadata data.Metadata          `json:"metadata"`
+			}
+			decodeJSON(t, rr, &body)
+
+			ids := []int64{}
+			for _, rr := range body.Requests {
+				ids = append(ids, rr.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("requests %v, want %v", ids, tt.want)
+			}
+			if body.Metadata != tt.metadata {
+				t.Errorf("metadata %+v, want %+v", body.Metadata, tt.metadata)
+			}
+		})
+	}
+}
+
+func TestEditConflicts(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+	ctx := context.Background()
+
+	seedResources(t, h, `{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"}`)
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-27", 40, `"NSX"`), http.StatusCreated)
+	mustDo(t, h, http.MethodPost, "/v1/requests/1/assignments", `{"resourceId": 1, "hoursPerWeek": 20}`, http.StatusCreated)
+
+	t.Run("request updated since read", func(t *testing.T) {
+		first, err := app.models.ResourceRequests.Get(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := app.models.ResourceRequests.Get(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first.Customer = "Acme Bank"
+		if _, err := app.models.ResourceRequests.Update(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+
+		second.Customer = "Acme Insurance"
+		if _, err := app.models.ResourceRequests.Update(ctx, second); !errors.Is(err, data.ErrEditConflict) {
+			t.Fatalf("second Update error = %v, want ErrEditConflict", err)
+		}
+
+		saved, err := app.models.ResourceRequests.Get(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Customer != "Acme Bank" || saved.Version != first.Version {
+			t.Errorf("saved customer %q version %d, want %q version %d", saved.Customer, saved.Version, "Acme Bank", first.Version)
+		}
+	})
+
+	t.Run("assignment updated since read", func(t *testing.T) {
+		first, err := app.models.ResourceAssignments.Get(ctx, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := app.models.ResourceAssignments.Get(ctx, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first.HoursPerWeek = 30
+		if _, err := app.models.ResourceAssignments.Update(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+
+		second.HoursPerWeek = 10
+		if _, err := app.models.ResourceAssignments.Update(ctx, second); !errors.Is(err, data.ErrEditConflict) {
+			t.Fatalf("second Update error = %v, want ErrEditConflict", err)
+		}
+	})
+
+	t.Run("stale version sent to a transition", func(t *testing.T) {
+		rr := mustDo(t, h, http.MethodGet, "/v1/requests/1", "", http.StatusOK)
+
+		var body struct {
+			Request data.ResourceRequest `json:"request"`
+		}
+		decodeJSON(t, rr, &body)
+
+		stale := fmt.Sprintf(`{"status": "on_hold", "reason": "budget", "version": %d}`, body.Request.Version-1)
+
+		rr = mustDo(t, h, http.MethodPost, "/v1/requests/1/transitions", stale, http.StatusConflict)
+
+		var p problem
+		decodeJSON(t, rr, &p)
+		if p.Type != problemEditConflict {
+			t.Errorf("problem type %q, want %q", p.Type, problemEditConflict)
+		}
+
+		current := fmt.Sprintf(`{"status": "on_hold", "reason": "budget", "version": %d}`, body.Request.Version)
+		mustDo(t, h, http.MethodPost, "/v1/requests/1/transitions", current, http.StatusOK)
+	})
+}
+
+func TestTransitionRequestInvalid(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2026-11-27", 40, `"NSX"`), http.StatusCreated)
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/requests/1/transitions", `{"status": "in_delivery"}`, http.StatusUnprocessableEntity)
+
+	var p problem
+	decodeJSON(t, rr, &p)
+	if want := "cannot change from open to in_delivery; it can change to on_hold, cancelled"; p.Errors["status"] != want {
+		t.Errorf("status error %q, want %q", p.Errors["status"], want)
+	}
+
+	// A final status has no statuses to allow, so the problem leaves them
+	// out.
+	tests := []struct {
+		current string
+		allowed []string
+	}{
+		{data.RequestOpen, []string{data.RequestOnHold, data.RequestCancelled}},
+		{data.RequestClosed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			app.invalidTransitionResponse(rr, httptest.NewRequest(http.MethodPost, "/v1/requests/1/transitions", nil), tt.current, data.RequestInDelivery, data.NextStatuses(tt.current))
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+			}
+
+			var p problem
+			decodeJSON(t, rr, &p)
+
+			if p.Type != problemInvalidTransition || p.CurrentStatus != tt.current || !reflect.DeepEqual(p.AllowedStatuses, tt.allowed) {
+				t.Errorf("problem %s, want %s from %s allowing %v", rr.Body, problemInvalidTransition, tt.current, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestCreateRequestSpan(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2031-11-02", 40, `"NSX"`), http.StatusCreated)
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/requests", requestBody("Acme", "2026-11-02", "2031-11-03", 40, `"NSX"`), http.StatusUnprocessableEntity)
+
+	var p problem
+	decodeJSON(t, rr, &p)
+	if want := "must not be more than 5 years after startDate"; p.Errors["endDate"] != want {
+		t.Errorf("endDate error %q, want %q", p.Errors["endDate"], want)
+	}
+}
@@ -3,24 +3,111 @@ package main
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/filter"
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
 )
 
+// resourceInput is the body that creates a resource.
+type resourceInput struct {
+	ID             int64    `json:"id"`
+	FirstName      string   `json:"firstName"`
+	LastName       string   `json:"lastName"`
+	Position       string   `json:"position"`
+	Clearance      string   `json:"clearance"`
+	Specialties    []string `json:"specialties"`
+	Certifications []string `json:"certifications"`
+	Active         bool     `json:"active"`
+	Sex            string   `json:"sex"`
+	Email          string   `json:"email"`
+	CapacityHours  *int64   `json:"capacityHours"`
+}
+
+// resource returns the resource input describes, with capacity hours a week
+// unless it gives its own.
+func (input resourceInput) resource(capacity int64) data.Resource {
+	if input.CapacityHours != nil {
+		capacity = *input.CapacityHours
+	}
+
+	return data.Resource{
+		ID:             input.ID,
+		FirstName:      input.FirstName,
+		LastName:       input.LastName,
+		Position:       input.Position,
+		Clearance:      input.Clearance,
+		Specialties:    input.Specialties,
+		Certifications: input.Certifications,
+		Active:         input.Active,
+		Sex:            input.Sex,
+		Email:          input.Email,
+		CapacityHours:  capacity,
+	}
+}
+
+// resourceUpdate is the body that updates a resource. Only the fields
+// present change.
+type resourceUpdate struct {
+	FirstName      *string  `json:"firstName"`
+	LastName       *string  `json:"lastName"`
+	Position       *string  `json:"position"`
+	Clearance      *string  `json:"clearance"`
+	Specialties    []string `json:"specialties"`
+	Certifications []string `json:"certifications"`
+	Active         *bool    `json:"active"`
+	Sex            *string  `json:"sex"`
+	Email          *string  `json:"email"`
+	CapacityHours  *int64   `json:"capacityHours"`
+}
+
+func (input resourceUpdate) apply(resource *data.Resource) {
+	if input.FirstName != nil {
+		resource.FirstName = *input.FirstName
+	}
+
+	if input.LastName != nil {
+		resource.LastName = *input.LastName
+	}
+
+	if input.Position != nil {
+		resource.Position = *input.Position
+	}
+
+	if input.Clearance != nil {
+		resource.Clearance = *input.Clearance
+	}
+
+	if input.Specialties != nil {
+		resource.Specialties = input.Specialties
+	}
+
+	if input.Certifications != nil {
+		resource.Certifications = input.Certifications
+	}
+
+	if input.Active != nil {
+		resource.Active = *input.Active
+	}
+
+	if input.Sex != nil {
+		resource.Sex = *input.Sex
+	}
+
+	if input.Email != nil {
+		resource.Email = *input.Email
+	}
+
+	if input.CapacityHours != nil {
+		resource.CapacityHours = *input.CapacityHours
+	}
+}
+
 func (app *application) handleCreateResource() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var input struct {
-			ID             int64    `json:"id"`
-			FirstName      string   `json:"firstName"`
-			LastName       string   `json:"lastName"`
-			Position       string   `json:"position"`
-			Clearance      string   `json:"clearance"`
-			Specialties    []string `json:"specialties"`
-			Certifications []string `json:"certifications"`
-			Active         bool     `json:"active"`
-			Sex            string   `json:"sex"`
-		}
+		var input resourceInput
 
 		err := app.readJSON(w, r, &input)
 		if err != nil {
@@ -28,17 +115,7 @@ func (app *application) handleCreateResource() http.HandlerFunc {
 			return
 		}
 
-		resource := data.Resource{
-			ID:             input.ID,
-			FirstName:      input.FirstName,
-			LastName:       input.LastName,
-			Position:       input.Position,
-			Clearance:      input.Clearance,
-			Specialties:    input.Specialties,
-			Certifications: input.Certifications,
-			Active:         input.Active,
-			Sex:            input.Sex,
-		}
+		resource := input.resource(int64(app.cfg.allocation.capacity))
 
 		v := validator.New()
 
@@ -47,13 +124,15 @@ func (app *application) handleCreateResource() http.HandlerFunc {
 			return
 		}
 
-		err = app.models.Resources.Insert(&resource)
+		err = app.models.Resources.Insert(r.Context(), &resource)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
 		}
 
-		// app.logger.PrintInfo("resource created", map[string]string{"id": fmt.Sprintf("%d", resource.ID)})
+		// app.logger.PrintInfo("resource created", map[string]any{"id": fmt.Sprintf("%d", resource.ID)})
+
+		app.publishEvent(data.EventResourceCreated, envelope{"resource": resource})
 
 		err = app.writeJSON(w, http.StatusCreated, envelope{"resource": resource}, nil)
 		if err != nil {
@@ -70,7 +149,16 @@ func (app *application) handleShowResource() http.HandlerFunc {
 			return
 		}
 
-		resource, err := app.models.Resources.Get(id)
+		v := validator.New()
+
+		include := app.readCSV(r.URL.Query(), "include", []string{})
+
+		if validateInclude(v, include, resourceIncludeSafelist); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		resource, err := app.models.Resources.Get(r.Context(), id)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrNotFound):
@@ -81,7 +169,27 @@ func (app *application) handleShowResource() http.HandlerFunc {
 			return
 		}
 
-		err = app.writeJSON(w, http.StatusOK, envelope{"resource": resource}, nil)
+		if len(include) == 0 {
+			err = app.writeJSON(w, http.StatusOK, envelope{"resource": resource}, nil)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		records, err := projectRecords([]*data.Resource{resource}, "resourceId", nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.includeForResources(r.Context(), []*data.Resource{resource}, records, include)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"resource": records[0]}, nil)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 		}
@@ -96,7 +204,7 @@ func (app *application) handleUpdateResource() http.HandlerFunc {
 			return
 		}
 
-		resource, err := app.models.Resources.Get(id)
+		resource, err := app.models.Resources.Get(r.Context(), id)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrNotFound):
@@ -107,53 +215,24 @@ func (app *application) handleUpdateResource() http.HandlerFunc {
 			return
 		}
 
-		var input struct {
-			FirstName      *string  `json:"firstName"`
-			LastName       *string  `json:"lastName"`
-			Position       *string  `json:"position"`
-			Clearance      *string  `json:"clearance"`
-			Specialties    []string `json:"specialties"`
-			Certifications []string `json:"certifications"`
-			Active         *bool    `json:"active"`
-			Sex            *string  `json:"sex"`
-		}
-
-		err = app.readJSON(w, r, &input)
-		if err != nil {
-			app.badRequestResponse(w, r, err)
-			return
-		}
-
-		if input.FirstName != nil {
-			resource.FirstName = *input.FirstName
-		}
-
-		if input.LastName != nil {
-			resource.LastName = *input.LastName
-		}
-
-		if input.Position != nil {
-			resource.Position = *input.Position
-		}
-
-		if input.Clearance != nil {
-			resource.Clearance = *input.Clearance
-		}
+		wasActive := resource.Active
 
-		if input.Specialties != nil {
-			resource.Specialties = input.Specialties
-		}
-
-		if input.Certifications != nil {
-			resource.Certifications = input.Certifications
-		}
+		if patchMediaType(r) != "" {
+			err = app.applyPatch(w, r, resource, resourcePatchable)
+			if err != nil {
+				app.patchErrorResponse(w, r, err)
+				return
+			}
+		} else {
+			var input resourceUpdate
 
-		if input.Active != nil {
-			resource.Active = *input.Active
-		}
+			err = app.readJSON(w, r, &input)
+			if err != nil {
+				app.badRequestResponse(w, r, err)
+				return
+			}
 
-		if input.Sex != nil {
-			resource.Sex = *input.Sex
+			input.apply(resource)
 		}
 
 		v := validator.New()
@@ -163,7 +242,7 @@ func (app *application) handleUpdateResource() http.HandlerFunc {
 			return
 		}
 
-		err = app.models.Resources.Update(resource)
+		err = app.models.Resources.Update(r.Context(), resource)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrEditConflict):
@@ -174,6 +253,12 @@ func (app *application) handleUpdateResource() http.HandlerFunc {
 			return
 		}
 
+		app.publishEvent(data.EventResourceUpdated, envelope{"resource": resource})
+
+		if wasActive && !resource.Active {
+			app.publishEvent(data.EventResourceDeactivated, envelope{"resource": resource})
+		}
+
 		err = app.writeJSON(w, http.StatusOK, envelope{"resource": resource}, nil)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
@@ -189,7 +274,7 @@ func (app *application) handleDeleteResource() http.HandlerFunc {
 			return
 		}
 
-		err = app.models.Resources.Delete(id)
+		err = app.models.Resources.Delete(r.Context(), id)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrNotFound):
@@ -200,6 +285,8 @@ func (app *application) handleDeleteResource() http.HandlerFunc {
 			return
 		}
 
+		app.publishEvent(data.EventResourceDeleted, envelope{"resourceId": id})
+
 		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
@@ -207,42 +294,103 @@ func (app *application) handleDeleteResource() http.HandlerFunc {
 	}
 }
 
-func (app *application) handleListResources() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var input struct {
-			Clearance      string
-			Specialties    []string
-			Certifications []string
-			Active         bool
-			data.Filters
+// resourceListInput is the query GET /v1/resources takes.
+type resourceListInput struct {
+	Clearance      string
+	Specialties    []string
+	Certifications []string
+	Active         bool
+	Include        []string
+	Where          *filter.Filter
+	data.Filters
+}
+
+// resourceViewParams are the list parameters a saved view can hold.
+var resourceViewParams = []string{"specialties", "certifications", "active", "filter", "sort", "fields", "include", "page_size"}
+
+func (app *application) readResourceListInput(qs url.Values, v *validator.Validator) resourceListInput {
+	var input resourceListInput
+
+	input.Clearance = app.readString(qs, "clearance", "*")
+	input.Specialties = app.readCSV(qs, "specialties", []string{})
+	input.Certifications = app.readCSV(qs, "certifications", []string{})
+	input.Active = app.readBool(qs, "active", true, v)
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "first_name", "last_name", "-id", "-first_name", "-last_name"}
+	input.Filters.Fields = app.readCSV(qs, "fields", []string{})
+	input.Filters.FieldSafelist = resourceFieldSafelist
+	input.Include = app.readCSV(qs, "include", []string{})
+
+	validateInclude(v, input.Include, resourceIncludeSafelist)
+
+	if expr := qs.Get("filter"); expr != "" {
+		where, err := data.ParseResourceFilter(expr)
+		if err != nil {
+			v.AddError("filter", err.Error())
 		}
+		input.Where = where
+	}
 
-		v := validator.New()
+	data.ValidateFilters(v, input.Filters)
 
+	return input
+}
+
+func (app *application) handleListResources() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		qs := r.URL.Query()
 
-		input.Clearance = app.readString(qs, "clearance", "*")
-		input.Specialties = app.readCSV(qs, "specialties", []string{})
-		input.Certifications = app.readCSV(qs, "certifications", []string{})
-		input.Active = app.readBool(qs, "active", true, v)
-		input.Filters.Page = app.readInt(qs, "page", 1, v)
-		input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
-		input.Filters.Sort = app.readString(qs, "sort", "id")
-		input.Filters.SortSafelist = []string{"id", "first_name", "last_name", "-id", "-first_name", "-last_name"}
+		view, ok := app.applyView(w, r, qs, data.ViewListResources)
+		if !ok {
+			return
+		}
+
+		v := validator.New()
 
-		if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		input := app.readResourceListInput(qs, v)
+		if !v.Valid() {
 			app.failedValidationResponse(w, r, v.Errors)
 			return
 		}
 
 		// currently not filtering for clearance also. Need to fix
-		resources, metadata, err := app.models.Resources.GetAll(input.Specialties, input.Certifications, input.Active, input.Filters)
+		resources, metadata, err := app.models.Resources.GetAll(r.Context(), input.Specialties, input.Certifications, input.Active, input.Where, input.Filters)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
 		}
 
-		err = app.writeJSON(w, http.StatusOK, envelope{"resources": resources, "metadata": metadata}, nil)
+		env := envelope{"resources": resources, "metadata": metadata}
+
+		if len(input.Filters.Fields) > 0 || len(input.Include) > 0 {
+			records, err := projectRecords(resources, "resourceId", input.Filters.Fields)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			err = app.includeForResources(r.Context(), resources, records, input.Include)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			env["resources"] = records
+		}
+
+		if view != nil {
+			env["view"], err = app.summarizeView(r, view, func(since time.Time) (int, error) {
+				return app.models.Resources.CountUpdatedSince(r.Context(), input.Specialties, input.Certifications, input.Active, input.Where, since)
+			})
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+		}
+
+		err = app.writeJSON(w, http.StatusOK, env, nil)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 		}
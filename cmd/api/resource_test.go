@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+func TestListResources(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	seedResources(t, h,
+		`{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Senior Consultant", "clearance": "NV1", "specialties": ["NSX", "vSAN"], "active": true, "sex": "Female"}`,
+		`{"id": 2, "firstName": "Bob", "lastName": "Babbage", "position": "Consultant", "clearance": "None", "specialties": ["vSAN"], "active": true, "sex": "Male"}`,
+		`{"id": 3, "firstName": "Carol", "lastName": "Shaw", "position": "Staff Consultant", "clearance": "NV2", "specialties": ["NSX"], "certifications": ["VCP"], "active": false, "sex": "Female"}`,
+		`{"id": 4, "firstName": "Dan", "lastName": "Abramov", "position": "Consultant", "clearance": "Baseline", "active": true, "sex": "Unknown"}`,
+		`{"id": 5, "firstName": "Eve", "lastName": "Curie", "position": "Consulting Architect", "clearance": "NV1", "specialties": ["NSX"], "active": true, "sex": "Female"}`,
+	)
+
+	tests := []struct {
+		name     string
+		query    string
+		want     []int64
+		metadata data.Metadata
+	}{
+		{
+			name:     "defaults",
+			query:    "",
+			want:     []int64{1, 2, 3, 4, 5},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 5},
+		},
+		{
+			name:     "inactive only",
+			query:    "?active=false",
+			want:     []int64{3},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 1},
+		},
+		{
+			name:     "specialties",
+			query:    "?specialties=NSX",
+			want:     []int64{1, 3, 5},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 3},
+		},
+		{
+			name:     "certifications",
+			query:    "?certifications=VCP",
+			want:     []int64{3},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 1},
+		},
+		{
+			name:     "filter expression",
+			query:    "?filter=clearance+%3E%3D+NV1+and+specialties+contains+NSX",
+			want:     []int64{1, 3, 5},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 3},
+		},
+		{
+			name:     "sorted by last name",
+			query:    "?sort=last_name",
+			want:     []int64{4, 2, 5, 1, 3},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 5},
+		},
+		{
+			name:     "sorted descending",
+			query:    "?sort=-first_name",
+			want:     []int64{5, 4, 3, 2, 1},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 5},
+		},
+		{
+			name:     "first page",
+			query:    "?page_size=2",
+			want:     []int64{1, 2},
+			metadata: data.Metadata{CurrentPage: 1, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5},
+		},
+		{
+			name:     "last page",
+			query:    "?page_size=2&page=3",
+			want:     []int64{5},
+			metadata: data.Metadata{CurrentPage: 3, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5},
+		},
+		{
+			name:     "page past the end",
+			query:    "?page_size=2&page=4",
+			want:     []int64{},
+			metadata: data.Metadata{CurrentPage: 4, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5},
+		},
+		{
+			name:     "sorted and paged",
+			query:    "?sort=-id&page_size=2&page=2&specialties=NSX",
+			want:     []int64{1},
+			metadata: data.Metadata{CurrentPage: 2, PageSize: 2, FirstPage: 1, LastPage: 2, TotalRecords: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := mustDo(t, h, http.MethodGet, "/v1/resources"+tt.query, "", http.StatusOK)
+
+			var body struct {
+				Resources []data.Resource `json:"resources"`
+				Metadata  data.Metadata   `json:"metadata"`
+			}
+			decodeJSON(t, rr, &body)
+
+			ids := []int64{}
+			for _, r := range body.Resources {
+				ids = append(ids, r.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("resources %v, want %v", ids, tt.want)
+			}
+			if body.Metadata != tt.metadata {
+				t.Errorf("metadata %+v, want %+v", body.Metadata, tt.metadata)
+			}
+		})
+	}
+}
+
+func TestListResourcesInvalid(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	tests := []struct {
+		query string
+		field string
+	}{
+		{"?sort=email", "sort"},
+		{"?page=0", "page"},
+		{"?page_size=101", "page_size"},
+		{"?active=maybe", "active"},
+		{"?filter=clearance+%3E%3D", "filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := mustDo(t, h, http.MethodGet, "/v1/resources"+tt.query, "", http.StatusUnprocessableEntity)
+
+			var body problem
+			decodeJSON(t, rr, &body)
+
+			if _, ok := body.Errors[tt.field]; !ok {
+				t.Errorf("errors %v, want one for %s", body.Errors, tt.field)
+			}
+		})
+	}
+}
+
+func TestResourceCapacity(t *testing.T) {
+	app := newTestApplication(t, "-allocation-capacity", "38")
+	h := app.routes()
+
+	resource := func(id int, extra string) string {
+		return fmt.Sprintf(`{"id": %d, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "active": true, "sex": "Female"%s}`, id, extra)
+	}
+
+	capacity := func(t *testing.T, method, target, body string, status int) int64 {
+		t.Helper()
+
+		var got struct {
+			Resource data.Resource `json:"resource"`
+		}
+		decodeJSON(t, mustDo(t, h, method, target, body, status), &got)
+		return got.Resource.CapacityHours
+	}
+
+	if got := capacity(t, http.MethodPost, "/v1/resources", resource(1, ""), http.StatusCreated); got != 38 {
+		t.Errorf("created resource's capacity %d, want the default 38", got)
+	}
+	if got := capacity(t, http.MethodPatch, "/v1/resources/1", `{"capacityHours": 20}`, http.StatusOK); got != 20 {
+		t.Errorf("updated resource's capacity %d, want 20", got)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+	}{
+		{"created with none", http.MethodPost, "/v1/resources", resource(2, `, "capacityHours": 0`)},
+		{"created with too many", http.MethodPost, "/v1/resources", resource(2, `, "capacityHours": 169`)},
+		{"updated to a negative", http.MethodPatch, "/v1/resources/1", `{"capacityHours": -1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := mustDo(t, h, tt.method, tt.target, tt.body, http.StatusUnprocessableEntity)
+
+			var body problem
+			decodeJSON(t, rr, &body)
+
+			if _, ok := body.Errors["capacityHours"]; !ok {
+				t.Errorf("errors %v, want one for capacityHours", body.Errors)
+			}
+		})
+	}
+}
@@ -2,18 +2,37 @@ package main
 
 import (
 	"net/http"
-
-	"github.com/julienschmidt/httprouter"
 )
 
 func (app *application) routes() http.Handler {
-	mux := httprouter.New()
+	mux := newPatternRouter()
+	mux.NotFound = http.HandlerFunc(app.notFoundResponse)
+	mux.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowedResponse)
+
+	mux.HandlerFunc(http.MethodGet, "/v1/healthz", app.handleHealthz())
+	mux.HandlerFunc(http.MethodGet, "/v1/readyz", app.handleReadyz())
+	// Kept for clients that predate the healthz/readyz split.
+	mux.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.handleHealthz())
+	mux.HandlerFunc(http.MethodGet, "/metrics", app.handleMetrics())
+	mux.HandlerFunc(http.MethodGet, "/v1/openapi.json", app.handleOpenAPI())
+	mux.HandlerFunc(http.MethodGet, "/v1/docs", app.handleDocs())
+
+	mux.HandlerFunc(http.MethodGet, "/v1/events", app.handleEvents())
 
-	mux.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.handleHealthcheck())
+	mux.HandlerFunc(http.MethodGet, "/v1/graphql", app.handleGraphQL())
+	mux.HandlerFunc(http.MethodPost, "/v1/graphql", app.handleGraphQL())
 
+	mux.HandlerFunc(http.MethodGet, "/v1/positions", app.handleListPositions())
 	mux.HandlerFunc(http.MethodPost, "/v1/positions", app.handleCreatePosition())
+	mux.HandlerFunc(http.MethodGet, "/v1/positions/:id", app.handleShowPosition())
+	mux.HandlerFunc(http.MethodPatch, "/v1/positions/:id", app.handleUpdatePosition())
+	mux.HandlerFunc(http.MethodDelete, "/v1/positions/:id", app.handleDeletePosition())
 
+	mux.HandlerFunc(http.MethodGet, "/v1/clearances", app.handleListClearances())
 	mux.HandlerFunc(http.MethodPost, "/v1/clearances", app.handleCreateClearance())
+	mux.HandlerFunc(http.MethodGet, "/v1/clearances/:id", app.handleShowClearance())
+	mux.HandlerFunc(http.MethodPatch, "/v1/clearances/:id", app.handleUpdateClearance())
+	mux.HandlerFunc(http.MethodDelete, "/v1/clearances/:id", app.handleDeleteClearance())
 
 	mux.HandlerFunc(http.MethodGet, "/v1/resources", app.handleListResources())
 	mux.HandlerFunc(http.MethodPost, "/v1/resources", app.handleCreateResource())
@@ -21,5 +40,39 @@ func (app *application) routes() http.Handler {
 	mux.HandlerFunc(http.MethodPatch, "/v1/resources/:id", app.handleUpdateResource())
 	mux.HandlerFunc(http.MethodDelete, "/v1/resources/:id", app.handleDeleteResource())
 
-	return app.recoverPanic(app.enableCORS(mux))
+	mux.HandlerFunc(http.MethodGet, "/v1/requests", app.handleListResourceRequests())
+	mux.HandlerFunc(http.MethodPost, "/v1/requests", app.handleCreateResourceRequest())
+	mux.HandlerFunc(http.MethodGet, "/v1/requests/:id", app.handleShowResourceRequest())
+	mux.HandlerFunc(http.MethodPatch, "/v1/requests/:id", app.handleUpdateResourceRequest())
+	mux.HandlerFunc(http.MethodDelete, "/v1/requests/:id", app.handleDeleteResourceRequest())
+
+	mux.HandlerFunc(http.MethodGet, "/v1/requests/:id/transitions", app.handleListRequestTransitions())
+	mux.HandlerFunc(http.MethodPost, "/v1/requests/:id/transitions", app.handleTransitionRequest())
+
+	mux.HandlerFunc(http.MethodGet, "/v1/requests/:id/assignments", app.handleListAssignments())
+	mux.HandlerFunc(http.MethodPost, "/v1/requests/:id/assignments", app.handleCreateAssignment())
+	mux.HandlerFunc(http.MethodGet, "/v1/requests/:id/assignments/:resourceId", app.handleShowAssignment())
+	mux.HandlerFunc(http.MethodPatch, "/v1/requests/:id/assignments/:resourceId", app.handleUpdateAssignment())
+	mux.HandlerFunc(http.MethodDelete, "/v1/requests/:id/assignments/:resourceId", app.handleDeleteAssignment())
+	mux.HandlerFunc(http.MethodGet, "/v1/requests/:id/assignments/:resourceId/transitions", app.handleListAssignmentTransitions())
+	mux.HandlerFunc(http.MethodPost, "/v1/requests/:id/assignments/:resourceId/transitions", app.handleTransitionAssignment())
+
+	mux.HandlerFunc(http.MethodGet, "/v1/views", app.handleListViews())
+	mux.HandlerFunc(http.MethodPost, "/v1/views", app.handleCreateView())
+	mux.HandlerFunc(http.MethodGet, "/v1/views/:id", app.handleShowView())
+	mux.HandlerFunc(http.MethodPatch, "/v1/views/:id", app.handleUpdateView())
+	mux.HandlerFunc(http.MethodDelete, "/v1/views/:id", app.handleDeleteView())
+	mux.HandlerFunc(http.MethodPost, "/v1/views/:id/visits", app.handleVisitView())
+
+	mux.HandlerFunc(http.MethodPost, "/v1/batch", app.handleBatch())
+
+	mux.HandlerFunc(http.MethodGet, "/v1/webhooks", app.handleListWebhooks())
+	mux.HandlerFunc(http.MethodPost, "/v1/webhooks", app.handleCreateWebhook())
+	mux.HandlerFunc(http.MethodGet, "/v1/webhooks/:id", app.handleShowWebhook())
+	mux.HandlerFunc(http.MethodPatch, "/v1/webhooks/:id", app.handleUpdateWebhook())
+	mux.HandlerFunc(http.MethodDelete, "/v1/webhooks/:id", app.handleDeleteWebhook())
+	mux.HandlerFunc(http.MethodGet, "/v1/webhooks/:id/deliveries", app.handleListWebhookDeliveries())
+	mux.HandlerFunc(http.MethodPost, "/v1/webhooks/:id/deliveries/:deliveryId/redeliver", app.handleRedeliverWebhook())
+
+	return app.instrument(mux, app.requestLogger(app.identifyUser(app.accessLog(mux, app.recoverPanic(app.hsts(app.enableCORS(app.conditionalGET(mux, mux))))))))
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,12 +13,36 @@ import (
 )
 
 func (app *application) serve() error {
+	// Every request context derives from baseCtx, so cancelling it aborts any
+	// database work still running once the shutdown grace period has passed.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.cfg.port),
 		Handler:      app.routes(),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
+	}
+
+	tlsEnabled := app.cfg.tls.certFile != ""
+
+	var redirectSrv *http.Server
+
+	if tlsEnabled {
+		tlsConfig, err := app.newTLSConfig()
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsConfig
+
+		if app.cfg.tls.redirectPort != 0 {
+			redirectSrv = app.redirectServer()
+		}
 	}
 
 	shutdownError := make(chan error)
@@ -27,32 +52,86 @@ func (app *application) serve() error {
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		s := <-quit
 
-		app.logger.PrintInfo("caught signal", map[string]string{
+		app.logger.PrintInfo("caught signal", map[string]any{
 			"signal": s.String(),
 		})
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		close(app.shutdown)
+
+		// readyz fails from now on, but the listeners stay open until load
+		// balancers have had time to notice and stop sending requests here.
+		if app.cfg.shutdown.drain > 0 {
+			app.logger.PrintInfo("draining connections", map[string]any{
+				"drain": app.cfg.shutdown.drain.String(),
+			})
+			time.Sleep(app.cfg.shutdown.drain)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), app.cfg.shutdown.timeout)
 		defer cancel()
 
-		err := srv.Shutdown(ctx)
-		if err != nil {
-			shutdownError <- err
+		if redirectSrv != nil {
+			// The redirect listener holds no state worth draining.
+			redirectSrv.Close()
 		}
 
-		app.logger.PrintInfo("completing background tasks", map[string]string{
+		err := srv.Shutdown(ctx)
+		cancelBase()
+
+		// Background tasks are waited for even if requests outlasted the
+		// timeout, and get a timeout of their own so that webhook deliveries
+		// and emails queued by those requests still have time to finish.
+		app.logger.PrintInfo("completing background tasks", map[string]any{
 			"addr": srv.Addr,
 		})
 
-		app.wg.Wait()
-		shutdownError <- nil
+		waitCtx, cancelWait := context.WithTimeout(context.Background(), app.cfg.shutdown.timeout)
+		defer cancelWait()
+
+		done := make(chan struct{})
+		go func() {
+			app.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-waitCtx.Done():
+			app.logger.PrintWarn("stopped waiting for background tasks", map[string]any{
+				"tasks": app.backgroundTasks.Load(),
+			})
+		}
+
+		shutdownError <- err
 	}()
 
-	app.logger.PrintInfo("starting server", map[string]string{
+	if redirectSrv != nil {
+		go func() {
+			app.logger.PrintInfo("starting HTTPS redirect server", map[string]any{
+				"addr": redirectSrv.Addr,
+			})
+
+			err := redirectSrv.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				app.logger.PrintError(err, map[string]any{
+					"addr": redirectSrv.Addr,
+				})
+			}
+		}()
+	}
+
+	app.logger.PrintInfo("starting server", map[string]any{
 		"addr": srv.Addr,
 		"env":  app.cfg.env,
+		"tls":  tlsEnabled,
 	})
 
-	err := srv.ListenAndServe()
+	var err error
+	if tlsEnabled {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -62,7 +141,7 @@ func (app *application) serve() error {
 		return err
 	}
 
-	app.logger.PrintInfo("stopped server", map[string]string{
+	app.logger.PrintInfo("stopped server", map[string]any{
 		"addr": srv.Addr,
 	})
 
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/jsonlog"
+)
+
+// testToken identifies requests made by do as alice.
+const testToken = "tok-alice"
+
+// newTestApplication returns an application backed by the in-memory store,
+// configured as the api command is by default apart from args.
+func newTestApplication(t *testing.T, args ...string) *application {
+	t.Helper()
+
+	args = append([]string{"api", "-store", "memory", "-auth-tokens", "alice=" + testToken}, args...)
+
+	cfg, err := loadConfig(args, mapEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &application{
+		cfg:           cfg,
+		logger:        jsonlog.New(io.Discard, jsonlog.LevelError),
+		models:        *data.NewMemoryModels(),
+		events:        newEventBroker(cfg.sse.replaySize),
+		webhookClient: &http.Client{Timeout: cfg.webhooks.timeout},
+		shutdown:      make(chan struct{}),
+	}
+	app.metrics = app.newMetrics(nil)
+
+	t.Cleanup(app.wg.Wait)
+
+	return app
+}
+
+// do sends a request to h, with body as its JSON body if it is not empty,
+// and returns the response.
+func do(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, target, nil)
+	} else {
+		r = httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set("Authorization", "Bearer "+testToken)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+
+	return rr
+}
+
+// mustDo is do for requests that set up a test, failing it unless the
+// response has the wanted status.
+func mustDo(t *testing.T, h http.Handler, method, target, body string, status int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rr := do(t, h, method, target, body)
+	if rr.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, target, rr.Code, status, rr.Body)
+	}
+
+	return rr
+}
+
+// decodeJSON decodes the body of rr into dst.
+func decodeJSON(t *testing.T, rr *httptest.ResponseRecorder, dst any) {
+	t.Helper()
+
+	if err := json.Unmarshal(rr.Body.Bytes(), dst); err != nil {
+		t.Fatalf("decoding %s: %v", rr.Body, err)
+	}
+}
+
+// seedResources creates resources through the API, numbered from 1.
+func seedResources(t *testing.T, h http.Handler, resources ...string) {
+	t.Helper()
+
+	for _, body := range resources {
+		mustDo(t, h, http.MethodPost, "/v1/resources", body, http.StatusCreated)
+	}
+}
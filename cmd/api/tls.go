@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certReloader serves the certificate in certFile/keyFile and reloads it
+// when either file changes, so renewed certificates are picked up without a
+// restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	cr := &certReloader{certFile: certFile, keyFile: keyFile}
+
+	if _, err := cr.reload(); err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// reload loads the key pair if either file has been modified since the last
+// successful load. It reports whether a new certificate was installed.
+func (cr *certReloader) reload() (bool, error) {
+	certInfo, err := os.Stat(cr.certFile)
+	if err != nil {
+		return false, err
+	}
+
+	keyInfo, err := os.Stat(cr.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	cr.mu.RLock()
+	unchanged := cr.cert != nil && certInfo.ModTime().Equal(cr.certTime) && keyInfo.ModTime().Equal(cr.keyTime)
+	cr.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	cr.mu.Lock()
+	cr.cert = &cert
+	cr.certTime = certInfo.ModTime()
+	cr.keyTime = keyInfo.ModTime()
+	cr.mu.Unlock()
+
+	return true, nil
+}
+
+func (cr *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	return cr.cert, nil
+}
+
+// watchCertificate polls the certificate files until shutdown begins. A
+// failed reload, such as while a renewal has written only one of the two
+// files, keeps the current certificate in service and is retried next tick.
+func (app *application) watchCertificate(cr *certReloader) {
+	ticker := time.NewTicker(app.cfg.tls.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := cr.reload()
+			switch {
+			case err != nil:
+				app.logger.PrintError(err, map[string]any{
+					"tls_cert": cr.certFile,
+					"tls_key":  cr.keyFile,
+				})
+			case reloaded:
+				app.logger.PrintInfo("reloaded TLS certificate", map[string]any{
+					"tls_cert": cr.certFile,
+				})
+			}
+		case <-app.shutdown:
+			return
+		}
+	}
+}
+
+// newTLSConfig builds the server's TLS configuration and starts watching the
+// certificate for changes.
+func (app *application) newTLSConfig() (*tls.Config, error) {
+	cr, err := newCertReloader(app.cfg.tls.certFile, app.cfg.tls.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tlsVersions[app.cfg.tls.minVersion],
+		GetCertificate: cr.getCertificate,
+	}
+
+	if app.cfg.tls.clientCAFile != "" {
+		pem, err := os.ReadFile(app.cfg.tls.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading TLS client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("TLS client CA file contains no certificates")
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if app.cfg.tls.reloadInterval > 0 {
+		go app.watchCertificate(cr)
+	}
+
+	return tlsConfig, nil
+}
+
+// redirectServer returns a plain HTTP server that permanently redirects every
+// request to the same URL on the HTTPS listener.
+func (app *application) redirectServer() *http.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		if app.cfg.port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(app.cfg.port))
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", app.cfg.tls.redirectPort),
+		Handler:      handler,
+		IdleTimeout:  time.Minute,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+}
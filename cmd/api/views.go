@@ -0,0 +1,424 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// viewSummary tells a list's caller which view was applied and how its
+// results have moved on since they last used it.
+type viewSummary struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	LastViewedAt *time.Time `json:"lastViewedAt"`
+	// ChangedSinceLastViewed counts the records in the results created or
+	// updated since the user's last recorded visit. It is null until they
+	// record one.
+	ChangedSinceLastViewed *int `json:"changedSinceLastViewed"`
+}
+
+// applyView looks up the view named by the view parameter in qs and adds its
+// saved parameters to qs; parameters given in the request take precedence.
+// It returns a nil view when there is no view parameter, and false after
+// writing an error response when the view cannot be applied.
+func (app *application) applyView(w http.ResponseWriter, r *http.Request, qs url.Values, list string) (*data.View, bool) {
+	raw := qs.Get("view")
+	if raw == "" {
+		return nil, true
+	}
+	qs.Del("view")
+
+	user := app.contextGetUser(r)
+	if user == "" {
+		app.authenticationRequiredResponse(w, r)
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"view": "must be an integer value"})
+		return nil, false
+	}
+
+	view, err := app.models.Views.Get(r.Context(), id)
+	if err != nil && !errors.Is(err, data.ErrNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+	if err != nil || !view.VisibleTo(user) {
+		app.failedValidationResponse(w, r, map[string]string{"view": "does not exist"})
+		return nil, false
+	}
+	if view.List != list {
+		app.failedValidationResponse(w, r, map[string]string{"view": "is a view of " + view.List})
+		return nil, false
+	}
+
+	for param, value := range view.Query {
+		if !qs.Has(param) {
+			qs.Set(param, value)
+		}
+	}
+
+	return view, true
+}
+
+// summarizeView reports when the user last recorded a visit to the view and
+// how many of its records have changed since. count returns the number of
+// records in the view's results updated after the given time. Applying a view
+// only reads, so that lists stay safe to repeat and cache; visits are
+// recorded through handleVisitView.
+func (app *application) summarizeView(r *http.Request, view *data.View, count func(since time.Time) (int, error)) (*viewSummary, error) {
+	summary := &viewSummary{ID: view.ID, Name: view.Name}
+
+	visit, err := app.models.Views.GetVisit(r.Context(), view.ID, app.contextGetUser(r))
+	if err != nil {
+		if errors.Is(err, data.ErrNotFound) {
+			return summary, nil
+		}
+		return nil, err
+	}
+
+	changed, err := count(visit.ViewedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.LastViewedAt = &visit.ViewedAt
+	summary.ChangedSinceLastViewed = &changed
+
+	return summary, nil
+}
+
+// validateViewQuery checks that a view only saves parameters of its list and
+// that they are valid there.
+func (app *application) validateViewQuery(v *validator.Validator, view *data.View) {
+	var params []string
+	var read func(url.Values, *validator.Validator)
+
+	switch view.List {
+	case data.ViewListResources:
+		params = resourceViewParams
+		read = func(qs url.Values, v *validator.Validator) { app.readResourceListInput(qs, v) }
+	case data.ViewListRequests:
+		params = requestViewParams
+		read = func(qs url.Values, v *validator.Validator) { app.readRequestListInput(qs, v) }
+	default:
+		return
+	}
+
+	qs := url.Values{}
+	for param, value := range view.Query {
+		if !validator.PermittedValue(param, params...) {
+			v.AddError("query."+param, "cannot be saved in a view of "+view.List)
+			continue
+		}
+		qs.Set(param, value)
+	}
+
+	qv := validator.New()
+	read(qs, qv)
+	for param, message := range qv.Errors {
+		v.AddError("query."+param, message)
+	}
+}
+
+// validateViewSharing checks that a view is only shared with users the API
+// knows.
+func (app *application) validateViewSharing(v *validator.Validator, view *data.View) {
+	for _, user := range view.SharedWith {
+		if _, ok := app.cfg.auth.tokens[user]; !ok {
+			v.AddError("sharedWith", "contains an unknown user "+strconv.Quote(user))
+			return
+		}
+	}
+}
+
+func (app *application) handleCreateView() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+		if user == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		var input struct {
+			Name       string            `json:"name"`
+			List       string            `json:"list"`
+			Query      map[string]string `json:"query"`
+			SharedWith []string          `json:"sharedWith"`
+		}
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		view := &data.View{
+			Owner:      user,
+			Name:       input.Name,
+			List:       input.List,
+			Query:      input.Query,
+			SharedWith: input.SharedWith,
+		}
+
+		if view.Query == nil {
+			view.Query = map[string]string{}
+		}
+
+		if view.SharedWith == nil {
+			view.SharedWith = []string{}
+		}
+
+		v := validator.New()
+
+		data.ValidateView(v, *view)
+		app.validateViewQuery(v, view)
+		app.validateViewSharing(v, view)
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.Views.Insert(r.Context(), view)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrDuplicateView):
+				app.failedValidationResponse(w, r, map[string]string{"name": "you already have a view of " + view.List + " with this name"})
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusCreated, envelope{"view": view}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleListViews() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+		if user == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		list := app.readString(r.URL.Query(), "list", "")
+
+		v := validator.New()
+
+		if v.Check(list == "" || validator.PermittedValue(list, data.ViewListResources, data.ViewListRequests), "list", "must be one of ('resources', 'requests')"); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		views, err := app.models.Views.GetAllForUser(r.Context(), user, list)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"views": views}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// readView returns the view named in the URL if user may see it, writing a
+// not found response otherwise.
+func (app *application) readView(w http.ResponseWriter, r *http.Request, user string) (*data.View, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	view, err := app.models.Views.Get(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	if !view.VisibleTo(user) {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	return view, true
+}
+
+func (app *application) handleShowView() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+		if user == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		view, ok := app.readView(w, r, user)
+		if !ok {
+			return
+		}
+
+		err := app.writeJSON(w, http.StatusOK, envelope{"view": view}, lastModified(view.UpdatedAt))
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// handleVisitView records that the user has now seen the view's results, so
+// that later lists through it count changes from this point.
+func (app *application) handleVisitView() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+		if user == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		view, ok := app.readView(w, r, user)
+		if !ok {
+			return
+		}
+
+		visit := &data.ViewVisit{ViewID: view.ID, User: user}
+
+		err := app.models.Views.SaveVisit(r.Context(), visit)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"visit": visit}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleUpdateView() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+		if user == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		view, ok := app.readView(w, r, user)
+		if !ok {
+			return
+		}
+
+		if view.Owner != user {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		var input struct {
+			Name       *string           `json:"name"`
+			Query      map[string]string `json:"query"`
+			SharedWith []string          `json:"sharedWith"`
+		}
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if input.Name != nil {
+			view.Name = *input.Name
+		}
+
+		if input.Query != nil {
+			view.Query = input.Query
+		}
+
+		if input.SharedWith != nil {
+			view.SharedWith = input.SharedWith
+		}
+
+		v := validator.New()
+
+		data.ValidateView(v, *view)
+		app.validateViewQuery(v, view)
+		app.validateViewSharing(v, view)
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.Views.Update(r.Context(), view)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w, r)
+			case errors.Is(err, data.ErrDuplicateView):
+				app.failedValidationResponse(w, r, map[string]string{"name": "you already have a view of " + view.List + " with this name"})
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"view": view}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleDeleteView() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+		if user == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		view, ok := app.readView(w, r, user)
+		if !ok {
+			return
+		}
+
+		if view.Owner != user {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		err := app.models.Views.Delete(r.Context(), view.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
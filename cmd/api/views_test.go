@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestViewVisits(t *testing.T) {
+	app := newTestApplication(t)
+	h := app.routes()
+
+	seedResources(t, h,
+		`{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "position": "Consultant", "clearance": "None", "specialties": ["NSX"], "active": true, "sex": "Female"}`,
+		`{"id": 2, "firstName": "Bob", "lastName": "Babbage", "position": "Consultant", "clearance": "None", "specialties": ["NSX"], "active": true, "sex": "Male"}`,
+		`{"id": 3, "firstName": "Carol", "lastName": "Shaw", "position": "Consultant", "clearance": "None", "specialties": ["vSAN"], "active": true, "sex": "Female"}`,
+	)
+	mustDo(t, h, http.MethodPost, "/v1/views", `{"name": "NSX", "list": "resources", "query": {"specialties": "NSX"}}`, http.StatusCreated)
+
+	// changed lists through the view and returns how many of its results
+	// changed since the last visit, or -1 when no visit is recorded.
+	changed := func(t *testing.T) int {
+		t.Helper()
+
+		rr := mustDo(t, h, http.MethodGet, "/v1/resources?view=1", "", http.StatusOK)
+
+		var body struct {
+			View viewSummary `json:"view"`
+		}
+		decodeJSON(t, rr, &body)
+
+		if (body.View.LastViewedAt == nil) != (body.View.ChangedSinceLastViewed == nil) {
+			t.Fatalf("lastViewedAt %v with changedSinceLastViewed %v", body.View.LastViewedAt, body.View.ChangedSinceLastViewed)
+		}
+		if body.View.ChangedSinceLastViewed == nil {
+			return -1
+		}
+		return *body.View.ChangedSinceLastViewed
+	}
+
+	if got := changed(t); got != -1 {
+		t.Fatalf("changed before any visit = %d, want none", got)
+	}
+	if got := changed(t); got != -1 {
+		t.Fatalf("changed after listing again = %d, want none: listing must not record a visit", got)
+	}
+
+	rr := mustDo(t, h, http.MethodPost, "/v1/views/1/visits", "", http.StatusOK)
+
+	var body struct {
+		Visit struct {
+			ViewID int64  `json:"viewId"`
+			User   string `json:"user"`
+		} `json:"visit"`
+	}
+	decodeJSON(t, rr, &body)
+	if body.Visit.ViewID != 1 || body.Visit.User != "alice" {
+		t.Errorf("visit %+v, want view 1 by alice", body.Visit)
+	}
+
+	if got := changed(t); got != 0 {
+		t.Errorf("changed right after a visit = %d, want 0", got)
+	}
+
+	mustDo(t, h, http.MethodPatch, "/v1/resources/1", `{"lastName": "Byron"}`, http.StatusOK)
+	mustDo(t, h, http.MethodPatch, "/v1/resources/3", `{"lastName": "Kay"}`, http.StatusOK)
+	seedResources(t, h, `{"id": 4, "firstName": "Dan", "lastName": "Abramov", "position": "Consultant", "clearance": "None", "specialties": ["NSX"], "active": true, "sex": "Male"}`)
+
+	if got := changed(t); got != 2 {
+		t.Errorf("changed after updating one result, adding another and updating a record outside the view = %d, want 2", got)
+	}
+
+	mustDo(t, h, http.MethodPost, "/v1/views/1/visits", "", http.StatusOK)
+
+	if got := changed(t); got != 0 {
+		t.Errorf("changed after visiting again = %d, want 0", got)
+	}
+
+	mustDo(t, h, http.MethodPost, "/v1/views/2/visits", "", http.StatusNotFound)
+}
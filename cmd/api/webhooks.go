@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+const (
+	webhookInitialBackoff = time.Second
+	webhookMaxBackoff     = time.Minute
+	// webhookStoreTimeout bounds each read and write of delivery records
+	// made outside a request.
+	webhookStoreTimeout = 5 * time.Second
+)
+
+// queueWebhooks records a delivery of body for every active webhook
+// subscribed to the event and hands each one to a background worker.
+func (app *application) queueWebhooks(event string, body []byte) {
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookStoreTimeout)
+		defer cancel()
+
+		webhooks, err := app.models.Webhooks.GetAllForEvent(ctx, event)
+		if err != nil {
+			app.logger.PrintError(err, map[string]any{"event": event})
+			return
+		}
+
+		for _, webhook := range webhooks {
+			delivery := &data.WebhookDelivery{
+				WebhookID: webhook.ID,
+				Event:     event,
+				Payload:   body,
+			}
+
+			err := app.models.WebhookDeliveries.Insert(ctx, delivery)
+			if err != nil {
+				app.logger.PrintError(err, map[string]any{
+					"event":      event,
+					"webhook_id": webhook.ID,
+				})
+				continue
+			}
+
+			webhook := webhook
+			app.background(func() {
+				app.deliverWebhook(webhook, delivery)
+			})
+		}
+	})
+}
+
+// resumeWebhookDeliveries hands the deliveries an earlier run left pending,
+// or gave up on before the current maximum attempts, back to background
+// workers. It looks them up before the server starts so that it cannot pick
+// up deliveries this run has queued itself. Deliveries for webhooks that have
+// since been deactivated stay pending.
+func (app *application) resumeWebhookDeliveries() {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookStoreTimeout)
+	defer cancel()
+
+	deliveries, err := app.models.WebhookDeliveries.GetAllUnfinished(ctx, app.cfg.webhooks.maxAttempts)
+	if err != nil {
+		app.logger.PrintError(err, nil)
+		return
+	}
+
+	webhooks := make(map[int64]*data.Webhook)
+	resumed := 0
+
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = app.models.Webhooks.Get(ctx, delivery.WebhookID)
+			if err != nil {
+				app.logger.PrintError(err, map[string]any{
+					"webhook_id":  delivery.WebhookID,
+					"delivery_id": delivery.ID,
+				})
+				continue
+			}
+			webhooks[webhook.ID] = webhook
+		}
+
+		if !webhook.Active {
+			continue
+		}
+
+		delivery := delivery
+		app.background(func() {
+			app.deliverWebhook(webhook, delivery)
+		})
+		resumed++
+	}
+
+	if resumed > 0 {
+		app.logger.PrintInfo("resumed webhook deliveries", map[string]any{
+			"deliveries": resumed,
+		})
+	}
+}
+
+// deliverWebhook posts the delivery payload to the webhook URL, retrying with
+// exponential backoff until it succeeds or the maximum attempts are used up.
+// It carries on from the attempts the delivery has already made. If the
+// server starts shutting down while it waits to retry, it leaves the delivery
+// pending for resumeWebhookDeliveries to finish after a restart.
+func (app *application) deliverWebhook(webhook *data.Webhook, delivery *data.WebhookDelivery) {
+	for delivery.Attempts < app.cfg.webhooks.maxAttempts {
+		if delivery.Attempts > 0 {
+			timer := time.NewTimer(webhookBackoff(delivery.Attempts))
+
+			select {
+			case <-app.shutdown:
+				timer.Stop()
+				delivery.Status = data.DeliveryPending
+				app.updateWebhookDelivery(delivery)
+				return
+			case <-timer.C:
+			}
+		}
+
+		status, err := app.sendWebhook(webhook, delivery)
+
+		delivery.Attempts++
+		delivery.ResponseStatus = status
+
+		switch {
+		case err == nil:
+			now := time.Now()
+			delivery.Status = data.DeliverySucceeded
+			delivery.LastError = ""
+			delivery.DeliveredAt = &now
+		case delivery.Attempts >= app.cfg.webhooks.maxAttempts:
+			delivery.Status = data.DeliveryFailed
+			delivery.LastError = err.Error()
+		default:
+			delivery.Status = data.DeliveryPending
+			delivery.LastError = err.Error()
+		}
+
+		app.updateWebhookDelivery(delivery)
+
+		if err == nil {
+			return
+		}
+	}
+
+	app.logger.PrintError(errors.New("webhook delivery failed"), map[string]any{
+		"webhook_id":  webhook.ID,
+		"delivery_id": delivery.ID,
+		"last_error":  delivery.LastError,
+	})
+}
+
+// webhookBackoff returns how long to wait before retrying a delivery that
+// has made the given number of attempts.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookInitialBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+func (app *application) updateWebhookDelivery(delivery *data.WebhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookStoreTimeout)
+	defer cancel()
+
+	if err := app.models.WebhookDeliveries.Update(ctx, delivery); err != nil {
+		app.logger.PrintError(err, map[string]any{
+			"delivery_id": delivery.ID,
+		})
+	}
+}
+
+func (app *application) sendWebhook(webhook *data.Webhook, delivery *data.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dashboard-webhooks/"+version)
+	req.Header.Set("X-Dashboard-Event", delivery.Event)
+	req.Header.Set("X-Dashboard-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Dashboard-Timestamp", timestamp)
+	req.Header.Set("X-Dashboard-Signature", signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := app.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// signWebhookPayload returns the HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// the webhook secret, in the form receivers expect in X-Dashboard-Signature.
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (app *application) handleCreateWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			URL    string   `json:"url"`
+			Secret string   `json:"secret"`
+			Events []string `json:"events"`
+			Active *bool    `json:"active"`
+		}
+
+		err := app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		webhook := &data.Webhook{
+			URL:    input.URL,
+			Secret: input.Secret,
+			Events: input.Events,
+			Active: true,
+		}
+
+		if input.Active != nil {
+			webhook.Active = *input.Active
+		}
+
+		v := validator.New()
+
+		if data.ValidateWebhook(v, *webhook); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.Webhooks.Insert(r.Context(), webhook)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusCreated, envelope{"webhook": webhook}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleListWebhooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhooks, err := app.models.Webhooks.GetAll(r.Context())
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"webhooks": webhooks}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleShowWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		webhook, err := app.models.Webhooks.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"webhook": webhook}, lastModified(webhook.UpdatedAt))
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleUpdateWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		webhook, err := app.models.Webhooks.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		var input struct {
+			URL    *string  `json:"url"`
+			Secret *string  `json:"secret"`
+			Events []string `json:"events"`
+			Active *bool    `json:"active"`
+		}
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if input.URL != nil {
+			webhook.URL = *input.URL
+		}
+
+		if input.Secret != nil {
+			webhook.Secret = *input.Secret
+		}
+
+		if input.Events != nil {
+			webhook.Events = input.Events
+		}
+
+		if input.Active != nil {
+			webhook.Active = *input.Active
+		}
+
+		v := validator.New()
+
+		if data.ValidateWebhook(v, *webhook); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		err = app.models.Webhooks.Update(r.Context(), webhook)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrEditConflict):
+				app.editConflictResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"webhook": webhook}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleDeleteWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		err = app.models.Webhooks.Delete(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully deleted"}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+func (app *application) handleListWebhookDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		var input struct {
+			Status string
+			data.Filters
+		}
+
+		v := validator.New()
+
+		qs := r.URL.Query()
+
+		input.Status = app.readString(qs, "status", "")
+		input.Filters.Page = app.readInt(qs, "page", 1, v)
+		input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+		input.Filters.Sort = "-id"
+		input.Filters.SortSafelist = []string{"-id"}
+
+		if input.Status != "" {
+			v.Check(validator.PermittedValue(input.Status, data.DeliveryPending, data.DeliverySucceeded, data.DeliveryFailed), "status", "invalid status value")
+		}
+
+		if data.ValidateFilters(v, input.Filters); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		_, err = app.models.Webhooks.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		deliveries, metadata, err := app.models.WebhookDeliveries.GetAllForWebhook(r.Context(), id, input.Status, input.Filters)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"deliveries": deliveries, "metadata": metadata}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// handleRedeliverWebhook queues a fresh delivery of a previously recorded
+// payload, leaving the original delivery record untouched.
+func (app *application) handleRedeliverWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := app.readIDParam(r)
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		deliveryID, err := app.readNamedIDParam(r, "deliveryId")
+		if err != nil {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		webhook, err := app.models.Webhooks.Get(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		original, err := app.models.WebhookDeliveries.Get(r.Context(), webhook.ID, deliveryID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		delivery := &data.WebhookDelivery{
+			WebhookID: webhook.ID,
+			Event:     original.Event,
+			Payload:   original.Payload,
+		}
+
+		err = app.models.WebhookDeliveries.Insert(r.Context(), delivery)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		pending := *delivery
+		app.background(func() {
+			app.deliverWebhook(webhook, &pending)
+		})
+
+		err = app.writeJSON(w, http.StatusAccepted, envelope{"delivery": delivery}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/pkg/client"
+)
+
+var assignmentColumns = []column[client.ResourceAssignment]{
+	{"requestId", func(a *client.ResourceAssignment) string { return formatID(a.ResourceRequestID) }},
+	{"resourceId", func(a *client.ResourceAssignment) string { return formatID(a.ResourceID) }},
+	{"hoursPerWeek", func(a *client.ResourceAssignment) string { return formatID(a.HoursPerWeek) }},
+	{"completed", func(a *client.ResourceAssignment) string { return formatBool(a.Completed) }},
+	{"status", func(a *client.ResourceAssignment) string { return a.Status }},
+}
+
+var assignmentTransitionColumns = []column[client.AssignmentTransition]{
+	{"from", func(t *client.AssignmentTransition) string { return t.From }},
+	{"to", func(t *client.AssignmentTransition) string { return t.To }},
+	{"comment", func(t *client.AssignmentTransition) string { return t.Comment }},
+	{"actor", func(t *client.AssignmentTransition) string { return t.Actor }},
+	{"at", func(t *client.AssignmentTransition) string { return formatTime(t.CreatedAt) }},
+}
+
+// assignmentRecord is one assignment in a bulk file. For create an absent
+// hoursPerWeek takes the request's and an absent status proposes the
+// resource; for update absent fields are left as they are.
+type assignmentRecord struct {
+	RequestID    int64  `json:"requestId"`
+	ResourceID   int64  `json:"resourceId"`
+	HoursPerWeek *int64 `json:"hoursPerWeek"`
+	Completed    *bool  `json:"completed"`
+	Status       string `json:"status"`
+}
+
+func (rec assignmentRecord) String() string {
+	return fmt.Sprintf("resource %d from request %d", rec.ResourceID, rec.RequestID)
+}
+
+var assignmentCommands = []command{
+	{"list", "<request-id>", "List a request's assignments", runAssignmentList},
+	{"get", "<request-id> <resource-id>", "Show an assignment", runAssignmentGet},
+	{"create", "<request-id> <resource-id> | -f file", "Assign a resource to a request", runAssignmentCreate},
+	{"update", "<request-id> <resource-id> | -f file", "Change an assignment", runAssignmentUpdate},
+	{"delete", "<request-id> <resource-id> | -f file", "Remove an assignment", runAssignmentDelete},
+	{"transition", "<request-id> <resource-id> <status>", "Move an assignment to another status", runAssignmentTransition},
+	{"history", "<request-id> <resource-id>", "Show an assignment's status changes", runAssignmentHistory},
+}
+
+// assignmentArgs parses a request id and, if given, a resource id.
+func assignmentArgs(pos []string) (assignmentRecord, error) {
+	var rec assignmentRecord
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return rec, err
+	}
+	rec.RequestID = id
+
+	if len(pos) > 1 {
+		id, err := parseID(pos[1])
+		if err != nil {
+			return rec, err
+		}
+		rec.ResourceID = id
+	}
+
+	return rec, nil
+}
+
+// assignmentRecords returns the assignment named by the arguments, or those
+// in file.
+func assignmentRecords(name string, pos []string, file string) ([]assignmentRecord, error) {
+	switch {
+	case len(pos) == 2 && file == "":
+		rec, err := assignmentArgs(pos)
+		if err != nil {
+			return nil, err
+		}
+		return []assignmentRecord{rec}, nil
+	case len(pos) == 0 && file != "":
+		var records []assignmentRecord
+		if err := readRecords(file, &records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	default:
+		return nil, usageError{fmt.Errorf("assignments %s: give either a request id and resource id or -f file", name)}
+	}
+}
+
+func runAssignmentList(c *cli, args []string) error {
+	fs := c.flags("assignments", "list")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	rec, err := assignmentArgs(pos)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := c.client.ListAssignments(c.ctx, rec.RequestID)
+	if err != nil {
+		return err
+	}
+
+	return writeList(c, assignmentColumns, assignments)
+}
+
+func runAssignmentGet(c *cli, args []string) error {
+	fs := c.flags("assignments", "get")
+	pos, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	rec, err := assignmentArgs(pos)
+	if err != nil {
+		return err
+	}
+
+	a, err := c.client.GetAssignment(c.ctx, rec.RequestID, rec.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, assignmentColumns, a)
+}
+
+func runAssignmentCreate(c *cli, args []string) error {
+	fs := c.flags("assignments", "create")
+	file := fs.String("f", "", "Create every assignment in this JSON or CSV file (- for stdin)")
+	hours := fs.Int64("hours", 0, "Hours per week (default the request's)")
+	status := fs.String("status", "", "Initial status, proposed or accepted (default proposed)")
+
+	pos, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	records, err := assignmentRecords("create", pos, *file)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		if setFlags(fs)["hours"] {
+			records[0].HoursPerWeek = hours
+		}
+		records[0].Status = *status
+
+		a, err := c.client.CreateAssignment(c.ctx, records[0].RequestID, records[0].ResourceID, records[0].HoursPerWeek, records[0].Status)
+		if err != nil {
+			return err
+		}
+		return writeOne(c, assignmentColumns, a)
+	}
+
+	created, err := runBulk(c, records, func(rec assignmentRecord) (*client.ResourceAssignment, error) {
+		return c.client.CreateAssignment(c.ctx, rec.RequestID, rec.ResourceID, rec.HoursPerWeek, rec.Status)
+	})
+	return writeBulk(c, assignmentColumns, created, err)
+}
+
+func runAssignmentUpdate(c *cli, args []string) error {
+	fs := c.flags("assignments", "update")
+	file := fs.String("f", "", "Apply every change in this JSON or CSV file (- for stdin)")
+	hours := fs.Int64("hours", 0, "Hours per week")
+	completed := fs.Bool("completed", true, "Whether the assignment is completed")
+
+	pos, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	records, err := assignmentRecords("update", pos, *file)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		set := setFlags(fs)
+		if !set["hours"] && !set["completed"] {
+			return usageError{errors.New("assignments update: nothing to change; use -hours or -completed")}
+		}
+
+		var u client.AssignmentUpdate
+		if set["hours"] {
+			u.HoursPerWeek = hours
+		}
+		if set["completed"] {
+			u.Completed = completed
+		}
+
+		a, err := c.client.UpdateAssignment(c.ctx, records[0].RequestID, records[0].ResourceID, u)
+		if err != nil {
+			return err
+		}
+		return writeOne(c, assignmentColumns, a)
+	}
+
+	updated, err := runBulk(c, records, func(rec assignmentRecord) (*client.ResourceAssignment, error) {
+		u := client.AssignmentUpdate{HoursPerWeek: rec.HoursPerWeek, Completed: rec.Completed}
+		return c.client.UpdateAssignment(c.ctx, rec.RequestID, rec.ResourceID, u)
+	})
+	return writeBulk(c, assignmentColumns, updated, err)
+}
+
+func runAssignmentDelete(c *cli, args []string) error {
+	fs := c.flags("assignments", "delete")
+	file := fs.String("f", "", "Remove every assignment in this JSON or CSV file (- for stdin)")
+
+	pos, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	records, err := assignmentRecords("delete", pos, *file)
+	if err != nil {
+		return err
+	}
+
+	return runBulkDelete(c, records, assignmentRecord.String, func(rec assignmentRecord) error {
+		return c.client.DeleteAssignment(c.ctx, rec.RequestID, rec.ResourceID)
+	})
+}
+
+func runAssignmentTransition(c *cli, args []string) error {
+	fs := c.flags("assignments", "transition")
+	comment := fs.String("comment", "", "Comment on the change; needed to reject or withdraw an assignment")
+
+	pos, err := parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	rec, err := assignmentArgs(pos[:2])
+	if err != nil {
+		return err
+	}
+
+	a, _, err := c.client.TransitionAssignment(c.ctx, rec.RequestID, rec.ResourceID, pos[2], *comment)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, assignmentColumns, a)
+}
+
+func runAssignmentHistory(c *cli, args []string) error {
+	fs := c.flags("assignments", "history")
+	pos, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	rec, err := assignmentArgs(pos)
+	if err != nil {
+		return err
+	}
+
+	transitions, err := c.client.ListAssignmentTransitions(c.ctx, rec.RequestID, rec.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	return writeList(c, assignmentTransitionColumns, transitions)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// date is a calendar date given as 2006-01-02 or as an RFC 3339 timestamp.
+// It is accepted by flags, CSV cells and JSON records alike.
+type date struct {
+	time.Time
+}
+
+func (d *date) Set(s string) error {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("invalid date %q, want YYYY-MM-DD", s)
+		}
+	}
+	d.Time = t
+	return nil
+}
+
+func (d *date) String() string {
+	if d == nil {
+		return ""
+	}
+	return formatDate(d.Time)
+}
+
+func (d *date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return d.Set(s)
+}
+
+// list is a comma-separated flag value.
+type list []string
+
+func (l *list) Set(s string) error {
+	*l = splitList(s)
+	return nil
+}
+
+func (l *list) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// splitList splits a CSV cell or flag value on commas or semicolons.
+func splitList(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' })
+
+	values := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			values = append(values, f)
+		}
+	}
+	return values
+}
+
+// readRecords decodes the file at path into out, a pointer to a slice of
+// structs. The file is either a JSON array of objects or a CSV file whose
+// header row names the objects' JSON fields; list cells separate their
+// values with commas or semicolons. A path of "-" reads standard input.
+func readRecords(path string, out any) error {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+
+	first, err := firstByte(br)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if first == '[' {
+		dec := json.NewDecoder(br)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(out); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := decodeCSV(br, out); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// firstByte skips leading white space and any byte order mark, and returns
+// the first byte of the content without consuming it.
+func firstByte(br *bufio.Reader) (byte, error) {
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, errors.New("file is empty")
+			}
+			return 0, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.Discard(1)
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func decodeCSV(r io.Reader, out any) error {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+
+	slice := reflect.ValueOf(out).Elem()
+	elemType := slice.Type().Elem()
+
+	// Check the header once so a typo fails before any record is sent.
+	probe := reflect.New(elemType).Elem()
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if _, ok := fieldByName(probe, header[i]); !ok {
+			return fmt.Errorf("line 1: unknown column %q", header[i])
+		}
+	}
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := cr.FieldPos(0)
+
+		elem := reflect.New(elemType).Elem()
+		for i, cell := range row {
+			field, _ := fieldByName(elem, header[i])
+			if err := setField(field, strings.TrimSpace(cell)); err != nil {
+				return fmt.Errorf("line %d: %s: %w", line, header[i], err)
+			}
+		}
+
+		slice.Set(reflect.Append(slice, elem))
+	}
+}
+
+// fieldByName finds the struct field whose JSON name is name, looking inside
+// embedded structs.
+func fieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if f, ok := fieldByName(v.Field(i), name); ok {
+				return f, true
+			}
+			continue
+		}
+
+		tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == name && sf.IsExported() {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// setField parses s into field. An empty cell leaves the field unset, which
+// for the pointer fields of update records means "do not change".
+func setField(field reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	if fv, ok := field.Addr().Interface().(flag.Value); ok {
+		return fv.Set(s)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(s)))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+// runBulk applies do to every record, carrying on past failures, which are
+// reported on stderr. It returns the results of the records that succeeded
+// and an error if any failed.
+func runBulk[R, T any](c *cli, records []R, do func(R) (*T, error)) ([]*T, error) {
+	var (
+		results []*T
+		failed  int
+	)
+
+	for i, rec := range records {
+		if err := c.ctx.Err(); err != nil {
+			return results, err
+		}
+
+		res, err := do(rec)
+		if err != nil {
+			failed++
+			fmt.Fprintf(c.stderr, "record %d: %v\n", i+1, err)
+			continue
+		}
+
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d records failed", failed, len(records))
+	}
+
+	return results, nil
+}
+
+// runBulkDelete deletes every record, reporting each deletion on stdout.
+func runBulkDelete[R any](c *cli, records []R, describe func(R) string, del func(R) error) error {
+	_, err := runBulk(c, records, func(rec R) (*struct{}, error) {
+		if err := del(rec); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(c.stdout, "deleted %s\n", describe(rec))
+		return nil, nil
+	})
+	return err
+}
+
+// idRecords returns the ids given as arguments, or those in file.
+func idRecords(fs *flag.FlagSet, args []string, file *string) ([]idRecord, error) {
+	pos, err := parse(fs, args, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []idRecord
+
+	switch {
+	case len(pos) > 0 && *file == "":
+		for _, arg := range pos {
+			id, err := parseID(arg)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, idRecord{ID: id})
+		}
+	case len(pos) == 0 && *file != "":
+		if err := readRecords(*file, &records); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, usageError{fmt.Errorf("%s: give either ids or -f file", fs.Name())}
+	}
+
+	return records, nil
+}
+
+// parseID parses a positional id argument.
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, usageError{fmt.Errorf("invalid id %q", s)}
+	}
+	return id, nil
+}
@@ -0,0 +1,315 @@
+// Command dashctl administers the delivery dashboard through its API.
+//
+// Usage:
+//
+//	dashctl [global flags] <group> <command> [flags] [arguments]
+//
+// Run "dashctl help" for the list of groups and commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/pkg/client"
+)
+
+// usageError reports a mistake in the command line. Its message has already
+// been printed by the flag package when err is nil.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	if e.err == nil {
+		return "invalid usage"
+	}
+	return e.err.Error()
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+type group struct {
+	name     string
+	summary  string
+	commands []command
+}
+
+// cli carries what every command needs: the API client, where to write and
+// the output format.
+type cli struct {
+	ctx     context.Context
+	client  *client.Client
+	config  string
+	profile string
+	output  string
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	var uerr usageError
+	if errors.As(err, &uerr) {
+		if uerr.err != nil {
+			fmt.Fprintf(os.Stderr, "dashctl: %v\n", uerr.err)
+		}
+		os.Exit(2)
+	}
+
+	fmt.Fprintf(os.Stderr, "dashctl: %v\n", err)
+	os.Exit(1)
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	c := &cli{stdout: stdout, stderr: stderr}
+
+	var (
+		server  string
+		token   string
+		timeout time.Duration
+	)
+
+	fs := flag.NewFlagSet("dashctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { printUsage(stderr) }
+
+	fs.StringVar(&c.config, "config", defaultConfigPath(), "Profiles file (env DASHCTL_CONFIG)")
+	fs.StringVar(&c.profile, "profile", os.Getenv("DASHCTL_PROFILE"), "Profile to use instead of the current one (env DASHCTL_PROFILE)")
+	fs.StringVar(&server, "server", os.Getenv("DASHCTL_SERVER"), "API base URL, overriding the profile (env DASHCTL_SERVER)")
+	fs.StringVar(&token, "token", os.Getenv("DASHCTL_TOKEN"), "Bearer token, overriding the profile (env DASHCTL_TOKEN)")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "Time limit for the whole command")
+	c.outputFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{}
+	}
+
+	args = fs.Args()
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(stdout)
+		return nil
+	}
+
+	g, ok := findGroup(args[0])
+	if !ok {
+		return usageError{fmt.Errorf("unknown group %q; run \"dashctl help\"", args[0])}
+	}
+
+	if len(args) < 2 || args[1] == "help" {
+		printGroupUsage(stdout, g)
+		return nil
+	}
+
+	cmd, ok := findCommand(g, args[1])
+	if !ok {
+		return usageError{fmt.Errorf("unknown command %q for %s; run \"dashctl %s help\"", args[1], g.name, g.name)}
+	}
+
+	cfg, err := loadProfiles(c.config)
+	if err != nil {
+		return err
+	}
+
+	// Profile commands must work before any profile exists, so they fall
+	// back to the default server rather than failing.
+	p, err := cfg.resolve(c.profile)
+	if err != nil {
+		if g.name != "profile" {
+			return err
+		}
+		p = profile{Server: defaultServer}
+	}
+
+	if server == "" {
+		server = p.Server
+	}
+	if token == "" {
+		token = p.Token
+	}
+	if !isSet(fs, "output") && p.Output != "" {
+		c.output = p.Output
+	}
+
+	c.client, err = client.New(server, client.WithToken(token), client.WithUserAgent("dashctl/"+version))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.ctx = ctx
+
+	return cmd.run(c, args[2:])
+}
+
+const version = "0.0.1"
+
+var groups []group
+
+func init() {
+	groups = []group{
+		{"resources", "People who can be staffed", resourceCommands},
+		{"positions", "Position titles", positionCommands},
+		{"clearances", "Security clearance levels", clearanceCommands},
+		{"requests", "Customer resource requests", requestCommands},
+		{"assignments", "Resources assigned to requests", assignmentCommands},
+		{"profile", "Saved servers and credentials", profileCommands},
+	}
+}
+
+func findGroup(name string) (group, bool) {
+	for _, g := range groups {
+		if g.name == name {
+			return g, true
+		}
+	}
+	return group{}, false
+}
+
+func findCommand(g group, name string) (command, bool) {
+	for _, cmd := range g.commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: dashctl [global flags] <group> <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Groups:")
+	for _, g := range groups {
+		fmt.Fprintf(w, "  %-12s %s\n", g.name, g.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	fmt.Fprintln(w, "  -config path     Profiles file (env DASHCTL_CONFIG)")
+	fmt.Fprintln(w, "  -profile name    Profile to use (env DASHCTL_PROFILE)")
+	fmt.Fprintln(w, "  -server url      API base URL (env DASHCTL_SERVER)")
+	fmt.Fprintln(w, "  -token token     Bearer token (env DASHCTL_TOKEN)")
+	fmt.Fprintln(w, "  -timeout dur     Time limit for the whole command (default 30s)")
+	fmt.Fprintln(w, "  -o format        Output format: table, json or csv (default table)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"dashctl <group> help\" for a group's commands.")
+}
+
+func printGroupUsage(w io.Writer, g group) {
+	fmt.Fprintf(w, "Usage: dashctl %s <command> [flags] [arguments]\n\n", g.name)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range g.commands {
+		fmt.Fprintf(w, "  %-40s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Run \"dashctl %s <command> -h\" for a command's flags.\n", g.name)
+}
+
+// flags returns a flag set for a command that also accepts -o.
+func (c *cli) flags(g, cmd string) *flag.FlagSet {
+	fs := flag.NewFlagSet("dashctl "+g+" "+cmd, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	c.outputFlag(fs)
+	return fs
+}
+
+func (c *cli) outputFlag(fs *flag.FlagSet) {
+	fs.Func("o", "Output format: table, json or csv (default table)", c.setOutput)
+	fs.Func("output", "Same as -o", c.setOutput)
+}
+
+func (c *cli) setOutput(s string) error {
+	switch s {
+	case formatTable, formatJSON, formatCSV:
+		c.output = s
+		return nil
+	default:
+		return errors.New("must be table, json or csv")
+	}
+}
+
+// parse parses fs, allowing flags after positional arguments, and checks
+// that between min and max positional arguments were given. max < 0 means
+// no upper limit.
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	switch {
+	case len(positional) < min:
+		return nil, usageError{fmt.Errorf("%s: missing arguments", fs.Name())}
+	case max >= 0 && len(positional) > max:
+		return nil, usageError{fmt.Errorf("%s: unexpected arguments %s", fs.Name(), strings.Join(positional[max:], " "))}
+	}
+
+	return positional, nil
+}
+
+// isSet reports whether the named flag was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name || (name == "output" && f.Name == "o") {
+			set = true
+		}
+	})
+	return set
+}
+
+// setFlags returns the names of the flags given on the command line.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// column is one field of a record as shown in table and CSV output. The name
+// matches the record's JSON field so CSV output can be fed back to the bulk
+// commands.
+type column[T any] struct {
+	name  string
+	value func(*T) string
+}
+
+// writeList prints items in the selected format. JSON output is the records
+// exactly as the API returned them.
+func writeList[T any](c *cli, cols []column[T], items []*T) error {
+	switch c.output {
+	case formatJSON:
+		if items == nil {
+			items = []*T{}
+		}
+		return writeJSON(c, items)
+
+	case formatCSV:
+		w := csv.NewWriter(c.stdout)
+
+		row := make([]string, len(cols))
+		for i, col := range cols {
+			row[i] = col.name
+		}
+		w.Write(row)
+
+		for _, item := range items {
+			for i, col := range cols {
+				row[i] = col.value(item)
+			}
+			w.Write(row)
+		}
+
+		w.Flush()
+		return w.Error()
+
+	default:
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+		row := make([]string, len(cols))
+		for i, col := range cols {
+			row[i] = heading(col.name)
+		}
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+
+		for _, item := range items {
+			for i, col := range cols {
+				row[i] = strings.ReplaceAll(col.value(item), "\t", " ")
+			}
+			w.Write([]byte(strings.Join(row, "\t") + "\n"))
+		}
+
+		return w.Flush()
+	}
+}
+
+// writeBulk prints the records a bulk command produced, if any, even when
+// some failed, and returns the command's error in preference to an output error.
+func writeBulk[T any](c *cli, cols []column[T], items []*T, err error) error {
+	if len(items) == 0 {
+		return err
+	}
+	if werr := writeList(c, cols, items); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// writeOne prints a single record: as an object for JSON and as a one-row
+// list otherwise.
+func writeOne[T any](c *cli, cols []column[T], item *T) error {
+	if c.output == formatJSON {
+		return writeJSON(c, item)
+	}
+	return writeList(c, cols, []*T{item})
+}
+
+func writeJSON(c *cli, v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// heading turns a JSON field name such as "hoursPerWeek" into a table
+// heading such as "HOURS PER WEEK".
+func heading(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(name[i-1])) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func formatBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+func formatList(values []string) string {
+	return strings.Join(values, ", ")
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8086"
+
+// profile is a named server and the credentials to use with it.
+type profile struct {
+	Server string `yaml:"server" json:"server"`
+	Token  string `yaml:"token,omitempty" json:"-"`
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+}
+
+// profiles is the profiles file, by default
+// $XDG_CONFIG_HOME/dashctl/config.yaml:
+//
+//	current: prod
+//	profiles:
+//	  prod:
+//	    server: https://dashboard.example.com
+//	    token: s3cr3t
+//	    output: table
+type profiles struct {
+	path     string
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]profile `yaml:"profiles,omitempty"`
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("DASHCTL_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "dashctl", "config.yaml")
+}
+
+// loadProfiles reads the profiles file. A missing file holds no profiles.
+func loadProfiles(path string) (*profiles, error) {
+	p := &profiles{path: path, Profiles: make(map[string]profile)}
+
+	if path == "" {
+		return p, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return p, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]profile)
+	}
+
+	return p, nil
+}
+
+// resolve returns the named profile, or the current one when name is empty.
+// Without either, it returns the default server with no credentials.
+func (p *profiles) resolve(name string) (profile, error) {
+	if name == "" {
+		name = p.Current
+	}
+
+	if name == "" {
+		return profile{Server: defaultServer}, nil
+	}
+
+	pr, ok := p.Profiles[name]
+	if !ok {
+		return profile{}, fmt.Errorf("profile %q not found in %s", name, p.path)
+	}
+
+	if pr.Server == "" {
+		pr.Server = defaultServer
+	}
+
+	return pr, nil
+}
+
+// save writes the profiles file, readable only by its owner since it holds
+// tokens.
+func (p *profiles) save() error {
+	if p.path == "" {
+		return errors.New("no profiles file: set -config or DASHCTL_CONFIG")
+	}
+
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p.path, b, 0o600)
+}
+
+type profileRow struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	profile
+	HasToken bool `json:"hasToken"`
+}
+
+var profileColumns = []column[profileRow]{
+	{"name", func(p *profileRow) string { return p.Name }},
+	{"current", func(p *profileRow) string { return mark(p.Current) }},
+	{"server", func(p *profileRow) string { return p.Server }},
+	{"token", func(p *profileRow) string { return mark(p.HasToken) }},
+	{"output", func(p *profileRow) string { return p.Output }},
+}
+
+func mark(b bool) string {
+	if b {
+		return "*"
+	}
+	return ""
+}
+
+var profileCommands = []command{
+	{"list", "", "List saved profiles", runProfileList},
+	{"set", "<name>", "Create or change a profile", runProfileSet},
+	{"use", "<name>", "Make a profile the current one", runProfileUse},
+	{"delete", "<name>", "Remove a profile", runProfileDelete},
+}
+
+func runProfileList(c *cli, args []string) error {
+	fs := c.flags("profile", "list")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	cfg, err := loadProfiles(c.config)
+	if err != nil {
+		return err
+	}
+
+	var rows []*profileRow
+	for _, name := range sortedKeys(cfg.Profiles) {
+		pr := cfg.Profiles[name]
+		rows = append(rows, &profileRow{
+			Name:     name,
+			Current:  name == cfg.Current,
+			profile:  pr,
+			HasToken: pr.Token != "",
+		})
+	}
+
+	return writeList(c, profileColumns, rows)
+}
+
+func runProfileSet(c *cli, args []string) error {
+	fs := c.flags("profile", "set")
+	server := fs.String("server", "", "API base URL")
+	token := fs.String("token", "", "Bearer token")
+	tokenStdin := fs.Bool("token-stdin", false, "Read the bearer token from standard input")
+	output := fs.String("default-output", "", "Default output format for this profile")
+	use := fs.Bool("use", false, "Also make this the current profile")
+
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadProfiles(c.config)
+	if err != nil {
+		return err
+	}
+
+	name := pos[0]
+	pr := cfg.Profiles[name]
+	set := setFlags(fs)
+
+	if set["server"] {
+		pr.Server = *server
+	}
+
+	switch {
+	case *tokenStdin:
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("reading token: %w", err)
+		}
+		pr.Token = strings.TrimSpace(line)
+	case set["token"]:
+		pr.Token = *token
+	}
+
+	if set["default-output"] {
+		switch *output {
+		case "", formatTable, formatJSON, formatCSV:
+			pr.Output = *output
+		default:
+			return usageError{errors.New("-default-output must be table, json or csv")}
+		}
+	}
+
+	cfg.Profiles[name] = pr
+	if *use || cfg.Current == "" {
+		cfg.Current = name
+	}
+
+	return cfg.save()
+}
+
+func runProfileUse(c *cli, args []string) error {
+	fs := c.flags("profile", "use")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadProfiles(c.config)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.Profiles[pos[0]]; !ok {
+		return fmt.Errorf("profile %q not found", pos[0])
+	}
+
+	cfg.Current = pos[0]
+
+	return cfg.save()
+}
+
+func runProfileDelete(c *cli, args []string) error {
+	fs := c.flags("profile", "delete")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadProfiles(c.config)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.Profiles[pos[0]]; !ok {
+		return fmt.Errorf("profile %q not found", pos[0])
+	}
+
+	delete(cfg.Profiles, pos[0])
+	if cfg.Current == pos[0] {
+		cfg.Current = ""
+	}
+
+	return cfg.save()
+}
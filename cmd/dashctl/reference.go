@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/pkg/client"
+)
+
+var positionColumns = []column[client.Position]{
+	{"id", func(p *client.Position) string { return formatID(p.ID) }},
+	{"title", func(p *client.Position) string { return p.Title }},
+}
+
+var clearanceColumns = []column[client.Clearance]{
+	{"id", func(cl *client.Clearance) string { return formatID(cl.ID) }},
+	{"description", func(cl *client.Clearance) string { return cl.Description }},
+}
+
+var positionCommands = []command{
+	{"list", "", "List positions", runPositionList},
+	{"get", "<id>", "Show a position", runPositionGet},
+	{"create", "<title> | -f file", "Create a position, or every position in a file", runPositionCreate},
+	{"update", "<id> <title> | -f file", "Rename a position, or every position in a file", runPositionUpdate},
+	{"delete", "<id>... | -f file", "Delete positions", runPositionDelete},
+}
+
+var clearanceCommands = []command{
+	{"list", "", "List clearances", runClearanceList},
+	{"get", "<id>", "Show a clearance", runClearanceGet},
+	{"create", "<description> | -f file", "Create a clearance, or every clearance in a file", runClearanceCreate},
+	{"update", "<id> <description> | -f file", "Rename a clearance, or every clearance in a file", runClearanceUpdate},
+	{"delete", "<id>... | -f file", "Delete clearances", runClearanceDelete},
+}
+
+// referenceRecord is a position or clearance in a bulk file. Positions use
+// title and clearances description.
+type referenceRecord struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func runPositionList(c *cli, args []string) error {
+	fs := c.flags("positions", "list")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	positions, err := c.client.ListPositions(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeList(c, positionColumns, positions)
+}
+
+func runPositionGet(c *cli, args []string) error {
+	fs := c.flags("positions", "get")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	position, err := c.client.GetPosition(c.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, positionColumns, position)
+}
+
+func runPositionCreate(c *cli, args []string) error {
+	fs := c.flags("positions", "create")
+	file := fs.String("f", "", "Create every title in this JSON or CSV file (- for stdin)")
+
+	pos, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 1 && *file == "":
+		position, err := c.client.CreatePosition(c.ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		return writeOne(c, positionColumns, position)
+	case len(pos) > 0 || *file == "":
+		return usageError{errors.New("positions create: give either a title or -f file")}
+	}
+
+	var records []referenceRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	created, err := runBulk(c, records, func(rec referenceRecord) (*client.Position, error) {
+		return c.client.CreatePosition(c.ctx, rec.Title)
+	})
+	return writeBulk(c, positionColumns, created, err)
+}
+
+func runPositionUpdate(c *cli, args []string) error {
+	fs := c.flags("positions", "update")
+	file := fs.String("f", "", "Apply every id and title in this JSON or CSV file (- for stdin)")
+
+	pos, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 2 && *file == "":
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+
+		position, err := c.client.UpdatePosition(c.ctx, id, pos[1])
+		if err != nil {
+			return err
+		}
+		return writeOne(c, positionColumns, position)
+	case len(pos) > 0 || *file == "":
+		return usageError{errors.New("positions update: give either an id and title or -f file")}
+	}
+
+	var records []referenceRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	updated, err := runBulk(c, records, func(rec referenceRecord) (*client.Position, error) {
+		return c.client.UpdatePosition(c.ctx, rec.ID, rec.Title)
+	})
+	return writeBulk(c, positionColumns, updated, err)
+}
+
+func runPositionDelete(c *cli, args []string) error {
+	fs := c.flags("positions", "delete")
+	file := fs.String("f", "", "Delete every id in this JSON or CSV file (- for stdin)")
+
+	records, err := idRecords(fs, args, file)
+	if err != nil {
+		return err
+	}
+
+	return runBulkDelete(c, records,
+		func(rec idRecord) string { return "position " + formatID(rec.ID) },
+		func(rec idRecord) error { return c.client.DeletePosition(c.ctx, rec.ID) })
+}
+
+func runClearanceList(c *cli, args []string) error {
+	fs := c.flags("clearances", "list")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	clearances, err := c.client.ListClearances(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeList(c, clearanceColumns, clearances)
+}
+
+func runClearanceGet(c *cli, args []string) error {
+	fs := c.flags("clearances", "get")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	clearance, err := c.client.GetClearance(c.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, clearanceColumns, clearance)
+}
+
+func runClearanceCreate(c *cli, args []string) error {
+	fs := c.flags("clearances", "create")
+	file := fs.String("f", "", "Create every description in this JSON or CSV file (- for stdin)")
+
+	pos, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 1 && *file == "":
+		clearance, err := c.client.CreateClearance(c.ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		return writeOne(c, clearanceColumns, clearance)
+	case len(pos) > 0 || *file == "":
+		return usageError{errors.New("clearances create: give either a description or -f file")}
+	}
+
+	var records []referenceRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	created, err := runBulk(c, records, func(rec referenceRecord) (*client.Clearance, error) {
+		return c.client.CreateClearance(c.ctx, rec.Description)
+	})
+	return writeBulk(c, clearanceColumns, created, err)
+}
+
+func runClearanceUpdate(c *cli, args []string) error {
+	fs := c.flags("clearances", "update")
+	file := fs.String("f", "", "Apply every id and description in this JSON or CSV file (- for stdin)")
+
+	pos, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 2 && *file == "":
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+
+		clearance, err := c.client.UpdateClearance(c.ctx, id, pos[1])
+		if err != nil {
+			return err
+		}
+		return writeOne(c, clearanceColumns, clearance)
+	case len(pos) > 0 || *file == "":
+		return usageError{errors.New("clearances update: give either an id and description or -f file")}
+	}
+
+	var records []referenceRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	updated, err := runBulk(c, records, func(rec referenceRecord) (*client.Clearance, error) {
+		return c.client.UpdateClearance(c.ctx, rec.ID, rec.Description)
+	})
+	return writeBulk(c, clearanceColumns, updated, err)
+}
+
+func runClearanceDelete(c *cli, args []string) error {
+	fs := c.flags("clearances", "delete")
+	file := fs.String("f", "", "Delete every id in this JSON or CSV file (- for stdin)")
+
+	records, err := idRecords(fs, args, file)
+	if err != nil {
+		return err
+	}
+
+	return runBulkDelete(c, records,
+		func(rec idRecord) string { return "clearance " + formatID(rec.ID) },
+		func(rec idRecord) error { return c.client.DeleteClearance(c.ctx, rec.ID) })
+}
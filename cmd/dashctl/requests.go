@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/pkg/client"
+)
+
+var requestColumns = []column[client.ResourceRequest]{
+	{"id", func(rr *client.ResourceRequest) string { return formatID(rr.ID) }},
+	{"customer", func(rr *client.ResourceRequest) string { return rr.Customer }},
+	{"startDate", func(rr *client.ResourceRequest) string { return formatDate(rr.StartDate) }},
+	{"endDate", func(rr *client.ResourceRequest) string { return formatDate(rr.EndDate) }},
+	{"hoursPerWeek", func(rr *client.ResourceRequest) string { return formatID(rr.HoursPerWeek) }},
+	{"skills", func(rr *client.ResourceRequest) string { return formatList(rr.Skills) }},
+	{"projectID", func(rr *client.ResourceRequest) string { return rr.OpportunityID }},
+	{"engagementID", func(rr *client.ResourceRequest) string { return rr.EngagementID }},
+	{"status", func(rr *client.ResourceRequest) string { return rr.Status }},
+}
+
+var transitionColumns = []column[client.RequestTransition]{
+	{"from", func(t *client.RequestTransition) string { return t.From }},
+	{"to", func(t *client.RequestTransition) string { return t.To }},
+	{"reason", func(t *client.RequestTransition) string { return t.Reason }},
+	{"actor", func(t *client.RequestTransition) string { return t.Actor }},
+	{"at", func(t *client.RequestTransition) string { return formatTime(t.CreatedAt) }},
+}
+
+// requestRecord is one resource request to create, from flags or a bulk
+// file.
+type requestRecord struct {
+	Customer     string   `json:"customer"`
+	StartDate    date     `json:"startDate"`
+	EndDate      date     `json:"endDate"`
+	HoursPerWeek int64    `json:"hoursPerWeek"`
+	Skills       []string `json:"skills"`
+	ProjectID    string   `json:"projectID"`
+	EngagementID string   `json:"engagementID"`
+	Status       string   `json:"status"`
+}
+
+func (rec requestRecord) request() *client.ResourceRequest {
+	return &client.ResourceRequest{
+		Customer:      rec.Customer,
+		StartDate:     rec.StartDate.Time,
+		EndDate:       rec.EndDate.Time,
+		HoursPerWeek:  rec.HoursPerWeek,
+		Skills:        rec.Skills,
+		OpportunityID: rec.ProjectID,
+		EngagementID:  rec.EngagementID,
+		Status:        rec.Status,
+	}
+}
+
+// requestUpdateRecord is one resource request to change in a bulk file.
+// Absent or empty fields are left as they are.
+type requestUpdateRecord struct {
+	ID           int64    `json:"id"`
+	Customer     *string  `json:"customer"`
+	StartDate    *date    `json:"startDate"`
+	EndDate      *date    `json:"endDate"`
+	HoursPerWeek *int64   `json:"hoursPerWeek"`
+	Skills       []string `json:"skills"`
+}
+
+func (rec requestUpdateRecord) update() client.RequestUpdate {
+	u := client.RequestUpdate{
+		Customer:     rec.Customer,
+		HoursPerWeek: rec.HoursPerWeek,
+		Skills:       rec.Skills,
+	}
+	if rec.StartDate != nil {
+		u.StartDate = &rec.StartDate.Time
+	}
+	if rec.EndDate != nil {
+		u.EndDate = &rec.EndDate.Time
+	}
+	return u
+}
+
+var requestCommands = []command{
+	{"list", "", "List resource requests", runRequestList},
+	{"get", "<id>", "Show a resource request", runRequestGet},
+	{"create", "[-f file]", "Create a request, or every request in a file", runRequestCreate},
+	{"update", "<id> | -f file", "Change a request, or every request in a file", runRequestUpdate},
+	{"delete", "<id>... | -f file", "Delete resource requests", runRequestDelete},
+	{"transition", "<id> <status>", "Move a request to another status", runRequestTransition},
+	{"history", "<id>", "Show a request's status changes", runRequestHistory},
+}
+
+func runRequestList(c *cli, args []string) error {
+	fs := c.flags("requests", "list")
+
+	var opts client.RequestListOptions
+	fs.StringVar(&opts.Customer, "customer", "", "Only requests whose customer contains these words")
+	fs.Var((*list)(&opts.Skills), "skills", "Only requests needing all of these skills (comma-separated)")
+	fs.Var((*list)(&opts.Status), "status", "Only requests in one of these statuses (comma-separated)")
+	fs.StringVar(&opts.Sort, "sort", "", "Sort column, prefixed with - to descend")
+	fs.IntVar(&opts.Page, "page", 0, "Fetch only this page (default every page)")
+	fs.IntVar(&opts.PageSize, "page-size", 0, "Records per page")
+
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if opts.Page > 0 {
+		requests, _, err := c.client.ListRequests(c.ctx, opts)
+		if err != nil {
+			return err
+		}
+		return writeList(c, requestColumns, requests)
+	}
+
+	var requests []*client.ResourceRequest
+	it := c.client.Requests(c.ctx, opts)
+	for it.Next() {
+		requests = append(requests, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	return writeList(c, requestColumns, requests)
+}
+
+func runRequestGet(c *cli, args []string) error {
+	fs := c.flags("requests", "get")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	rr, err := c.client.GetRequest(c.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, requestColumns, rr)
+}
+
+func runRequestCreate(c *cli, args []string) error {
+	fs := c.flags("requests", "create")
+	file := fs.String("f", "", "Create every request in this JSON or CSV file (- for stdin)")
+
+	var rec requestRecord
+	fs.StringVar(&rec.Customer, "customer", "", "Customer name")
+	fs.Var(&rec.StartDate, "start", "Start date (YYYY-MM-DD)")
+	fs.Var(&rec.EndDate, "end", "End date (YYYY-MM-DD)")
+	fs.Int64Var(&rec.HoursPerWeek, "hours", 0, "Hours per week")
+	fs.Var((*list)(&rec.Skills), "skills", "Skills needed (comma-separated)")
+	fs.StringVar(&rec.ProjectID, "project", "", "Project (opportunity) id")
+	fs.StringVar(&rec.EngagementID, "engagement", "", "Engagement id")
+	fs.StringVar(&rec.Status, "status", "", "Initial status, draft or open (default open)")
+
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		rr, err := c.client.CreateRequest(c.ctx, rec.request())
+		if err != nil {
+			return err
+		}
+		return writeOne(c, requestColumns, rr)
+	}
+
+	var records []requestRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	created, err := runBulk(c, records, func(rec requestRecord) (*client.ResourceRequest, error) {
+		return c.client.CreateRequest(c.ctx, rec.request())
+	})
+	return writeBulk(c, requestColumns, created, err)
+}
+
+func runRequestUpdate(c *cli, args []string) error {
+	fs := c.flags("requests", "update")
+	file := fs.String("f", "", "Apply every change in this JSON or CSV file (- for stdin)")
+
+	var (
+		u     client.RequestUpdate
+		start date
+		end   date
+	)
+	customer := fs.String("customer", "", "Customer name")
+	fs.Var(&start, "start", "Start date (YYYY-MM-DD)")
+	fs.Var(&end, "end", "End date (YYYY-MM-DD)")
+	hours := fs.Int64("hours", 0, "Hours per week")
+	fs.Var((*list)(&u.Skills), "skills", "Skills needed (comma-separated), replacing the current ones")
+
+	pos, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 1 && *file == "":
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+
+		set := setFlags(fs)
+		if set["customer"] {
+			u.Customer = customer
+		}
+		if set["start"] {
+			u.StartDate = &start.Time
+		}
+		if set["end"] {
+			u.EndDate = &end.Time
+		}
+		if set["hours"] {
+			u.HoursPerWeek = hours
+		}
+
+		rr, err := c.client.UpdateRequest(c.ctx, id, u)
+		if err != nil {
+			return err
+		}
+		return writeOne(c, requestColumns, rr)
+	case len(pos) > 0 || *file == "":
+		return usageError{errors.New("requests update: give either an id or -f file")}
+	}
+
+	var records []requestUpdateRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	updated, err := runBulk(c, records, func(rec requestUpdateRecord) (*client.ResourceRequest, error) {
+		return c.client.UpdateRequest(c.ctx, rec.ID, rec.update())
+	})
+	return writeBulk(c, requestColumns, updated, err)
+}
+
+func runRequestDelete(c *cli, args []string) error {
+	fs := c.flags("requests", "delete")
+	file := fs.String("f", "", "Delete every id in this JSON or CSV file (- for stdin)")
+
+	records, err := idRecords(fs, args, file)
+	if err != nil {
+		return err
+	}
+
+	return runBulkDelete(c, records,
+		func(rec idRecord) string { return "request " + formatID(rec.ID) },
+		func(rec idRecord) error { return c.client.DeleteRequest(c.ctx, rec.ID) })
+}
+
+func runRequestTransition(c *cli, args []string) error {
+	fs := c.flags("requests", "transition")
+	reason := fs.String("reason", "", "Why the request is moving; needed to put it on hold or cancel it")
+
+	pos, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	rr, _, err := c.client.TransitionRequest(c.ctx, id, pos[1], *reason)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, requestColumns, rr)
+}
+
+func runRequestHistory(c *cli, args []string) error {
+	fs := c.flags("requests", "history")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	transitions, err := c.client.ListRequestTransitions(c.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeList(c, transitionColumns, transitions)
+}
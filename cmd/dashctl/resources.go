@@ -0,0 +1,281 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/pkg/client"
+)
+
+var resourceColumns = []column[client.Resource]{
+	{"id", func(r *client.Resource) string { return formatID(r.ID) }},
+	{"firstName", func(r *client.Resource) string { return r.FirstName }},
+	{"lastName", func(r *client.Resource) string { return r.LastName }},
+	{"position", func(r *client.Resource) string { return r.Position }},
+	{"clearance", func(r *client.Resource) string { return r.Clearance }},
+	{"specialties", func(r *client.Resource) string { return formatList(r.Specialties) }},
+	{"certifications", func(r *client.Resource) string { return formatList(r.Certifications) }},
+	{"active", func(r *client.Resource) string { return formatBool(r.Active) }},
+	{"sex", func(r *client.Resource) string { return r.Sex }},
+	{"email", func(r *client.Resource) string { return r.Email }},
+	{"capacityHours", func(r *client.Resource) string { return formatID(r.CapacityHours) }},
+}
+
+// resourceRecord is one resource to create, from flags or a bulk file.
+type resourceRecord struct {
+	ID             int64    `json:"id"`
+	FirstName      string   `json:"firstName"`
+	LastName       string   `json:"lastName"`
+	Position       string   `json:"position"`
+	Clearance      string   `json:"clearance"`
+	Specialties    []string `json:"specialties"`
+	Certifications []string `json:"certifications"`
+	Active         *bool    `json:"active"`
+	Sex            string   `json:"sex"`
+	Email          string   `json:"email"`
+	CapacityHours  int64    `json:"capacityHours"`
+}
+
+func (rec resourceRecord) resource() *client.Resource {
+	r := &client.Resource{
+		ID:             rec.ID,
+		FirstName:      rec.FirstName,
+		LastName:       rec.LastName,
+		Position:       rec.Position,
+		Clearance:      rec.Clearance,
+		Specialties:    rec.Specialties,
+		Certifications: rec.Certifications,
+		Active:         true,
+		Sex:            rec.Sex,
+		Email:          rec.Email,
+		CapacityHours:  rec.CapacityHours,
+	}
+	if rec.Active != nil {
+		r.Active = *rec.Active
+	}
+	return r
+}
+
+// resourceUpdateRecord is one resource to change in a bulk file. Absent or
+// empty fields are left as they are.
+type resourceUpdateRecord struct {
+	ID int64 `json:"id"`
+	client.ResourceUpdate
+}
+
+type idRecord struct {
+	ID int64 `json:"id"`
+}
+
+var resourceCommands = []command{
+	{"list", "", "List resources", runResourceList},
+	{"get", "<id>", "Show a resource", runResourceGet},
+	{"create", "[-f file]", "Create a resource, or every resource in a file", runResourceCreate},
+	{"update", "<id> | -f file", "Change a resource, or every resource in a file", runResourceUpdate},
+	{"deactivate", "<id>... | -f file", "Mark leavers inactive", runResourceDeactivate},
+	{"delete", "<id>... | -f file", "Delete resources", runResourceDelete},
+}
+
+func runResourceList(c *cli, args []string) error {
+	fs := c.flags("resources", "list")
+
+	var opts client.ResourceListOptions
+	fs.Var((*list)(&opts.Specialties), "specialties", "Only resources with all of these specialties (comma-separated)")
+	fs.Var((*list)(&opts.Certifications), "certifications", "Only resources with all of these certifications (comma-separated)")
+	fs.BoolVar(&opts.Inactive, "inactive", false, "List only inactive resources")
+	fs.StringVar(&opts.Filter, "filter", "", "Only resources matching this filter expression, e.g. 'clearance >= NV1'")
+	fs.StringVar(&opts.Sort, "sort", "", "Sort column, prefixed with - to descend")
+	fs.IntVar(&opts.Page, "page", 0, "Fetch only this page (default every page)")
+	fs.IntVar(&opts.PageSize, "page-size", 0, "Records per page")
+
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if opts.Page > 0 {
+		resources, _, err := c.client.ListResources(c.ctx, opts)
+		if err != nil {
+			return err
+		}
+		return writeList(c, resourceColumns, resources)
+	}
+
+	var resources []*client.Resource
+	it := c.client.Resources(c.ctx, opts)
+	for it.Next() {
+		resources = append(resources, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	return writeList(c, resourceColumns, resources)
+}
+
+func runResourceGet(c *cli, args []string) error {
+	fs := c.flags("resources", "get")
+	pos, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	resource, err := c.client.GetResource(c.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeOne(c, resourceColumns, resource)
+}
+
+func runResourceCreate(c *cli, args []string) error {
+	fs := c.flags("resources", "create")
+	file := fs.String("f", "", "Create every resource in this JSON or CSV file (- for stdin)")
+
+	var rec resourceRecord
+	fs.Int64Var(&rec.ID, "id", 0, "Employee id")
+	fs.StringVar(&rec.FirstName, "first-name", "", "First name")
+	fs.StringVar(&rec.LastName, "last-name", "", "Last name")
+	fs.StringVar(&rec.Position, "position", "", "Position title")
+	fs.StringVar(&rec.Clearance, "clearance", "", "Clearance level")
+	fs.Var((*list)(&rec.Specialties), "specialties", "Specialties (comma-separated)")
+	fs.Var((*list)(&rec.Certifications), "certifications", "Certifications (comma-separated)")
+	fs.StringVar(&rec.Sex, "sex", "", "Sex")
+	fs.StringVar(&rec.Email, "email", "", "Email address")
+	fs.Int64Var(&rec.CapacityHours, "capacity", 0, "Hours a week the resource can be assigned (default the server's)")
+	inactive := fs.Bool("inactive", false, "Create the resource inactive")
+
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		if *inactive {
+			active := false
+			rec.Active = &active
+		}
+
+		resource, err := c.client.CreateResource(c.ctx, rec.resource())
+		if err != nil {
+			return err
+		}
+		return writeOne(c, resourceColumns, resource)
+	}
+
+	var records []resourceRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	created, err := runBulk(c, records, func(rec resourceRecord) (*client.Resource, error) {
+		return c.client.CreateResource(c.ctx, rec.resource())
+	})
+	return writeBulk(c, resourceColumns, created, err)
+}
+
+func runResourceUpdate(c *cli, args []string) error {
+	fs := c.flags("resources", "update")
+	file := fs.String("f", "", "Apply every change in this JSON or CSV file (- for stdin)")
+
+	var u client.ResourceUpdate
+	firstName := fs.String("first-name", "", "First name")
+	lastName := fs.String("last-name", "", "Last name")
+	position := fs.String("position", "", "Position title")
+	clearance := fs.String("clearance", "", "Clearance level")
+	fs.Var((*list)(&u.Specialties), "specialties", "Specialties (comma-separated), replacing the current ones")
+	fs.Var((*list)(&u.Certifications), "certifications", "Certifications (comma-separated), replacing the current ones")
+	active := fs.Bool("active", true, "Whether the resource is active")
+	sex := fs.String("sex", "", "Sex")
+	email := fs.String("email", "", "Email address")
+	capacity := fs.Int64("capacity", 0, "Hours a week the resource can be assigned")
+
+	pos, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 1 && *file == "":
+		id, err := parseID(pos[0])
+		if err != nil {
+			return err
+		}
+
+		set := setFlags(fs)
+		if set["first-name"] {
+			u.FirstName = firstName
+		}
+		if set["last-name"] {
+			u.LastName = lastName
+		}
+		if set["position"] {
+			u.Position = position
+		}
+		if set["clearance"] {
+			u.Clearance = clearance
+		}
+		if set["active"] {
+			u.Active = active
+		}
+		if set["sex"] {
+			u.Sex = sex
+		}
+		if set["email"] {
+			u.Email = email
+		}
+		if set["capacity"] {
+			u.CapacityHours = capacity
+		}
+
+		resource, err := c.client.UpdateResource(c.ctx, id, u)
+		if err != nil {
+			return err
+		}
+		return writeOne(c, resourceColumns, resource)
+	case len(pos) > 0 || *file == "":
+		return usageError{errors.New("resources update: give either an id or -f file")}
+	}
+
+	var records []resourceUpdateRecord
+	if err := readRecords(*file, &records); err != nil {
+		return err
+	}
+
+	updated, err := runBulk(c, records, func(rec resourceUpdateRecord) (*client.Resource, error) {
+		return c.client.UpdateResource(c.ctx, rec.ID, rec.ResourceUpdate)
+	})
+	return writeBulk(c, resourceColumns, updated, err)
+}
+
+func runResourceDeactivate(c *cli, args []string) error {
+	fs := c.flags("resources", "deactivate")
+	file := fs.String("f", "", "Deactivate every id in this JSON or CSV file (- for stdin)")
+
+	records, err := idRecords(fs, args, file)
+	if err != nil {
+		return err
+	}
+
+	inactive := false
+	updated, err := runBulk(c, records, func(rec idRecord) (*client.Resource, error) {
+		return c.client.UpdateResource(c.ctx, rec.ID, client.ResourceUpdate{Active: &inactive})
+	})
+	return writeBulk(c, resourceColumns, updated, err)
+}
+
+func runResourceDelete(c *cli, args []string) error {
+	fs := c.flags("resources", "delete")
+	file := fs.String("f", "", "Delete every id in this JSON or CSV file (- for stdin)")
+
+	records, err := idRecords(fs, args, file)
+	if err != nil {
+		return err
+	}
+
+	return runBulkDelete(c, records,
+		func(rec idRecord) string { return "resource " + formatID(rec.ID) },
+		func(rec idRecord) error { return c.client.DeleteResource(c.ctx, rec.ID) })
+}
@@ -1,8 +1,10 @@
 module github.com/vmw-pso/delivery-dashboard/back-end
 
-go 1.19
+go 1.20
 
 require (
+	github.com/graphql-go/graphql v0.8.1
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/lib/pq v1.10.7
+	gopkg.in/yaml.v3 v3.0.1
 )
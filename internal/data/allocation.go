@@ -0,0 +1,99 @@
+package data
+
+import (
+	"sort"
+	"time"
+)
+
+// AllocationConflict is a week in which a resource's assignments add up to
+// more hours than the resource has.
+type AllocationConflict struct {
+	ResourceID int64 `json:"resourceId"`
+	// Week is the Monday the week starts on.
+	Week           time.Time `json:"week"`
+	AllocatedHours int64     `json:"allocatedHours"`
+	CapacityHours  int64     `json:"capacityHours"`
+	// Assignments are those running that week.
+	Assignments []*ResourceAssignment `json:"assignments"`
+}
+
+// Overallocations checks the weeks that the changed assignments run in and
+// returns those where a resource's assignments total more hours than its
+// capacity, ordered by resource and week. An assignment runs in every
+// week that its request's dates touch, for its full hours, and only counts
+// while it does towards the request and the request has not ended.
+//
+// assignments must hold every assignment of the changed assignments'
+// resources as it would be after the change, requests every request they
+// belong to, and capacities the hours a week each of those resources has.
+func Overallocations(assignments, changed []*ResourceAssignment, requests map[int64]*ResourceRequest, capacities map[int64]int64) []*AllocationConflict {
+	running := func(a *ResourceAssignment) (*ResourceRequest, bool) {
+		rr, ok := requests[a.ResourceRequestID]
+		return rr, ok && a.Counts() && !rr.Ended()
+	}
+
+	byResource := make(map[int64][]*ResourceAssignment)
+	for _, a := range assignments {
+		if _, ok := running(a); ok {
+			byResource[a.ResourceID] = append(byResource[a.ResourceID], a)
+		}
+	}
+
+	type resourceWeek struct {
+		resourceID int64
+		week       time.Time
+	}
+
+	checked := make(map[resourceWeek]bool)
+	conflicts := []*AllocationConflict{}
+
+	for _, c := range changed {
+		rr, ok := running(c)
+		if !ok {
+			continue
+		}
+
+		for week := weekStart(rr.StartDate); !week.After(rr.EndDate); week = week.AddDate(0, 0, 7) {
+			key := resourceWeek{c.ResourceID, week}
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+
+			conflict := &AllocationConflict{ResourceID: c.ResourceID, Week: week, CapacityHours: capacities[c.ResourceID]}
+			for _, a := range byResource[c.ResourceID] {
+				other := requests[a.ResourceRequestID]
+				if other.StartDate.Before(week.AddDate(0, 0, 7)) && !other.EndDate.Before(week) {
+					conflict.AllocatedHours += a.HoursPerWeek
+					conflict.Assignments = append(conflict.Assignments, a)
+				}
+			}
+
+			if conflict.AllocatedHours > conflict.CapacityHours {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].ResourceID != conflicts[j].ResourceID {
+			return conflicts[i].ResourceID < conflicts[j].ResourceID
+		}
+		return conflicts[i].Week.Before(conflicts[j].Week)
+	})
+
+	for _, conflict := range conflicts {
+		sort.Slice(conflict.Assignments, func(i, j int) bool {
+			return conflict.Assignments[i].ResourceRequestID < conflict.Assignments[j].ResourceRequestID
+		})
+	}
+
+	return conflicts
+}
+
+// weekStart returns midnight UTC on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
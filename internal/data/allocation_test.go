@@ -0,0 +1,157 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOverallocations(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2026, 11, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// The weeks start on Mondays 2 Nov, 9 Nov, 16 Nov and 23 Nov 2026.
+	requests := map[int64]*ResourceRequest{
+		1: {ID: 1, StartDate: date(2), EndDate: date(13), Status: RequestFilled},
+		// 2 overlaps 1 in the week of 9 Nov.
+		2: {ID: 2, StartDate: date(9), EndDate: date(20), Status: RequestFilled},
+		// 3 starts the Monday after 1 ends.
+		3: {ID: 3, StartDate: date(16), EndDate: date(27), Status: RequestFilled},
+		// 4 starts the Thursday 1 ends on, in the same week.
+		4: {ID: 4, StartDate: date(12), EndDate: date(17), Status: RequestFilled},
+		5: {ID: 5, StartDate: date(2), EndDate: date(13), Status: RequestClosed},
+	}
+
+	accepted := func(requestID, resourceID, hours int64) *ResourceAssignment {
+		return &ResourceAssignment{ResourceRequestID: requestID, ResourceID: resourceID, HoursPerWeek: hours, Status: AssignmentAccepted}
+	}
+	proposed := func(requestID, resourceID, hours int64) *ResourceAssignment {
+		a := accepted(requestID, resourceID, hours)
+		a.Status = AssignmentProposed
+		return a
+	}
+	completed := func(requestID, resourceID, hours int64) *ResourceAssignment {
+		a := accepted(requestID, resourceID, hours)
+		a.Completed = true
+		return a
+	}
+
+	tests := []struct {
+		name       string
+		others     []*ResourceAssignment
+		changed    []*ResourceAssignment
+		capacities map[int64]int64
+		// want describes each conflict as "resource week allocated/capacity
+		// requests".
+		want []string
+	}{
+		{
+			name:    "within capacity",
+			others:  []*ResourceAssignment{accepted(1, 1, 20)},
+			changed: []*ResourceAssignment{accepted(2, 1, 20)},
+		},
+		{
+			name:    "overlapping week",
+			others:  []*ResourceAssignment{accepted(1, 1, 30)},
+			changed: []*ResourceAssignment{accepted(2, 1, 20)},
+			want:    []string{"1 2026-11-09 50/40 [1 2]"},
+		},
+		{
+			name:    "adjacent weeks",
+			others:  []*ResourceAssignment{accepted(1, 1, 30)},
+			changed: []*ResourceAssignment{accepted(3, 1, 30)},
+		},
+		{
+			name:    "adjacent days in one week",
+			others:  []*ResourceAssignment{accepted(1, 1, 30)},
+			changed: []*ResourceAssignment{accepted(4, 1, 20)},
+			want:    []string{"1 2026-11-09 50/40 [1 4]"},
+		},
+		{
+			name:    "only the changed assignment's weeks",
+			others:  []*ResourceAssignment{accepted(1, 1, 30), accepted(2, 1, 20)},
+			changed: []*ResourceAssignment{accepted(3, 1, 10)},
+		},
+		{
+			name:    "every week of the change",
+			others:  []*ResourceAssignment{accepted(1, 1, 30), accepted(3, 1, 30)},
+			changed: []*ResourceAssignment{accepted(2, 1, 20)},
+			want:    []string{"1 2026-11-09 50/40 [1 2]", "1 2026-11-16 50/40 [2 3]"},
+		},
+		{
+			name:    "proposals ignored",
+			others:  []*ResourceAssignment{proposed(1, 1, 30)},
+			changed: []*ResourceAssignment{accepted(2, 1, 20)},
+		},
+		{
+			name:    "changed proposal ignored",
+			others:  []*ResourceAssignment{accepted(1, 1, 30)},
+			changed: []*ResourceAssignment{proposed(2, 1, 20)},
+		},
+		{
+			name:    "completed assignments ignored",
+			others:  []*ResourceAssignment{completed(1, 1, 30)},
+			changed: []*ResourceAssignment{accepted(2, 1, 20)},
+		},
+		{
+			name:    "ended requests ignored",
+			others:  []*ResourceAssignment{accepted(5, 1, 30)},
+			changed: []*ResourceAssignment{accepted(1, 1, 20)},
+		},
+		{
+			name:       "each resource's own capacity",
+			others:     []*ResourceAssignment{accepted(1, 1, 15), accepted(1, 2, 15)},
+			changed:    []*ResourceAssignment{accepted(2, 1, 10), accepted(2, 2, 10)},
+			capacities: map[int64]int64{1: 40, 2: 20},
+			want:       []string{"2 2026-11-09 25/20 [1 2]"},
+		},
+		{
+			name:       "ordered by resource and week",
+			others:     []*ResourceAssignment{accepted(1, 1, 30), accepted(3, 1, 30), accepted(1, 2, 30)},
+			changed:    []*ResourceAssignment{accepted(2, 2, 20), accepted(2, 1, 20)},
+			capacities: map[int64]int64{1: 40, 2: 40},
+			want:       []string{"1 2026-11-09 50/40 [1 2]", "1 2026-11-16 50/40 [2 3]", "2 2026-11-09 50/40 [1 2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacities := tt.capacities
+			if capacities == nil {
+				capacities = map[int64]int64{1: 40}
+			}
+
+			assignments := append(append([]*ResourceAssignment{}, tt.changed...), tt.others...)
+
+			got := []string{}
+			for _, c := range Overallocations(assignments, tt.changed, requests, capacities) {
+				var requestIDs []int64
+				for _, a := range c.Assignments {
+					requestIDs = append(requestIDs, a.ResourceRequestID)
+				}
+				got = append(got, fmt.Sprintf("%d %s %d/%d %v", c.ResourceID, c.Week.Format("2006-01-02"), c.AllocatedHours, c.CapacityHours, requestIDs))
+			}
+
+			want := tt.want
+			if want == nil {
+				want = []string{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("conflicts %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2026, 11, 9, 0, 0, 0, 0, time.UTC)
+
+	for day := 0; day < 7; day++ {
+		in := monday.AddDate(0, 0, day).Add(15 * time.Hour)
+		if got := weekStart(in); !got.Equal(monday) {
+			t.Errorf("weekStart(%s) = %s, want %s", in.Format(time.RFC1123), got, monday)
+		}
+	}
+}
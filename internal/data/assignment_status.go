@@ -0,0 +1,184 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// The stages of an assignment's proposal. A resource manager proposes a
+// resource, the engagement lead shortlists and accepts or rejects them, and
+// the customer may interview them first; a proposal can be withdrawn at any
+// point, even once accepted.
+const (
+	AssignmentProposed          = "proposed"
+	AssignmentShortlisted       = "shortlisted"
+	AssignmentCustomerInterview = "customer-interview"
+	AssignmentAccepted          = "accepted"
+	AssignmentRejected          = "rejected"
+	AssignmentWithdrawn         = "withdrawn"
+)
+
+// AssignmentStatuses lists every assignment status in workflow order.
+var AssignmentStatuses = []string{
+	AssignmentProposed,
+	AssignmentShortlisted,
+	AssignmentCustomerInterview,
+	AssignmentAccepted,
+	AssignmentRejected,
+	AssignmentWithdrawn,
+}
+
+// assignmentTransitions lists the statuses an assignment can be moved to
+// from each status. Rejected and withdrawn assignments are final.
+var assignmentTransitions = map[string][]string{
+	AssignmentProposed:          {AssignmentShortlisted, AssignmentCustomerInterview, AssignmentAccepted, AssignmentRejected, AssignmentWithdrawn},
+	AssignmentShortlisted:       {AssignmentCustomerInterview, AssignmentAccepted, AssignmentRejected, AssignmentWithdrawn},
+	AssignmentCustomerInterview: {AssignmentAccepted, AssignmentRejected, AssignmentWithdrawn},
+	AssignmentAccepted:          {AssignmentWithdrawn},
+}
+
+// CanTransitionAssignment reports whether an assignment can be moved from
+// one status to another.
+func CanTransitionAssignment(from, to string) bool {
+	return validator.PermittedValue(to, assignmentTransitions[from]...)
+}
+
+// NextAssignmentStatuses returns the statuses an assignment in status from
+// can be moved to, or none if from is final.
+func NextAssignmentStatuses(from string) []string {
+	return append([]string{}, assignmentTransitions[from]...)
+}
+
+// AssignmentTransition records one change of an assignment's status and the
+// comment made with it.
+type AssignmentTransition struct {
+	ID                int64     `json:"id"`
+	ResourceRequestID int64     `json:"resourceRequestId"`
+	ResourceID        int64     `json:"resourceId"`
+	From              string    `json:"from"`
+	To                string    `json:"to"`
+	Comment           string    `json:"comment"`
+	Actor             string    `json:"actor,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// ValidateAssignmentTransition checks that an assignment in status from can
+// be moved to status to with the given comment.
+func ValidateAssignmentTransition(v *validator.Validator, from, to, comment string) {
+	switch {
+	case !validator.PermittedValue(to, AssignmentStatuses...):
+		v.AddError("status", "must be a valid assignment status")
+	case !CanTransitionAssignment(from, to):
+		v.AddError("status", transitionMessage(from, to, assignmentTransitions[from]))
+	}
+
+	if validator.PermittedValue(to, AssignmentRejected, AssignmentWithdrawn) {
+		v.Check(comment != "", "comment", "must be provided to reject or withdraw an assignment")
+	}
+	v.Check(len(comment) <= 1000, "comment", "must not be more than 1000 bytes")
+}
+
+// Transition moves a to status to, recording who did it and their comment,
+// and updates a's status, timestamps and version. The request's fill status
+// follows, as only accepted assignments count towards it; the request's
+// transition is returned too, or nil if its status stayed the same. It
+// returns ErrInvalidTransition if the move is not in the transition table and
+// ErrEditConflict if a has changed since it was read.
+func (m *ResourceAssignmentModel) Transition(ctx context.Context, a *ResourceAssignment, to, comment, actor string) (*AssignmentTransition, *RequestTransition, error) {
+	if !CanTransitionAssignment(a.Status, to) {
+		return nil, nil, ErrInvalidTransition
+	}
+
+	qry := `
+		WITH updated AS (
+			UPDATE resource_assignments
+			SET status = $3, status_changed_at = now(), updated_at = now(), version = version + 1
+			WHERE resource_request_id = $1 AND resource_id = $2 AND version = $4
+			RETURNING status_changed_at, updated_at, version
+		), inserted AS (
+			INSERT INTO assignment_transitions (resource_request_id, resource_id, from_status, to_status, comment, actor, created_at)
+			SELECT $1, $2, $5, $3, $6, $7, status_changed_at
+			FROM updated
+			RETURNING id
+		)
+		SELECT inserted.id, updated.status_changed_at, updated.updated_at, updated.version
+		FROM updated, inserted`
+
+	args := []interface{}{a.ResourceRequestID, a.ResourceID, to, a.Version, a.Status, comment, actor}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	t := &AssignmentTransition{
+		ResourceRequestID: a.ResourceRequestID,
+		ResourceID:        a.ResourceID,
+		From:              a.Status,
+		To:                to,
+		Comment:           comment,
+		Actor:             actor,
+	}
+
+	var moved *RequestTransition
+
+	err := inTx(ctx, m.DB, func(q Querier) error {
+		err := q.QueryRowContext(ctx, qry, args...).Scan(&t.ID, &a.StatusChangedAt, &a.UpdatedAt, &a.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return ctxError(ctx, err)
+			}
+		}
+
+		moved, err = syncFillStatus(ctx, q, a.ResourceRequestID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a.Status = to
+	t.CreatedAt = a.StatusChangedAt
+
+	return t, moved, nil
+}
+
+// GetTransitions returns the status changes of an assignment, oldest first.
+func (m *ResourceAssignmentModel) GetTransitions(ctx context.Context, requestID, resourceID int64) ([]*AssignmentTransition, error) {
+	qry := `
+		SELECT id, resource_request_id, resource_id, from_status, to_status, comment, actor, created_at
+		FROM assignment_transitions
+		WHERE resource_request_id = $1 AND resource_id = $2
+		ORDER BY id`
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, requestID, resourceID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	transitions := []*AssignmentTransition{}
+
+	for rows.Next() {
+		var t AssignmentTransition
+		err := rows.Scan(&t.ID, &t.ResourceRequestID, &t.ResourceID, &t.From, &t.To, &t.Comment, &t.Actor, &t.CreatedAt)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		transitions = append(transitions, &t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return transitions, nil
+}
@@ -0,0 +1,78 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+func TestValidateAssignmentTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		comment  string
+		errors   map[string]string
+	}{
+		{
+			name: "allowed",
+			from: AssignmentProposed, to: AssignmentShortlisted,
+		},
+		{
+			name: "rejected with a comment",
+			from: AssignmentCustomerInterview, to: AssignmentRejected, comment: "not available",
+		},
+		{
+			name: "unknown status",
+			from: AssignmentProposed, to: "hired",
+			errors: map[string]string{"status": "must be a valid assignment status"},
+		},
+		{
+			name: "not allowed",
+			from: AssignmentAccepted, to: AssignmentShortlisted,
+			errors: map[string]string{"status": "cannot change from accepted to shortlisted; it can change to withdrawn"},
+		},
+		{
+			name: "from a final status",
+			from: AssignmentRejected, to: AssignmentAccepted,
+			errors: map[string]string{"status": "cannot change from rejected to accepted; rejected is final"},
+		},
+		{
+			name: "withdrawn without a comment",
+			from: AssignmentAccepted, to: AssignmentWithdrawn,
+			errors: map[string]string{"comment": "must be provided to reject or withdraw an assignment"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateAssignmentTransition(v, tt.from, tt.to, tt.comment)
+
+			want := tt.errors
+			if want == nil {
+				want = map[string]string{}
+			}
+			if !reflect.DeepEqual(v.Errors, want) {
+				t.Errorf("errors %v, want %v", v.Errors, want)
+			}
+		})
+	}
+}
+
+func TestNextAssignmentStatuses(t *testing.T) {
+	next := NextAssignmentStatuses(AssignmentAccepted)
+	if want := []string{AssignmentWithdrawn}; !reflect.DeepEqual(next, want) {
+		t.Fatalf("next statuses %v, want %v", next, want)
+	}
+
+	// The caller owns what it is given.
+	next[0] = AssignmentProposed
+	if !CanTransitionAssignment(AssignmentAccepted, AssignmentWithdrawn) {
+		t.Error("changing the returned statuses changed the transition table")
+	}
+
+	if next := NextAssignmentStatuses(AssignmentWithdrawn); len(next) != 0 {
+		t.Errorf("final status moves to %v", next)
+	}
+}
@@ -0,0 +1,156 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheReferenceLists keeps the position and clearance lists in memory for
+// up to ttl, as they are read on nearly every page and rarely change. Writes
+// through these models, including those committed by WithTx, drop the cached
+// lists at once; ttl bounds how long writes made elsewhere, such as by
+// another instance of the API, go unseen. A ttl of zero leaves the models
+// uncached.
+//
+// It must be called before the models are copied or shared.
+func (m *Models) CacheReferenceLists(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	positions := &listCache[Position]{ttl: ttl}
+	clearances := &listCache[Clearance]{ttl: ttl}
+
+	m.Positions = &cachedPositions{PositionStore: m.Positions, cache: positions}
+	m.Clearances = &cachedClearances{ClearanceStore: m.Clearances, cache: clearances}
+
+	// The models inside a transaction are left uncached: what they read may
+	// never be committed. The lists are dropped once the transaction ends,
+	// however it ends.
+	withTx := m.withTx
+	m.withTx = func(ctx context.Context, fn func(tx *Models) error) error {
+		defer positions.invalidate()
+		defer clearances.invalidate()
+		return withTx(ctx, fn)
+	}
+}
+
+// listCache holds one list of records. Each invalidation starts a new
+// generation, and a list loaded during an earlier generation is not kept, so
+// a read racing a write cannot cache what the write replaced.
+type listCache[T any] struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	items      []T
+	loadedAt   time.Time
+	loaded     bool
+	generation uint64
+}
+
+// get returns the cached list, calling load to fill the cache when it is
+// empty or stale. Callers get their own copies of the records.
+func (c *listCache[T]) get(ctx context.Context, load func(ctx context.Context) ([]*T, error)) ([]*T, error) {
+	c.mu.Lock()
+	if c.loaded && time.Since(c.loadedAt) < c.ttl {
+		items := copyItems(c.items)
+		c.mu.Unlock()
+		return items, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	loaded, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	if loaded != nil {
+		items = make([]T, len(loaded))
+		for i, item := range loaded {
+			items[i] = *item
+		}
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.items = items
+		c.loadedAt = time.Now()
+		c.loaded = true
+	}
+	c.mu.Unlock()
+
+	return loaded, nil
+}
+
+func (c *listCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = nil
+	c.loaded = false
+	c.generation++
+}
+
+func copyItems[T any](items []T) []*T {
+	if items == nil {
+		return nil
+	}
+
+	copies := make([]*T, len(items))
+	for i := range items {
+		item := items[i]
+		copies[i] = &item
+	}
+	return copies
+}
+
+type cachedPositions struct {
+	PositionStore
+	cache *listCache[Position]
+}
+
+func (s *cachedPositions) Insert(ctx context.Context, p *Position) error {
+	defer s.cache.invalidate()
+	return s.PositionStore.Insert(ctx, p)
+}
+
+func (s *cachedPositions) Update(ctx context.Context, p Position) error {
+	defer s.cache.invalidate()
+	return s.PositionStore.Update(ctx, p)
+}
+
+func (s *cachedPositions) Delete(ctx context.Context, id int64) error {
+	defer s.cache.invalidate()
+	return s.PositionStore.Delete(ctx, id)
+}
+
+func (s *cachedPositions) GetAll(ctx context.Context) ([]*Position, error) {
+	return s.cache.get(ctx, s.PositionStore.GetAll)
+}
+
+type cachedClearances struct {
+	ClearanceStore
+	cache *listCache[Clearance]
+}
+
+func (s *cachedClearances) Insert(ctx context.Context, c *Clearance) error {
+	defer s.cache.invalidate()
+	return s.ClearanceStore.Insert(ctx, c)
+}
+
+func (s *cachedClearances) Update(ctx context.Context, c Clearance) error {
+	defer s.cache.invalidate()
+	return s.ClearanceStore.Update(ctx, c)
+}
+
+func (s *cachedClearances) Delete(ctx context.Context, id int64) error {
+	defer s.cache.invalidate()
+	return s.ClearanceStore.Delete(ctx, id)
+}
+
+func (s *cachedClearances) GetAll(ctx context.Context) ([]*Clearance, error) {
+	return s.cache.get(ctx, s.ClearanceStore.GetAll)
+}
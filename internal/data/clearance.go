@@ -3,7 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
-	"time"
+	"errors"
 
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
 )
@@ -19,33 +19,34 @@ func ValidateDescription(v *validator.Validator, description string) {
 }
 
 type ClearanceModel struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func (m *ClearanceModel) Insert(c *Clearance) error {
+func (m *ClearanceModel) Insert(ctx context.Context, c *Clearance) error {
 	qry := `
 		INSERT INTO clearances (description)
 		VALUES ($1)
 		RETURNING id`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, qry, c.Description).Scan(&c.ID)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	return nil
 }
 
-func (m *ClearanceModel) Get(id int64) (*Clearance, error) {
+func (m *ClearanceModel) Get(ctx context.Context, id int64) (*Clearance, error) {
 	qry := `
 		SELECT description
 		FROM clearances
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.read(ctx)
 	defer cancel()
 
 	row := m.DB.QueryRowContext(ctx, qry, id)
@@ -53,41 +54,59 @@ func (m *ClearanceModel) Get(id int64) (*Clearance, error) {
 	c := Clearance{ID: id}
 
 	if err := row.Scan(&c.Description); err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
 	}
 
 	return &c, nil
 }
 
-func (m *ClearanceModel) Update(c Clearance) error {
+func (m *ClearanceModel) Update(ctx context.Context, c Clearance) error {
 	qry := `
 		UPDATE clearances
 		SET description = $1
 		WHERE id = $2`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
-	_, err := m.DB.QueryContext(ctx, qry, c.Description, c.ID)
-	return err
+	result, err := m.DB.ExecContext(ctx, qry, c.Description, c.ID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
-func (m *ClearanceModel) Delete(id int64) error {
+func (m *ClearanceModel) Delete(ctx context.Context, id int64) error {
 	qry := `
 		DELETE FROM clearances
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, qry, id)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
@@ -97,18 +116,18 @@ func (m *ClearanceModel) Delete(id int64) error {
 	return nil
 }
 
-func (m *ClearanceModel) GetAll() ([]*Clearance, error) {
+func (m *ClearanceModel) GetAll(ctx context.Context) ([]*Clearance, error) {
 	qry := `
 		SELECT id, description
 		FROM clearances
 		ORDER BY description`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.list(ctx)
 	defer cancel()
 
 	rows, err := m.DB.QueryContext(ctx, qry)
 	if err != nil {
-		return nil, err
+		return nil, ctxError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -121,7 +140,7 @@ func (m *ClearanceModel) GetAll() ([]*Clearance, error) {
 			&c.Description,
 		)
 		if err != nil {
-			return nil, err
+			return nil, ctxError(ctx, err)
 		}
 		clearances = append(clearances, &c)
 	}
@@ -0,0 +1,43 @@
+package data
+
+const (
+	EventResourceCreated     = "resource.created"
+	EventResourceUpdated     = "resource.updated"
+	EventResourceDeleted     = "resource.deleted"
+	EventResourceDeactivated = "resource.deactivated"
+
+	EventRequestCreated       = "request.created"
+	EventRequestUpdated       = "request.updated"
+	EventRequestDeleted       = "request.deleted"
+	EventRequestStatusChanged = "request.status_changed"
+
+	EventAssignmentCreated       = "assignment.created"
+	EventAssignmentUpdated       = "assignment.updated"
+	EventAssignmentDeleted       = "assignment.deleted"
+	EventAssignmentStatusChanged = "assignment.status_changed"
+)
+
+// Events lists every event type published by the API.
+var Events = []string{
+	EventResourceCreated,
+	EventResourceUpdated,
+	EventResourceDeleted,
+	EventResourceDeactivated,
+	EventRequestCreated,
+	EventRequestUpdated,
+	EventRequestDeleted,
+	EventRequestStatusChanged,
+	EventAssignmentCreated,
+	EventAssignmentUpdated,
+	EventAssignmentDeleted,
+	EventAssignmentStatusChanged,
+}
+
+// WebhookEvents lists the event types that a webhook may subscribe to.
+var WebhookEvents = []string{
+	EventRequestCreated,
+	EventRequestStatusChanged,
+	EventAssignmentCreated,
+	EventAssignmentStatusChanged,
+	EventResourceDeactivated,
+}
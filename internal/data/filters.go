@@ -12,6 +12,10 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	// Fields narrows a list to the named JSON fields; empty means all of
+	// them.
+	Fields        []string
+	FieldSafelist []string
 }
 
 type Metadata struct {
@@ -28,6 +32,13 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(f.PageSize > 0, "page_size", "must be a positive integer")
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 	v.Check(validator.PermittedValue(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+
+	for _, field := range f.Fields {
+		if !validator.PermittedValue(field, f.FieldSafelist...) {
+			v.AddError("fields", "invalid field "+field)
+			break
+		}
+	}
 }
 
 func calculateMetadata(totalRecords, page, pageSize int) Metadata {
@@ -67,3 +78,43 @@ func (f Filters) limit() int {
 func (f Filters) offset() int {
 	return (f.Page - 1) * f.PageSize
 }
+
+// column is a field a list can be narrowed to, the SQL expression that
+// selects it and where a row's value is scanned into. Only expressions from
+// these tables are ever put into a query, never the field names a client
+// sends.
+type column[T any] struct {
+	field string
+	expr  string
+	dest  func(*T) any
+}
+
+// selectColumns returns the columns for f.Fields, or all of them when no
+// fields were asked for. The first column, the record's id, is always
+// selected so that lists stay ordered and related records can be found.
+func selectColumns[T any](f Filters, columns []column[T]) (string, func(*T) []any) {
+	selected := columns
+	if len(f.Fields) > 0 {
+		selected = columns[:1]
+		for _, c := range columns[1:] {
+			if validator.PermittedValue(c.field, f.Fields...) {
+				selected = append(selected[:len(selected):len(selected)], c)
+			}
+		}
+	}
+
+	exprs := make([]string, len(selected))
+	for i, c := range selected {
+		exprs[i] = c.expr
+	}
+
+	dests := func(record *T) []any {
+		d := make([]any, len(selected))
+		for i, c := range selected {
+			d[i] = c.dest(record)
+		}
+		return d
+	}
+
+	return strings.Join(exprs, ", "), dests
+}
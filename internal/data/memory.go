@@ -0,0 +1,1624 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/filter"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+// errForeignKey stands in for the foreign key violations PostgreSQL reports
+// when a write would leave a dangling reference.
+var errForeignKey = errors.New("violates foreign key constraint")
+
+// memoryStore holds every table for the in-memory models behind a single
+// lock, so lookups across tables see a consistent view.
+type memoryStore struct {
+	mu sync.RWMutex
+
+	positions  map[int64]Position
+	clearances map[int64]Clearance
+	resources  map[int64]Resource
+	requests   map[int64]ResourceRequest
+	// transitions holds every request's status changes by their id.
+	transitions map[int64]RequestTransition
+	// unfilledNotified mirrors resource_requests.unfilled_notified_at.
+	unfilledNotified map[int64]time.Time
+	assignments      map[assignmentKey]ResourceAssignment
+	// assignmentTransitions holds every assignment's status changes by
+	// their id.
+	assignmentTransitions map[int64]AssignmentTransition
+	webhooks              map[int64]Webhook
+	deliveries            map[int64]WebhookDelivery
+	views                 map[int64]View
+	visits                map[viewVisitKey]ViewVisit
+
+	nextPositionID             int64
+	nextClearanceID            int64
+	nextRequestID              int64
+	nextTransitionID           int64
+	nextAssignmentTransitionID int64
+	nextWebhookID              int64
+	nextDeliveryID             int64
+	nextViewID                 int64
+}
+
+type assignmentKey struct {
+	requestID  int64
+	resourceID int64
+}
+
+type viewVisitKey struct {
+	viewID int64
+	user   string
+}
+
+// NewMemoryModels returns models backed by process memory. Nothing is
+// persisted; it exists for tests and for running the API without a database.
+func NewMemoryModels() *Models {
+	s := &memoryStore{
+		positions:             make(map[int64]Position),
+		clearances:            make(map[int64]Clearance),
+		resources:             make(map[int64]Resource),
+		requests:              make(map[int64]ResourceRequest),
+		transitions:           make(map[int64]RequestTransition),
+		unfilledNotified:      make(map[int64]time.Time),
+		assignments:           make(map[assignmentKey]ResourceAssignment),
+		assignmentTransitions: make(map[int64]AssignmentTransition),
+		webhooks:              make(map[int64]Webhook),
+		deliveries:            make(map[int64]WebhookDelivery),
+		views:                 make(map[int64]View),
+		visits:                make(map[viewVisitKey]ViewVisit),
+	}
+
+	m := s.models()
+	m.withTx = s.withTx
+
+	return m
+}
+
+func (s *memoryStore) models() *Models {
+	return &Models{
+		Positions:           &memoryPositions{s},
+		Clearances:          &memoryClearances{s},
+		Resources:           &memoryResources{s},
+		ResourceRequests:    &memoryResourceRequests{s},
+		ResourceAssignments: &memoryResourceAssignments{s},
+		Webhooks:            &memoryWebhooks{s},
+		WebhookDeliveries:   &memoryWebhookDeliveries{s},
+		Views:               &memoryViews{s},
+	}
+}
+
+// withTx runs fn against a copy of the store and swaps the copy in if fn
+// succeeds. The store stays locked throughout, which serialises transactions
+// with every other access the way a single connection would.
+func (s *memoryStore) withTx(ctx context.Context, fn func(tx *Models) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Records are stored by value and their slices are cloned on the way in
+	// and out, so copying the maps is enough to isolate the transaction.
+	tx := &memoryStore{
+		positions:                  copyMap(s.positions),
+		clearances:                 copyMap(s.clearances),
+		resources:                  copyMap(s.resources),
+		requests:                   copyMap(s.requests),
+		transitions:                copyMap(s.transitions),
+		unfilledNotified:           copyMap(s.unfilledNotified),
+		assignments:                copyMap(s.assignments),
+		assignmentTransitions:      copyMap(s.assignmentTransitions),
+		webhooks:                   copyMap(s.webhooks),
+		deliveries:                 copyMap(s.deliveries),
+		views:                      copyMap(s.views),
+		visits:                     copyMap(s.visits),
+		nextPositionID:             s.nextPositionID,
+		nextClearanceID:            s.nextClearanceID,
+		nextRequestID:              s.nextRequestID,
+		nextTransitionID:           s.nextTransitionID,
+		nextAssignmentTransitionID: s.nextAssignmentTransitionID,
+		nextWebhookID:              s.nextWebhookID,
+		nextDeliveryID:             s.nextDeliveryID,
+		nextViewID:                 s.nextViewID,
+	}
+
+	txModels := tx.models()
+	txModels.withTx = func(_ context.Context, fn func(tx *Models) error) error {
+		return fn(txModels)
+	}
+
+	if err := fn(txModels); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	s.positions = tx.positions
+	s.clearances = tx.clearances
+	s.resources = tx.resources
+	s.requests = tx.requests
+	s.transitions = tx.transitions
+	s.unfilledNotified = tx.unfilledNotified
+	s.assignments = tx.assignments
+	s.assignmentTransitions = tx.assignmentTransitions
+	s.webhooks = tx.webhooks
+	s.deliveries = tx.deliveries
+	s.views = tx.views
+	s.visits = tx.visits
+	s.nextPositionID = tx.nextPositionID
+	s.nextClearanceID = tx.nextClearanceID
+	s.nextRequestID = tx.nextRequestID
+	s.nextTransitionID = tx.nextTransitionID
+	s.nextAssignmentTransitionID = tx.nextAssignmentTransitionID
+	s.nextWebhookID = tx.nextWebhookID
+	s.nextDeliveryID = tx.nextDeliveryID
+	s.nextViewID = tx.nextViewID
+
+	return nil
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// memoryNow returns the current time at the precision PostgreSQL stores.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesWords approximates plainto_tsquery('simple', query) against
+// to_tsvector('simple', text): every word of the query must appear as a word
+// of the text, ignoring case.
+func matchesWords(text, query string) bool {
+	split := func(s string) []string {
+		return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+	}
+	return containsAll(split(text), split(query))
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortAndPage orders records by the filter's sort column, breaking ties by
+// id, and returns the requested page with its metadata.
+func sortAndPage[T any](records []*T, filters Filters, compare func(a, b *T, column string) int, id func(*T) int64) ([]*T, Metadata) {
+	column := filters.sortColumn()
+	desc := filters.sortDirection() == "DESC"
+
+	sort.SliceStable(records, func(i, j int) bool {
+		c := compare(records[i], records[j], column)
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			return id(records[i]) < id(records[j])
+		}
+		return c < 0
+	})
+
+	metadata := calculateMetadata(len(records), filters.Page, filters.PageSize)
+
+	start := filters.offset()
+	if start > len(records) {
+		start = len(records)
+	}
+	end := start + filters.limit()
+	if end > len(records) {
+		end = len(records)
+	}
+
+	return records[start:end], metadata
+}
+
+type memoryPositions struct{ s *memoryStore }
+
+func (m *memoryPositions) Insert(ctx context.Context, p *Position) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for _, existing := range m.s.positions {
+		if existing.Title == p.Title {
+			return errors.New("duplicate position title")
+		}
+	}
+
+	m.s.nextPositionID++
+	p.ID = m.s.nextPositionID
+	m.s.positions[p.ID] = *p
+
+	return nil
+}
+
+func (m *memoryPositions) Get(ctx context.Context, id int64) (*Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	p, ok := m.s.positions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &p, nil
+}
+
+func (m *memoryPositions) Update(ctx context.Context, p Position) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.positions[p.ID]; !ok {
+		return ErrNotFound
+	}
+
+	m.s.positions[p.ID] = p
+
+	return nil
+}
+
+func (m *memoryPositions) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.positions[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.s.positions, id)
+
+	return nil
+}
+
+func (m *memoryPositions) GetAll(ctx context.Context) ([]*Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	var positions []*Position
+	for _, p := range m.s.positions {
+		p := p
+		positions = append(positions, &p)
+	}
+
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Title < positions[j].Title })
+
+	return positions, nil
+}
+
+type memoryClearances struct{ s *memoryStore }
+
+func (m *memoryClearances) Insert(ctx context.Context, c *Clearance) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	for _, existing := range m.s.clearances {
+		if existing.Description == c.Description {
+			return errors.New("duplicate clearance description")
+		}
+	}
+
+	m.s.nextClearanceID++
+	c.ID = m.s.nextClearanceID
+	m.s.clearances[c.ID] = *c
+
+	return nil
+}
+
+func (m *memoryClearances) Get(ctx context.Context, id int64) (*Clearance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	c, ok := m.s.clearances[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &c, nil
+}
+
+func (m *memoryClearances) Update(ctx context.Context, c Clearance) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.clearances[c.ID]; !ok {
+		return ErrNotFound
+	}
+
+	m.s.clearances[c.ID] = c
+
+	return nil
+}
+
+func (m *memoryClearances) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.clearances[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.s.clearances, id)
+
+	return nil
+}
+
+func (m *memoryClearances) GetAll(ctx context.Context) ([]*Clearance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	var clearances []*Clearance
+	for _, c := range m.s.clearances {
+		c := c
+		clearances = append(clearances, &c)
+	}
+
+	sort.Slice(clearances, func(i, j int) bool { return clearances[i].Description < clearances[j].Description })
+
+	return clearances, nil
+}
+
+type memoryResources struct{ s *memoryStore }
+
+func cloneResource(r Resource) *Resource {
+	r.Specialties = cloneStrings(r.Specialties)
+	r.Certifications = cloneStrings(r.Certifications)
+	return &r
+}
+
+func (m *memoryResources) Insert(ctx context.Context, r *Resource) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.resources[r.ID]; ok {
+		return errors.New("duplicate resource id")
+	}
+
+	r.UpdatedAt = memoryNow()
+	m.s.resources[r.ID] = *cloneResource(*r)
+
+	return nil
+}
+
+func (m *memoryResources) Get(ctx context.Context, id int64) (*Resource, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	r, ok := m.s.resources[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneResource(r), nil
+}
+
+func (m *memoryResources) GetByIDs(ctx context.Context, ids []int64) ([]*Resource, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	resources := []*Resource{}
+	for _, id := range ids {
+		if r, ok := m.s.resources[id]; ok {
+			resources = append(resources, cloneResource(r))
+		}
+	}
+
+	return resources, nil
+}
+
+// LockForUpdate has nothing to do: a transaction holds the store's lock
+// throughout.
+func (m *memoryResources) LockForUpdate(ctx context.Context, ids []int64) error {
+	return ctx.Err()
+}
+
+func (m *memoryResources) Update(ctx context.Context, r *Resource) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.resources[r.ID]; !ok {
+		return ErrEditConflict
+	}
+
+	r.UpdatedAt = memoryNow()
+	m.s.resources[r.ID] = *cloneResource(*r)
+
+	return nil
+}
+
+func (m *memoryResources) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.resources[id]; !ok {
+		return ErrNotFound
+	}
+
+	for key := range m.s.assignments {
+		if key.resourceID == id {
+			return errForeignKey
+		}
+	}
+
+	delete(m.s.resources, id)
+
+	return nil
+}
+
+func (m *memoryResources) GetAll(ctx context.Context, specialties []string, certifications []string, active bool, where *filter.Filter, filters Filters) ([]*Resource, Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	resources := []*Resource{}
+	for _, r := range m.s.resources {
+		if matchesResource(&r, specialties, certifications, active, where) {
+			resources = append(resources, cloneResource(r))
+		}
+	}
+
+	compare := func(a, b *Resource, column string) int {
+		switch column {
+		case "first_name":
+			return strings.Compare(a.FirstName, b.FirstName)
+		case "last_name":
+			return strings.Compare(a.LastName, b.LastName)
+		default:
+			return compareInt64(a.ID, b.ID)
+		}
+	}
+
+	page, metadata := sortAndPage(resources, filters, compare, func(r *Resource) int64 { return r.ID })
+
+	return page, metadata, nil
+}
+
+func (m *memoryResources) CountUpdatedSince(ctx context.Context, specialties []string, certifications []string, active bool, where *filter.Filter, since time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	count := 0
+	for _, r := range m.s.resources {
+		if r.UpdatedAt.After(since) && matchesResource(&r, specialties, certifications, active, where) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// matchesResource reports whether GetAll lists r for the given arguments.
+func matchesResource(r *Resource, specialties []string, certifications []string, active bool, where *filter.Filter) bool {
+	if !containsAll(r.Specialties, specialties) || !containsAll(r.Certifications, certifications) {
+		return false
+	}
+	// Matches the SQL: active=true lists everyone, active=false only the inactive.
+	if !active && r.Active {
+		return false
+	}
+	return where == nil || where.Match(resourceFilterValue(r))
+}
+
+type memoryResourceRequests struct{ s *memoryStore }
+
+func cloneResourceRequest(rr ResourceRequest) *ResourceRequest {
+	rr.Skills = cloneStrings(rr.Skills)
+	return &rr
+}
+
+func (m *memoryResourceRequests) Insert(ctx context.Context, rr *ResourceRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	now := memoryNow()
+
+	m.s.nextRequestID++
+	rr.ID = m.s.nextRequestID
+	rr.CreatedAt = now
+	rr.UpdatedAt = now
+	rr.Version = 1
+	rr.StatusChangedAt = now
+
+	m.s.requests[rr.ID] = *cloneResourceRequest(*rr)
+
+	return nil
+}
+
+func (m *memoryResourceRequests) Get(ctx context.Context, id int64) (*ResourceRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	rr, ok := m.s.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneResourceRequest(rr), nil
+}
+
+func (m *memoryResourceRequests) GetByIDs(ctx context.Context, ids []int64) ([]*ResourceRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	requests := []*ResourceRequest{}
+	for _, id := range ids {
+		if rr, ok := m.s.requests[id]; ok {
+			requests = append(requests, cloneResourceRequest(rr))
+		}
+	}
+
+	return requests, nil
+}
+
+func (m *memoryResourceRequests) Update(ctx context.Context, rr *ResourceRequest) (*RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	existing, ok := m.s.requests[rr.ID]
+	if !ok || !existing.UpdatedAt.Equal(rr.UpdatedAt) {
+		return nil, ErrEditConflict
+	}
+
+	rr.UpdatedAt = memoryNow()
+	rr.Version = existing.Version + 1
+	rr.CreatedAt = existing.CreatedAt
+	rr.OpportunityID = existing.OpportunityID
+	rr.EngagementID = existing.EngagementID
+	rr.Status = existing.Status
+	rr.StatusChangedAt = existing.StatusChangedAt
+
+	m.s.requests[rr.ID] = *cloneResourceRequest(*rr)
+
+	moved := m.s.syncFillStatus(rr.ID)
+	if moved != nil {
+		*rr = *cloneResourceRequest(m.s.requests[rr.ID])
+	}
+
+	return moved, nil
+}
+
+func (m *memoryResourceRequests) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.requests[id]; !ok {
+		return ErrNotFound
+	}
+
+	for key := range m.s.assignments {
+		if key.requestID == id {
+			return errForeignKey
+		}
+	}
+
+	delete(m.s.requests, id)
+	delete(m.s.unfilledNotified, id)
+
+	for transitionID, t := range m.s.transitions {
+		if t.RequestID == id {
+			delete(m.s.transitions, transitionID)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryResourceRequests) GetAll(ctx context.Context, customer string, skills []string, statuses []string, filters Filters) ([]*ResourceRequest, Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	requests := []*ResourceRequest{}
+	for _, rr := range m.s.requests {
+		if matchesRequest(&rr, customer, skills, statuses) {
+			requests = append(requests, cloneResourceRequest(rr))
+		}
+	}
+
+	compare := func(a, b *ResourceRequest, column string) int {
+		switch column {
+		case "customer":
+			return strings.Compare(a.Customer, b.Customer)
+		case "start_date":
+			return compareTime(a.StartDate, b.StartDate)
+		case "end_date":
+			return compareTime(a.EndDate, b.EndDate)
+		default:
+			return compareInt64(a.ID, b.ID)
+		}
+	}
+
+	page, metadata := sortAndPage(requests, filters, compare, func(rr *ResourceRequest) int64 { return rr.ID })
+
+	return page, metadata, nil
+}
+
+func (m *memoryResourceRequests) CountUpdatedSince(ctx context.Context, customer string, skills []string, statuses []string, since time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	count := 0
+	for _, rr := range m.s.requests {
+		if rr.UpdatedAt.After(since) && matchesRequest(&rr, customer, skills, statuses) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// matchesRequest reports whether GetAll lists rr for the given arguments.
+func matchesRequest(rr *ResourceRequest, customer string, skills []string, statuses []string) bool {
+	if customer != "" && !matchesWords(rr.Customer, customer) {
+		return false
+	}
+	if len(statuses) > 0 && !validator.PermittedValue(rr.Status, statuses...) {
+		return false
+	}
+	return containsAll(rr.Skills, skills)
+}
+
+func (m *memoryResourceRequests) GetUnfilledStartingBefore(ctx context.Context, before time.Time) ([]*ResourceRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	requests := []*ResourceRequest{}
+	for id, rr := range m.s.requests {
+		if rr.Status != RequestOpen {
+			continue
+		}
+		if _, notified := m.s.unfilledNotified[id]; notified {
+			continue
+		}
+		if rr.StartDate.Before(today) || rr.StartDate.After(before) {
+			continue
+		}
+		requests = append(requests, cloneResourceRequest(rr))
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		if c := compareTime(requests[i].StartDate, requests[j].StartDate); c != 0 {
+			return c < 0
+		}
+		return requests[i].ID < requests[j].ID
+	})
+
+	return requests, nil
+}
+
+func (m *memoryResourceRequests) MarkUnfilledNotified(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.requests[id]; ok {
+		m.s.unfilledNotified[id] = memoryNow()
+	}
+
+	return nil
+}
+
+func (m *memoryResourceRequests) Transition(ctx context.Context, rr *ResourceRequest, to, reason, actor string) (*RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !CanTransition(rr.Status, to) {
+		return nil, ErrInvalidTransition
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	existing, ok := m.s.requests[rr.ID]
+	if !ok || existing.Version != rr.Version {
+		return nil, ErrEditConflict
+	}
+
+	if to == RequestOpen {
+		to = fillStatus(existing.HoursPerWeek, m.s.assignedHours(rr.ID))
+	}
+
+	t := m.s.recordTransition(&existing, to, reason, actor)
+	*rr = *cloneResourceRequest(existing)
+
+	return t, nil
+}
+
+func (m *memoryResourceRequests) GetTransitions(ctx context.Context, requestID int64) ([]*RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	transitions := []*RequestTransition{}
+	for _, t := range m.s.transitions {
+		if t.RequestID == requestID {
+			t := t
+			transitions = append(transitions, &t)
+		}
+	}
+
+	sort.Slice(transitions, func(i, j int) bool { return transitions[i].ID < transitions[j].ID })
+
+	return transitions, nil
+}
+
+// recordTransition moves rr to status to, stores it and records the change.
+// The caller must hold the write lock.
+func (s *memoryStore) recordTransition(rr *ResourceRequest, to, reason, actor string) *RequestTransition {
+	now := memoryNow()
+
+	s.nextTransitionID++
+	t := RequestTransition{
+		ID:        s.nextTransitionID,
+		RequestID: rr.ID,
+		From:      rr.Status,
+		To:        to,
+		Reason:    reason,
+		Actor:     actor,
+		CreatedAt: now,
+	}
+	s.transitions[t.ID] = t
+
+	rr.Status = to
+	rr.StatusChangedAt = now
+	rr.UpdatedAt = now
+	rr.Version++
+	s.requests[rr.ID] = *cloneResourceRequest(*rr)
+
+	return &t
+}
+
+// assignedHours totals the weekly hours of a request's assignments that
+// count towards it. The caller must hold the lock.
+func (s *memoryStore) assignedHours(requestID int64) int64 {
+	var hours int64
+	for key, a := range s.assignments {
+		if key.requestID == requestID && a.Counts() {
+			hours += a.HoursPerWeek
+		}
+	}
+	return hours
+}
+
+// syncFillStatus moves a request whose status depends on its assignments to
+// the status they now give it, and returns the transition it made or nil if
+// the status stayed the same. The caller must hold the write lock.
+func (s *memoryStore) syncFillStatus(requestID int64) *RequestTransition {
+	rr, ok := s.requests[requestID]
+	if !ok || !validator.PermittedValue(rr.Status, fillStatuses...) {
+		return nil
+	}
+
+	to := fillStatus(rr.HoursPerWeek, s.assignedHours(requestID))
+	if to == rr.Status {
+		return nil
+	}
+
+	return s.recordTransition(&rr, to, fillReason, "")
+}
+
+type memoryResourceAssignments struct{ s *memoryStore }
+
+func (m *memoryResourceAssignments) Insert(ctx context.Context, a *ResourceAssignment) (*RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	key := assignmentKey{a.ResourceRequestID, a.ResourceID}
+
+	if _, ok := m.s.assignments[key]; ok {
+		return nil, ErrDuplicateAssignment
+	}
+
+	_, requestExists := m.s.requests[a.ResourceRequestID]
+	_, resourceExists := m.s.resources[a.ResourceID]
+	if !requestExists || !resourceExists {
+		return nil, errForeignKey
+	}
+
+	now := memoryNow()
+
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	a.Version = 1
+	a.Completed = false
+	a.StatusChangedAt = now
+
+	m.s.assignments[key] = *a
+
+	return m.s.syncFillStatus(a.ResourceRequestID), nil
+}
+
+func (m *memoryResourceAssignments) Get(ctx context.Context, requestID, resourceID int64) (*ResourceAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	a, ok := m.s.assignments[assignmentKey{requestID, resourceID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &a, nil
+}
+
+func (m *memoryResourceAssignments) Update(ctx context.Context, a *ResourceAssignment) (*RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	key := assignmentKey{a.ResourceRequestID, a.ResourceID}
+
+	existing, ok := m.s.assignments[key]
+	if !ok || existing.Version != a.Version {
+		return nil, ErrEditConflict
+	}
+
+	a.UpdatedAt = memoryNow()
+	a.Version++
+	a.CreatedAt = existing.CreatedAt
+	a.Status = existing.Status
+	a.StatusChangedAt = existing.StatusChangedAt
+	a.ProposedBy = existing.ProposedBy
+
+	m.s.assignments[key] = *a
+
+	return m.s.syncFillStatus(a.ResourceRequestID), nil
+}
+
+func (m *memoryResourceAssignments) Delete(ctx context.Context, requestID, resourceID int64) (*RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	key := assignmentKey{requestID, resourceID}
+
+	if _, ok := m.s.assignments[key]; !ok {
+		return nil, ErrNotFound
+	}
+
+	delete(m.s.assignments, key)
+	moved := m.s.syncFillStatus(requestID)
+
+	for transitionID, t := range m.s.assignmentTransitions {
+		if t.ResourceRequestID == requestID && t.ResourceID == resourceID {
+			delete(m.s.assignmentTransitions, transitionID)
+		}
+	}
+
+	return moved, nil
+}
+
+func (m *memoryResourceAssignments) Transition(ctx context.Context, a *ResourceAssignment, to, comment, actor string) (*AssignmentTransition, *RequestTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if !CanTransitionAssignment(a.Status, to) {
+		return nil, nil, ErrInvalidTransition
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	key := assignmentKey{a.ResourceRequestID, a.ResourceID}
+
+	existing, ok := m.s.assignments[key]
+	if !ok || existing.Version != a.Version {
+		return nil, nil, ErrEditConflict
+	}
+
+	now := memoryNow()
+
+	m.s.nextAssignmentTransitionID++
+	t := AssignmentTransition{
+		ID:                m.s.nextAssignmentTransitionID,
+		ResourceRequestID: a.ResourceRequestID,
+		ResourceID:        a.ResourceID,
+		From:              existing.Status,
+		To:                to,
+		Comment:           comment,
+		Actor:             actor,
+		CreatedAt:         now,
+	}
+	m.s.assignmentTransitions[t.ID] = t
+
+	existing.Status = to
+	existing.StatusChangedAt = now
+	existing.UpdatedAt = now
+	existing.Version++
+	m.s.assignments[key] = existing
+	moved := m.s.syncFillStatus(a.ResourceRequestID)
+
+	*a = existing
+
+	return &t, moved, nil
+}
+
+func (m *memoryResourceAssignments) GetTransitions(ctx context.Context, requestID, resourceID int64) ([]*AssignmentTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	transitions := []*AssignmentTransition{}
+	for _, t := range m.s.assignmentTransitions {
+		if t.ResourceRequestID == requestID && t.ResourceID == resourceID {
+			t := t
+			transitions = append(transitions, &t)
+		}
+	}
+
+	sort.Slice(transitions, func(i, j int) bool { return transitions[i].ID < transitions[j].ID })
+
+	return transitions, nil
+}
+
+func (m *memoryResourceAssignments) GetAllForRequest(ctx context.Context, requestID int64) ([]*ResourceAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	assignments := []*ResourceAssignment{}
+	for key, a := range m.s.assignments {
+		if key.requestID == requestID {
+			a := a
+			assignments = append(assignments, &a)
+		}
+	}
+
+	sort.Slice(assignments, func(i, j int) bool { return assignments[i].ResourceID < assignments[j].ResourceID })
+
+	return assignments, nil
+}
+
+func (m *memoryResourceAssignments) GetAllForRequests(ctx context.Context, requestIDs []int64) ([]*ResourceAssignment, error) {
+	assignments, err := m.filter(ctx, requestIDs, func(key assignmentKey) int64 { return key.requestID })
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(assignments, func(i, j int) bool {
+		if assignments[i].ResourceRequestID != assignments[j].ResourceRequestID {
+			return assignments[i].ResourceRequestID < assignments[j].ResourceRequestID
+		}
+		return assignments[i].ResourceID < assignments[j].ResourceID
+	})
+
+	return assignments, nil
+}
+
+func (m *memoryResourceAssignments) GetAllForResources(ctx context.Context, resourceIDs []int64) ([]*ResourceAssignment, error) {
+	assignments, err := m.filter(ctx, resourceIDs, func(key assignmentKey) int64 { return key.resourceID })
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(assignments, func(i, j int) bool {
+		if assignments[i].ResourceID != assignments[j].ResourceID {
+			return assignments[i].ResourceID < assignments[j].ResourceID
+		}
+		return assignments[i].ResourceRequestID < assignments[j].ResourceRequestID
+	})
+
+	return assignments, nil
+}
+
+// filter returns the assignments whose key, as picked by field, is in ids.
+func (m *memoryResourceAssignments) filter(ctx context.Context, ids []int64, field func(assignmentKey) int64) ([]*ResourceAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	assignments := []*ResourceAssignment{}
+	for key, a := range m.s.assignments {
+		if wanted[field(key)] {
+			a := a
+			assignments = append(assignments, &a)
+		}
+	}
+
+	return assignments, nil
+}
+
+type memoryWebhooks struct{ s *memoryStore }
+
+func cloneWebhook(w Webhook) *Webhook {
+	w.Events = cloneStrings(w.Events)
+	return &w
+}
+
+func (m *memoryWebhooks) Insert(ctx context.Context, w *Webhook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	now := memoryNow()
+
+	m.s.nextWebhookID++
+	w.ID = m.s.nextWebhookID
+	w.CreatedAt = now
+	w.UpdatedAt = now
+	w.Version = 1
+
+	m.s.webhooks[w.ID] = *cloneWebhook(*w)
+
+	return nil
+}
+
+func (m *memoryWebhooks) Get(ctx context.Context, id int64) (*Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	w, ok := m.s.webhooks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneWebhook(w), nil
+}
+
+func (m *memoryWebhooks) Update(ctx context.Context, w *Webhook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	existing, ok := m.s.webhooks[w.ID]
+	if !ok || existing.Version != w.Version {
+		return ErrEditConflict
+	}
+
+	w.UpdatedAt = memoryNow()
+	w.Version++
+	w.CreatedAt = existing.CreatedAt
+
+	m.s.webhooks[w.ID] = *cloneWebhook(*w)
+
+	return nil
+}
+
+func (m *memoryWebhooks) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.s.webhooks, id)
+
+	for deliveryID, d := range m.s.deliveries {
+		if d.WebhookID == id {
+			delete(m.s.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryWebhooks) GetAll(ctx context.Context) ([]*Webhook, error) {
+	return m.query(ctx, func(Webhook) bool { return true })
+}
+
+func (m *memoryWebhooks) GetAllForEvent(ctx context.Context, event string) ([]*Webhook, error) {
+	return m.query(ctx, func(w Webhook) bool {
+		return w.Active && containsAll(w.Events, []string{event})
+	})
+}
+
+func (m *memoryWebhooks) query(ctx context.Context, match func(Webhook) bool) ([]*Webhook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	webhooks := []*Webhook{}
+	for _, w := range m.s.webhooks {
+		if match(w) {
+			webhooks = append(webhooks, cloneWebhook(w))
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+
+	return webhooks, nil
+}
+
+type memoryWebhookDeliveries struct{ s *memoryStore }
+
+func cloneWebhookDelivery(d WebhookDelivery) *WebhookDelivery {
+	d.Payload = append([]byte{}, d.Payload...)
+	if d.DeliveredAt != nil {
+		deliveredAt := *d.DeliveredAt
+		d.DeliveredAt = &deliveredAt
+	}
+	return &d
+}
+
+func (m *memoryWebhookDeliveries) Insert(ctx context.Context, d *WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if d.Status == "" {
+		d.Status = DeliveryPending
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.webhooks[d.WebhookID]; !ok {
+		return errForeignKey
+	}
+
+	now := memoryNow()
+
+	m.s.nextDeliveryID++
+	d.ID = m.s.nextDeliveryID
+	d.Attempts = 0
+	d.CreatedAt = now
+	d.UpdatedAt = now
+
+	m.s.deliveries[d.ID] = *cloneWebhookDelivery(*d)
+
+	return nil
+}
+
+func (m *memoryWebhookDeliveries) Get(ctx context.Context, webhookID, id int64) (*WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	d, ok := m.s.deliveries[id]
+	if !ok || d.WebhookID != webhookID {
+		return nil, ErrNotFound
+	}
+
+	return cloneWebhookDelivery(d), nil
+}
+
+func (m *memoryWebhookDeliveries) Update(ctx context.Context, d *WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	existing, ok := m.s.deliveries[d.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	existing.Status = d.Status
+	existing.Attempts = d.Attempts
+	existing.ResponseStatus = d.ResponseStatus
+	existing.LastError = d.LastError
+	existing.DeliveredAt = d.DeliveredAt
+	existing.UpdatedAt = memoryNow()
+
+	d.UpdatedAt = existing.UpdatedAt
+	m.s.deliveries[d.ID] = *cloneWebhookDelivery(existing)
+
+	return nil
+}
+
+func (m *memoryWebhookDeliveries) GetAllForWebhook(ctx context.Context, webhookID int64, status string, filters Filters) ([]*WebhookDelivery, Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	deliveries := []*WebhookDelivery{}
+	for _, d := range m.s.deliveries {
+		if d.WebhookID == webhookID && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, cloneWebhookDelivery(d))
+		}
+	}
+
+	compare := func(a, b *WebhookDelivery, column string) int {
+		return compareInt64(a.ID, b.ID)
+	}
+
+	page, metadata := sortAndPage(deliveries, filters, compare, func(d *WebhookDelivery) int64 { return d.ID })
+
+	return page, metadata, nil
+}
+
+func (m *memoryWebhookDeliveries) GetAllUnfinished(ctx context.Context, maxAttempts int) ([]*WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	deliveries := []*WebhookDelivery{}
+	for _, d := range m.s.deliveries {
+		if d.Status == DeliveryPending || (d.Status == DeliveryFailed && d.Attempts < maxAttempts) {
+			deliveries = append(deliveries, cloneWebhookDelivery(d))
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+
+	return deliveries, nil
+}
+
+type memoryViews struct{ s *memoryStore }
+
+func cloneView(view View) *View {
+	view.SharedWith = cloneStrings(view.SharedWith)
+	if view.Query != nil {
+		view.Query = copyMap(view.Query)
+	}
+	return &view
+}
+
+// duplicateView reports whether another view has the same owner, list and
+// name, mirroring the unique constraint.
+func (m *memoryViews) duplicateView(view *View) bool {
+	for _, other := range m.s.views {
+		if other.ID != view.ID && other.Owner == view.Owner && other.List == view.List && other.Name == view.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memoryViews) Insert(ctx context.Context, view *View) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if m.duplicateView(view) {
+		return ErrDuplicateView
+	}
+
+	now := memoryNow()
+
+	m.s.nextViewID++
+	view.ID = m.s.nextViewID
+	view.CreatedAt = now
+	view.UpdatedAt = now
+	view.Version = 1
+
+	m.s.views[view.ID] = *cloneView(*view)
+
+	return nil
+}
+
+func (m *memoryViews) Get(ctx context.Context, id int64) (*View, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	view, ok := m.s.views[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneView(view), nil
+}
+
+func (m *memoryViews) Update(ctx context.Context, view *View) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	existing, ok := m.s.views[view.ID]
+	if !ok || existing.Version != view.Version {
+		return ErrEditConflict
+	}
+
+	if m.duplicateView(view) {
+		return ErrDuplicateView
+	}
+
+	view.UpdatedAt = memoryNow()
+	view.Version++
+	view.CreatedAt = existing.CreatedAt
+
+	m.s.views[view.ID] = *cloneView(*view)
+
+	return nil
+}
+
+func (m *memoryViews) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.views[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.s.views, id)
+
+	for key := range m.s.visits {
+		if key.viewID == id {
+			delete(m.s.visits, key)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryViews) GetAllForUser(ctx context.Context, user, list string) ([]*View, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	views := []*View{}
+	for _, view := range m.s.views {
+		if view.VisibleTo(user) && (list == "" || view.List == list) {
+			views = append(views, cloneView(view))
+		}
+	}
+
+	sort.Slice(views, func(i, j int) bool { return views[i].ID < views[j].ID })
+
+	return views, nil
+}
+
+func (m *memoryViews) GetVisit(ctx context.Context, viewID int64, user string) (*ViewVisit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.s.mu.RLock()
+	defer m.s.mu.RUnlock()
+
+	visit, ok := m.s.visits[viewVisitKey{viewID, user}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &visit, nil
+}
+
+func (m *memoryViews) SaveVisit(ctx context.Context, visit *ViewVisit) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+
+	if _, ok := m.s.views[visit.ViewID]; !ok {
+		return errForeignKey
+	}
+
+	visit.ViewedAt = memoryNow()
+
+	m.s.visits[viewVisitKey{visit.ViewID, visit.User}] = *visit
+
+	return nil
+}
@@ -1,8 +1,12 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/filter"
 )
 
 var (
@@ -10,18 +14,229 @@ var (
 	ErrEditConflict = errors.New("edit conflict")
 )
 
+// Querier is the part of *sql.DB and *sql.Tx the PostgreSQL models use, so
+// the same model can run inside a transaction or outside one.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Models groups the stores used by the API. NewModels backs them with
+// PostgreSQL and NewMemoryModels with in-process maps; both honour the same
+// not-found, edit-conflict and duplicate semantics.
 type Models struct {
-	Positions        PositionModel
-	Clearances       ClearanceModel
-	Resources        ResourceModel
-	ResourceRequests ResourceRequestModel
+	Positions           PositionStore
+	Clearances          ClearanceStore
+	Resources           ResourceStore
+	ResourceRequests    ResourceRequestStore
+	ResourceAssignments ResourceAssignmentStore
+	Webhooks            WebhookStore
+	WebhookDeliveries   WebhookDeliveryStore
+	Views               ViewStore
+
+	withTx func(ctx context.Context, fn func(tx *Models) error) error
+}
+
+// WithTx calls fn with models whose stores all work in one transaction. The
+// transaction commits if fn returns nil and rolls back if it returns an error
+// or panics, so none of fn's writes are seen until all of them are. Calling
+// WithTx on the models passed to fn runs in the same transaction.
+func (m *Models) WithTx(ctx context.Context, fn func(tx *Models) error) error {
+	return m.withTx(ctx, fn)
+}
+
+type PositionStore interface {
+	Insert(ctx context.Context, p *Position) error
+	Get(ctx context.Context, id int64) (*Position, error)
+	Update(ctx context.Context, p Position) error
+	Delete(ctx context.Context, id int64) error
+	GetAll(ctx context.Context) ([]*Position, error)
+}
+
+type ClearanceStore interface {
+	Insert(ctx context.Context, c *Clearance) error
+	Get(ctx context.Context, id int64) (*Clearance, error)
+	Update(ctx context.Context, c Clearance) error
+	Delete(ctx context.Context, id int64) error
+	GetAll(ctx context.Context) ([]*Clearance, error)
+}
+
+type ResourceStore interface {
+	Insert(ctx context.Context, r *Resource) error
+	Get(ctx context.Context, id int64) (*Resource, error)
+	Update(ctx context.Context, r *Resource) error
+	Delete(ctx context.Context, id int64) error
+	GetByIDs(ctx context.Context, ids []int64) ([]*Resource, error)
+	GetAll(ctx context.Context, specialties []string, certifications []string, active bool, where *filter.Filter, filters Filters) ([]*Resource, Metadata, error)
+	CountUpdatedSince(ctx context.Context, specialties []string, certifications []string, active bool, where *filter.Filter, since time.Time) (int, error)
+	LockForUpdate(ctx context.Context, ids []int64) error
+}
+
+type ResourceRequestStore interface {
+	Insert(ctx context.Context, rr *ResourceRequest) error
+	Get(ctx context.Context, id int64) (*ResourceRequest, error)
+	Update(ctx context.Context, rr *ResourceRequest) (*RequestTransition, error)
+	Delete(ctx context.Context, id int64) error
+	GetByIDs(ctx context.Context, ids []int64) ([]*ResourceRequest, error)
+	GetAll(ctx context.Context, customer string, skills []string, statuses []string, filters Filters) ([]*ResourceRequest, Metadata, error)
+	CountUpdatedSince(ctx context.Context, customer string, skills []string, statuses []string, since time.Time) (int, error)
+	GetUnfilledStartingBefore(ctx context.Context, before time.Time) ([]*ResourceRequest, error)
+	MarkUnfilledNotified(ctx context.Context, id int64) error
+	Transition(ctx context.Context, rr *ResourceRequest, to, reason, actor string) (*RequestTransition, error)
+	GetTransitions(ctx context.Context, requestID int64) ([]*RequestTransition, error)
+}
+
+// ResourceAssignmentStore writes also move the request between open,
+// partially filled and filled to match its accepted assignments.
+type ResourceAssignmentStore interface {
+	Insert(ctx context.Context, a *ResourceAssignment) (*RequestTransition, error)
+	Get(ctx context.Context, requestID, resourceID int64) (*ResourceAssignment, error)
+	Update(ctx context.Context, a *ResourceAssignment) (*RequestTransition, error)
+	Delete(ctx context.Context, requestID, resourceID int64) (*RequestTransition, error)
+	GetAllForRequest(ctx context.Context, requestID int64) ([]*ResourceAssignment, error)
+	GetAllForRequests(ctx context.Context, requestIDs []int64) ([]*ResourceAssignment, error)
+	GetAllForResources(ctx context.Context, resourceIDs []int64) ([]*ResourceAssignment, error)
+	Transition(ctx context.Context, a *ResourceAssignment, to, comment, actor string) (*AssignmentTransition, *RequestTransition, error)
+	GetTransitions(ctx context.Context, requestID, resourceID int64) ([]*AssignmentTransition, error)
+}
+
+type WebhookStore interface {
+	Insert(ctx context.Context, w *Webhook) error
+	Get(ctx context.Context, id int64) (*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
+	Delete(ctx context.Context, id int64) error
+	GetAll(ctx context.Context) ([]*Webhook, error)
+	GetAllForEvent(ctx context.Context, event string) ([]*Webhook, error)
+}
+
+type WebhookDeliveryStore interface {
+	Insert(ctx context.Context, d *WebhookDelivery) error
+	Get(ctx context.Context, webhookID, id int64) (*WebhookDelivery, error)
+	Update(ctx context.Context, d *WebhookDelivery) error
+	GetAllForWebhook(ctx context.Context, webhookID int64, status string, filters Filters) ([]*WebhookDelivery, Metadata, error)
+	GetAllUnfinished(ctx context.Context, maxAttempts int) ([]*WebhookDelivery, error)
+}
+
+type ViewStore interface {
+	Insert(ctx context.Context, view *View) error
+	Get(ctx context.Context, id int64) (*View, error)
+	Update(ctx context.Context, view *View) error
+	Delete(ctx context.Context, id int64) error
+	GetAllForUser(ctx context.Context, user, list string) ([]*View, error)
+	GetVisit(ctx context.Context, viewID int64, user string) (*ViewVisit, error)
+	SaveVisit(ctx context.Context, visit *ViewVisit) error
+}
+
+// Timeouts bounds how long each kind of database operation may run. The
+// deadline is applied on top of the caller's context, so a cancelled request
+// still aborts its queries early. A zero duration means no extra deadline.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	List  time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Read:  3 * time.Second,
+	Write: 5 * time.Second,
+	List:  5 * time.Second,
+}
+
+func (t Timeouts) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Read)
+}
+
+func (t Timeouts) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Write)
+}
+
+func (t Timeouts) list(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.List)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// ctxError returns the context's error in place of err once the context has
+// ended. The driver reports a cancelled query as an ordinary server error, so
+// this lets callers tell cancellations and timeouts apart from real failures.
+func ctxError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// inTx calls fn with a Querier whose statements all run in one transaction:
+// db itself if it is already a transaction, such as one begun by WithTx, or
+// a new one that commits if fn returns nil and rolls back otherwise.
+func inTx(ctx context.Context, db Querier, fn func(q Querier) error) error {
+	conn, ok := db.(interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	})
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return ctxError(ctx, tx.Commit())
+}
+
+func NewModels(db *sql.DB, timeouts Timeouts) *Models {
+	m := newModels(db, timeouts)
+
+	m.withTx = func(ctx context.Context, fn func(tx *Models) error) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return ctxError(ctx, err)
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				tx.Rollback()
+				panic(p)
+			}
+		}()
+
+		txModels := newModels(tx, timeouts)
+		txModels.withTx = func(_ context.Context, fn func(tx *Models) error) error {
+			return fn(txModels)
+		}
+
+		if err := fn(txModels); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return ctxError(ctx, tx.Commit())
+	}
+
+	return m
 }
 
-func NewModels(db *sql.DB) *Models {
+func newModels(db Querier, timeouts Timeouts) *Models {
 	return &Models{
-		Positions:        PositionModel{DB: db},
-		Clearances:       ClearanceModel{DB: db},
-		Resources:        ResourceModel{DB: db},
-		ResourceRequests: ResourceRequestModel{DB: db},
+		Positions:           &PositionModel{DB: db, Timeouts: timeouts},
+		Clearances:          &ClearanceModel{DB: db, Timeouts: timeouts},
+		Resources:           &ResourceModel{DB: db, Timeouts: timeouts},
+		ResourceRequests:    &ResourceRequestModel{DB: db, Timeouts: timeouts},
+		ResourceAssignments: &ResourceAssignmentModel{DB: db, Timeouts: timeouts},
+		Webhooks:            &WebhookModel{DB: db, Timeouts: timeouts},
+		WebhookDeliveries:   &WebhookDeliveryModel{DB: db, Timeouts: timeouts},
+		Views:               &ViewModel{DB: db, Timeouts: timeouts},
 	}
 }
@@ -3,7 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
-	"time"
+	"errors"
 
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
 )
@@ -19,33 +19,34 @@ func ValidateTitle(v *validator.Validator, title string) {
 }
 
 type PositionModel struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func (m *PositionModel) Insert(p *Position) error {
+func (m *PositionModel) Insert(ctx context.Context, p *Position) error {
 	qry := `
 		INSERT INTO positions (title)
 		VALUES ($1)
 		RETURNING id`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
 	err := m.DB.QueryRowContext(ctx, qry, p.Title).Scan(&p.ID)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	return nil
 }
 
-func (m *PositionModel) Get(id int64) (*Position, error) {
+func (m *PositionModel) Get(ctx context.Context, id int64) (*Position, error) {
 	qry := `
 		SELECT title
 		FROM positions
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.read(ctx)
 	defer cancel()
 
 	row := m.DB.QueryRowContext(ctx, qry, id)
@@ -53,41 +54,59 @@ func (m *PositionModel) Get(id int64) (*Position, error) {
 	p := Position{ID: id}
 
 	if err := row.Scan(&p.Title); err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
 	}
 
 	return &p, nil
 }
 
-func (m *PositionModel) Update(p Position) error {
+func (m *PositionModel) Update(ctx context.Context, p Position) error {
 	qry := `
 		UPDATE positions
 		SET title = $1
 		WHERE id = $2`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
-	_, err := m.DB.QueryContext(ctx, qry, p.Title, p.ID)
-	return err
+	result, err := m.DB.ExecContext(ctx, qry, p.Title, p.ID)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
-func (m *PositionModel) Delete(id int64) error {
+func (m *PositionModel) Delete(ctx context.Context, id int64) error {
 	qry := `
 		DELETE FROM positions
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, qry, id)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
@@ -97,18 +116,18 @@ func (m *PositionModel) Delete(id int64) error {
 	return nil
 }
 
-func (m *PositionModel) GetAll() ([]*Position, error) {
+func (m *PositionModel) GetAll(ctx context.Context) ([]*Position, error) {
 	qry := `
 		SELECT id, title
 		FROM positions
 		ORDER BY title`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.list(ctx)
 	defer cancel()
 
 	rows, err := m.DB.QueryContext(ctx, qry)
 	if err != nil {
-		return nil, err
+		return nil, ctxError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -121,7 +140,7 @@ func (m *PositionModel) GetAll() ([]*Position, error) {
 			&p.Title,
 		)
 		if err != nil {
-			return nil, err
+			return nil, ctxError(ctx, err)
 		}
 		positions = append(positions, &p)
 	}
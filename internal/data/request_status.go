@@ -0,0 +1,278 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+// The stages of a resource request's life. A request is drafted, opened,
+// filled by assignments, delivered and closed; it can be put on hold or
+// cancelled along the way.
+const (
+	RequestDraft           = "draft"
+	RequestOpen            = "open"
+	RequestPartiallyFilled = "partially_filled"
+	RequestFilled          = "filled"
+	RequestInDelivery      = "in_delivery"
+	RequestClosed          = "closed"
+	RequestCancelled       = "cancelled"
+	RequestOnHold          = "on_hold"
+)
+
+// RequestStatuses lists every request status in lifecycle order.
+var RequestStatuses = []string{
+	RequestDraft,
+	RequestOpen,
+	RequestPartiallyFilled,
+	RequestFilled,
+	RequestInDelivery,
+	RequestClosed,
+	RequestCancelled,
+	RequestOnHold,
+}
+
+// fillStatuses are the statuses a request's assignments decide between. A
+// request in one of them moves to another whenever its assignments change,
+// and never by hand.
+var fillStatuses = []string{RequestOpen, RequestPartiallyFilled, RequestFilled}
+
+// requestTransitions lists the statuses a request can be moved to by hand
+// from each status. Moving to open lands on whichever fill status the
+// request's assignments give it. Closed and cancelled requests are final.
+var requestTransitions = map[string][]string{
+	RequestDraft:           {RequestOpen, RequestCancelled},
+	RequestOpen:            {RequestOnHold, RequestCancelled},
+	RequestPartiallyFilled: {RequestOnHold, RequestCancelled},
+	RequestFilled:          {RequestInDelivery, RequestOnHold, RequestCancelled},
+	RequestInDelivery:      {RequestClosed, RequestOnHold},
+	RequestOnHold:          {RequestOpen, RequestInDelivery, RequestCancelled},
+}
+
+// CanTransition reports whether a request can be moved by hand from one
+// status to another.
+func CanTransition(from, to string) bool {
+	return validator.PermittedValue(to, requestTransitions[from]...)
+}
+
+// NextStatuses returns the statuses a request in status from can be moved to
+// by hand, or none if from is final.
+func NextStatuses(from string) []string {
+	return append([]string{}, requestTransitions[from]...)
+}
+
+// transitionMessage explains that a record cannot move from one status to
+// another, naming the statuses it can move to instead.
+func transitionMessage(from, to string, next []string) string {
+	if len(next) == 0 {
+		return fmt.Sprintf("cannot change from %s to %s; %s is final", from, to, from)
+	}
+	return fmt.Sprintf("cannot change from %s to %s; it can change to %s", from, to, strings.Join(next, ", "))
+}
+
+// fillStatus is the status of a request needing requested hours a week that
+// has assigned hours a week assigned to it.
+func fillStatus(requested, assigned int64) string {
+	switch {
+	case assigned <= 0:
+		return RequestOpen
+	case assigned < requested:
+		return RequestPartiallyFilled
+	default:
+		return RequestFilled
+	}
+}
+
+// fillStatusSQL is fillStatus for PostgreSQL, taking the requested and
+// assigned hours as SQL expressions.
+func fillStatusSQL(requested, assigned string) string {
+	return fmt.Sprintf(`CASE WHEN %[2]s <= 0 THEN '%[3]s' WHEN %[2]s < %[1]s THEN '%[4]s' ELSE '%[5]s' END`,
+		requested, assigned, RequestOpen, RequestPartiallyFilled, RequestFilled)
+}
+
+// fillReason is the reason recorded when assignments move a request.
+const fillReason = "assignments changed"
+
+// RequestTransition records one change of a request's status. Actor is the
+// user who made it, or empty when assignments moved the request.
+type RequestTransition struct {
+	ID        int64     `json:"id"`
+	RequestID int64     `json:"requestId"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ValidateTransition checks that a request in status from can be moved by
+// hand to status to for the given reason.
+func ValidateTransition(v *validator.Validator, from, to, reason string) {
+	switch {
+	case !validator.PermittedValue(to, RequestStatuses...):
+		v.AddError("status", "must be a valid request status")
+	case validator.PermittedValue(to, RequestPartiallyFilled, RequestFilled):
+		v.AddError("status", "is set from the request's assignments")
+	case !CanTransition(from, to):
+		v.AddError("status", transitionMessage(from, to, requestTransitions[from]))
+	}
+
+	if validator.PermittedValue(to, RequestOnHold, RequestCancelled) {
+		v.Check(reason != "", "reason", "must be provided to put a request on hold or cancel it")
+	}
+	v.Check(len(reason) <= 1000, "reason", "must not be more than 1000 bytes")
+}
+
+// Transition moves rr by hand to status to, recording who did it and why,
+// and updates rr's status, timestamps and version. It returns
+// ErrInvalidTransition if the move is not in the transition table and
+// ErrEditConflict if rr has changed since it was read.
+func (m *ResourceRequestModel) Transition(ctx context.Context, rr *ResourceRequest, to, reason, actor string) (*RequestTransition, error) {
+	if !CanTransition(rr.Status, to) {
+		return nil, ErrInvalidTransition
+	}
+
+	qry := fmt.Sprintf(`
+		WITH fill AS (
+			SELECT coalesce(sum(hours_per_week), 0) AS hours
+			FROM resource_assignments
+			WHERE resource_request_id = $1 AND status = '%s' AND NOT completed
+		), updated AS (
+			UPDATE resource_requests
+			SET status = CASE WHEN $2::text = '%s' THEN %s ELSE $2::text END,
+				status_changed_at = now(), updated_at = now(), version = version + 1
+			FROM fill
+			WHERE id = $1 AND version = $3
+			RETURNING status, status_changed_at, updated_at, version
+		), inserted AS (
+			INSERT INTO request_transitions (request_id, from_status, to_status, reason, actor, created_at)
+			SELECT $1, $4, status, $5, $6, status_changed_at
+			FROM updated
+			RETURNING id
+		)
+		SELECT inserted.id, updated.status, updated.status_changed_at, updated.updated_at, updated.version
+		FROM updated, inserted`, AssignmentAccepted, RequestOpen, fillStatusSQL("hours_per_week", "fill.hours"))
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	t := &RequestTransition{RequestID: rr.ID, From: rr.Status, Reason: reason, Actor: actor}
+
+	err := m.DB.QueryRowContext(ctx, qry, rr.ID, to, rr.Version, rr.Status, reason, actor).Scan(&t.ID, &rr.Status, &rr.StatusChangedAt, &rr.UpdatedAt, &rr.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	t.To = rr.Status
+	t.CreatedAt = rr.StatusChangedAt
+
+	return t, nil
+}
+
+// GetTransitions returns the status changes of a request, oldest first.
+func (m *ResourceRequestModel) GetTransitions(ctx context.Context, requestID int64) ([]*RequestTransition, error) {
+	qry := `
+		SELECT id, request_id, from_status, to_status, reason, actor, created_at
+		FROM request_transitions
+		WHERE request_id = $1
+		ORDER BY id`
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, requestID)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	transitions := []*RequestTransition{}
+
+	for rows.Next() {
+		var t RequestTransition
+		err := rows.Scan(&t.ID, &t.RequestID, &t.From, &t.To, &t.Reason, &t.Actor, &t.CreatedAt)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		transitions = append(transitions, &t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return transitions, nil
+}
+
+// syncFillStatus moves a request whose status depends on its assignments to
+// the status they now give it, recording the change, and returns the
+// transition it made or nil if the status stayed the same. If rr is not nil
+// and the status changed, rr's status, timestamps and version are updated.
+func syncFillStatus(ctx context.Context, db Querier, requestID int64, rr *ResourceRequest) (*RequestTransition, error) {
+	qry := fmt.Sprintf(`
+		WITH fill AS (
+			SELECT resource_requests.id, resource_requests.status AS from_status,
+				%s AS to_status
+			FROM resource_requests
+			LEFT JOIN resource_assignments
+				ON resource_assignments.resource_request_id = resource_requests.id
+				AND resource_assignments.status = '%s' AND NOT resource_assignments.completed
+			WHERE resource_requests.id = $1 AND resource_requests.status = ANY('{%s,%s,%s}')
+			GROUP BY resource_requests.id
+		), updated AS (
+			UPDATE resource_requests
+			SET status = fill.to_status, status_changed_at = now(), updated_at = now(), version = version + 1
+			FROM fill
+			WHERE resource_requests.id = fill.id AND fill.to_status <> fill.from_status
+			RETURNING resource_requests.id, fill.from_status, resource_requests.status,
+				resource_requests.status_changed_at, resource_requests.updated_at, resource_requests.version
+		), inserted AS (
+			INSERT INTO request_transitions (request_id, from_status, to_status, reason, created_at)
+			SELECT id, from_status, status, $2, status_changed_at
+			FROM updated
+			RETURNING id
+		)
+		SELECT inserted.id, updated.from_status, updated.status, updated.status_changed_at, updated.updated_at, updated.version
+		FROM updated, inserted`,
+		fillStatusSQL("resource_requests.hours_per_week", "coalesce(sum(resource_assignments.hours_per_week), 0)"),
+		AssignmentAccepted, RequestOpen, RequestPartiallyFilled, RequestFilled)
+
+	var synced ResourceRequest
+	t := &RequestTransition{RequestID: requestID, Reason: fillReason}
+
+	err := db.QueryRowContext(ctx, qry, requestID, fillReason).Scan(&t.ID, &t.From, &synced.Status, &synced.StatusChangedAt, &synced.UpdatedAt, &synced.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, nil
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	t.To = synced.Status
+	t.CreatedAt = synced.StatusChangedAt
+
+	if rr != nil {
+		rr.Status = synced.Status
+		rr.StatusChangedAt = synced.StatusChangedAt
+		rr.UpdatedAt = synced.UpdatedAt
+		rr.Version = synced.Version
+	}
+
+	return t, nil
+}
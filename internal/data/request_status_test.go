@@ -0,0 +1,243 @@
+package data
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{RequestDraft, RequestOpen, true},
+		{RequestDraft, RequestCancelled, true},
+		{RequestDraft, RequestOnHold, false},
+		{RequestOpen, RequestOnHold, true},
+		{RequestOpen, RequestFilled, false},
+		{RequestOpen, RequestInDelivery, false},
+		{RequestPartiallyFilled, RequestCancelled, true},
+		{RequestPartiallyFilled, RequestInDelivery, false},
+		{RequestFilled, RequestInDelivery, true},
+		{RequestFilled, RequestOpen, false},
+		{RequestInDelivery, RequestClosed, true},
+		{RequestInDelivery, RequestCancelled, false},
+		{RequestOnHold, RequestOpen, true},
+		{RequestOnHold, RequestInDelivery, true},
+		{RequestOnHold, RequestClosed, false},
+		{RequestClosed, RequestOpen, false},
+		{RequestCancelled, RequestOpen, false},
+		{"unknown", RequestOpen, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestRequestTransitions(t *testing.T) {
+	for from, targets := range requestTransitions {
+		if !validator.PermittedValue(from, RequestStatuses...) {
+			t.Errorf("transitions from unknown status %q", from)
+		}
+
+		for _, to := range targets {
+			if !validator.PermittedValue(to, RequestStatuses...) {
+				t.Errorf("%s moves to unknown status %q", from, to)
+			}
+			// Assignments alone move a request between these.
+			if validator.PermittedValue(to, RequestPartiallyFilled, RequestFilled) {
+				t.Errorf("%s moves by hand to fill status %s", from, to)
+			}
+		}
+	}
+
+	for _, final := range []string{RequestClosed, RequestCancelled} {
+		if targets := requestTransitions[final]; len(targets) > 0 {
+			t.Errorf("final status %s moves to %v", final, targets)
+		}
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		reason   string
+		errors   map[string]string
+	}{
+		{
+			name: "allowed",
+			from: RequestDraft, to: RequestOpen,
+		},
+		{
+			name: "put on hold with a reason",
+			from: RequestOpen, to: RequestOnHold, reason: "budget",
+		},
+		{
+			name: "unknown status",
+			from: RequestOpen, to: "paused",
+			errors: map[string]string{"status": "must be a valid request status"},
+		},
+		{
+			name: "fill status",
+			from: RequestOpen, to: RequestFilled,
+			errors: map[string]string{"status": "is set from the request's assignments"},
+		},
+		{
+			name: "not allowed",
+			from: RequestOpen, to: RequestInDelivery,
+			errors: map[string]string{"status": "cannot change from open to in_delivery; it can change to on_hold, cancelled"},
+		},
+		{
+			name: "from a final status",
+			from: RequestClosed, to: RequestOpen,
+			errors: map[string]string{"status": "cannot change from closed to open; closed is final"},
+		},
+		{
+			name: "put on hold without a reason",
+			from: RequestOpen, to: RequestOnHold,
+			errors: map[string]string{"reason": "must be provided to put a request on hold or cancel it"},
+		},
+		{
+			name: "cancelled without a reason",
+			from: RequestDraft, to: RequestCancelled,
+			errors: map[string]string{"reason": "must be provided to put a request on hold or cancel it"},
+		},
+		{
+			name: "reason too long",
+			from: RequestDraft, to: RequestOpen, reason: string(make([]byte, 1001)),
+			errors: map[string]string{"reason": "must not be more than 1000 bytes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateTransition(v, tt.from, tt.to, tt.reason)
+
+			want := tt.errors
+			if want == nil {
+				want = map[string]string{}
+			}
+			if !reflect.DeepEqual(v.Errors, want) {
+				t.Errorf("errors %v, want %v", v.Errors, want)
+			}
+		})
+	}
+}
+
+func TestFillStatus(t *testing.T) {
+	tests := []struct {
+		requested, assigned int64
+		want                string
+	}{
+		{40, 0, RequestOpen},
+		{40, 20, RequestPartiallyFilled},
+		{40, 39, RequestPartiallyFilled},
+		{40, 40, RequestFilled},
+		{40, 60, RequestFilled},
+	}
+
+	for _, tt := range tests {
+		if got := fillStatus(tt.requested, tt.assigned); got != tt.want {
+			t.Errorf("fillStatus(%d, %d) = %q, want %q", tt.requested, tt.assigned, got, tt.want)
+		}
+	}
+}
+
+func TestSyncFillStatus(t *testing.T) {
+	ctx := context.Background()
+	models := NewMemoryModels()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := models.Resources.Insert(ctx, &Resource{ID: id, FirstName: "Ada", LastName: "Lovelace"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	rr := &ResourceRequest{Customer: "Acme", StartDate: start, EndDate: start.AddDate(0, 0, 25), HoursPerWeek: 40, Status: RequestOpen}
+	if err := models.ResourceRequests.Insert(ctx, rr); err != nil {
+		t.Fatal(err)
+	}
+
+	// moved checks the transition a step returned and the request's
+	// status afterwards.
+	moved := func(t *testing.T, step string, got *RequestTransition, err error, from, to string) {
+		t.Helper()
+
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+
+		switch {
+		case from == "" && got != nil:
+			t.Errorf("%s moved the request from %s to %s, want no move", step, got.From, got.To)
+		case from != "" && got == nil:
+			t.Errorf("%s did not move the request, want %s to %s", step, from, to)
+		case from != "" && (got.From != from || got.To != to || got.RequestID != rr.ID || got.Reason != fillReason || got.ID == 0):
+			t.Errorf("%s made transition %+v, want %s to %s", step, got, from, to)
+		}
+
+		saved, err := models.ResourceRequests.Get(ctx, rr.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != "" && saved.Status != to {
+			t.Errorf("after %s status %s, want %s", step, saved.Status, to)
+		}
+	}
+
+	first := &ResourceAssignment{ResourceRequestID: rr.ID, ResourceID: 1, HoursPerWeek: 20, Status: AssignmentAccepted}
+	got, err := models.ResourceAssignments.Insert(ctx, first)
+	moved(t, "inserting an accepted assignment", got, err, RequestOpen, RequestPartiallyFilled)
+
+	second := &ResourceAssignment{ResourceRequestID: rr.ID, ResourceID: 2, HoursPerWeek: 20, Status: AssignmentProposed}
+	got, err = models.ResourceAssignments.Insert(ctx, second)
+	moved(t, "inserting a proposal", got, err, "", "")
+
+	_, got, err = models.ResourceAssignments.Transition(ctx, second, AssignmentAccepted, "", "alice")
+	moved(t, "accepting the proposal", got, err, RequestPartiallyFilled, RequestFilled)
+
+	first.HoursPerWeek = 10
+	got, err = models.ResourceAssignments.Update(ctx, first)
+	moved(t, "reducing hours", got, err, RequestFilled, RequestPartiallyFilled)
+
+	if rr, err = models.ResourceRequests.Get(ctx, rr.ID); err != nil {
+		t.Fatal(err)
+	}
+	rr.HoursPerWeek = 30
+	got, err = models.ResourceRequests.Update(ctx, rr)
+	moved(t, "reducing the hours requested", got, err, RequestPartiallyFilled, RequestFilled)
+	if rr.Status != RequestFilled {
+		t.Errorf("updated request status %s, want %s", rr.Status, RequestFilled)
+	}
+
+	got, err = models.ResourceAssignments.Delete(ctx, rr.ID, 2)
+	moved(t, "deleting an assignment", got, err, RequestFilled, RequestPartiallyFilled)
+
+	if rr, err = models.ResourceRequests.Get(ctx, rr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.ResourceRequests.Transition(ctx, rr, RequestOnHold, "budget", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = models.ResourceAssignments.Delete(ctx, rr.ID, 1)
+	moved(t, "deleting an assignment of a request on hold", got, err, "", "")
+
+	transitions, err := models.ResourceRequests.GetTransitions(ctx, rr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transitions) != 6 {
+		t.Errorf("recorded %d transitions, want 6", len(transitions))
+	}
+}
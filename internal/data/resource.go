@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/filter"
 	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
 )
 
@@ -43,6 +44,13 @@ type Resource struct {
 	Certifications []string `json:"certifications,omitempty"`
 	Active         bool     `json:"active"`
 	Sex            string   `json:"sex"`
+	Email          string   `json:"email,omitempty"`
+	// CapacityHours is how many hours a week the resource can be assigned
+	// before their assignments conflict.
+	CapacityHours int64 `json:"capacityHours"`
+	// UpdatedAt is when the resource last changed, kept for counting the
+	// changes in a view's results rather than shown in the API.
+	UpdatedAt time.Time `json:"-"`
 }
 
 func ValidateID(v *validator.Validator, id int) {
@@ -60,13 +68,18 @@ func ValidateLastName(v *validator.Validator, lastName string) {
 	v.Check(len(lastName) < 256, "lastName", "must not be more than 256 bytes")
 }
 
+// positions and clearances are listed from most junior to most senior, the
+// order filters compare them in.
+var (
+	positions  = []string{"Associate Consultant I", "Associate Consultant II", "Consultant", "Senior Consultant", "Staff Consultant", "Consulting Architect", "Staff Consulting Architect"}
+	clearances = []string{"None", "Baseline", "NV1", "NV2", "TSPV"}
+)
+
 func ValidatePosition(v *validator.Validator, position string) {
-	positions := []string{"Associate Consultant I", "Associate Consultant II", "Consultant", "Senior Consultant", "Staff Consultant", "Consulting Architect", "Staff Consulting Architect"}
 	v.Check(validator.PermittedValue(position, positions...), "position", "does not exist")
 }
 
 func ValidateClearance(v *validator.Validator, clearance string) {
-	clearances := []string{"None", "Baseline", "NV1", "NV2", "TSPV"}
 	v.Check(validator.PermittedValue(clearance, clearances...), "clearance", "must be one of ('None', 'Baseline', 'NV1', 'NV2')")
 }
 
@@ -75,6 +88,16 @@ func ValidateSex(v *validator.Validator, sex string) {
 	v.Check(validator.PermittedValue(sex, sexes...), "sex", "must be one of ('Unknown', 'Male', 'Female', 'Not Specified')")
 }
 
+func ValidateEmail(v *validator.Validator, email string) {
+	v.Check(email == "" || validator.Matches(email, validator.EmailRX), "email", "must be a valid email address")
+	v.Check(len(email) <= 256, "email", "must not be more than 256 bytes")
+}
+
+func ValidateCapacityHours(v *validator.Validator, hours int64) {
+	v.Check(hours > 0, "capacityHours", "must be a positive integer")
+	v.Check(hours <= 168, "capacityHours", "must not be more than 168")
+}
+
 func ValidateResource(v *validator.Validator, r Resource) {
 	ValidateID(v, int(r.ID))
 	ValidateFirstName(v, r.FirstName)
@@ -82,42 +105,45 @@ func ValidateResource(v *validator.Validator, r Resource) {
 	ValidatePosition(v, r.Position)
 	ValidateClearance(v, r.Clearance)
 	ValidateSex(v, r.Sex)
+	ValidateEmail(v, r.Email)
+	ValidateCapacityHours(v, r.CapacityHours)
 	v.Check(validator.Unique(r.Specialties), "specialties", "must not contain duplicate values")
 	v.Check(validator.Unique(r.Certifications), "certification", "must not contain duplicate values")
 }
 
 type ResourceModel struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func (m *ResourceModel) Insert(r *Resource) error {
+func (m *ResourceModel) Insert(ctx context.Context, r *Resource) error {
 	qry := `
 		INSERT INTO resources
-		(id, first_name, last_name, position_id, clearance_id, specialties, certifications, active, sex)
-		VALUES ($1, $2, $3, (SELECT id FROM positions WHERE title = $4), (SELECT id FROM clearances WHERE description = $5), $6, $7, $8, $9)
+		(id, first_name, last_name, position_id, clearance_id, specialties, certifications, active, sex, email, capacity_hours)
+		VALUES ($1, $2, $3, (SELECT id FROM positions WHERE title = $4), (SELECT id FROM clearances WHERE description = $5), $6, $7, $8, $9, $10, $11)
 		RETURNING id`
 
-	args := []interface{}{r.ID, r.FirstName, r.LastName, r.Position, r.Clearance, pq.Array(r.Specialties), pq.Array(r.Certifications), r.Active, r.Sex}
+	args := []interface{}{r.ID, r.FirstName, r.LastName, r.Position, r.Clearance, pq.Array(r.Specialties), pq.Array(r.Certifications), r.Active, r.Sex, r.Email, r.CapacityHours}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, qry, args...).Scan(&r.ID)
+	return ctxError(ctx, m.DB.QueryRowContext(ctx, qry, args...).Scan(&r.ID))
 }
 
-func (m *ResourceModel) Get(id int64) (*Resource, error) {
+func (m *ResourceModel) Get(ctx context.Context, id int64) (*Resource, error) {
 	if id < 1 {
 		return nil, ErrNotFound
 	}
 
 	qry := `
-		SELECT resources.id, resources.first_name, resources.last_name, positions.title, clearances.description, resources.specialties, resources.certifications, resources.active, resources.sex
+		SELECT resources.id, resources.first_name, resources.last_name, positions.title, clearances.description, resources.specialties, resources.certifications, resources.active, resources.sex, resources.email, resources.capacity_hours
 		FROM ((resources
 			INNER JOIN positions ON positions.id = resources.position_id)
 			INNER JOIN clearances ON clearances.id = resources.clearance_id)
 		WHERE resources.id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.read(ctx)
 	defer cancel()
 
 	var r Resource
@@ -132,24 +158,26 @@ func (m *ResourceModel) Get(id int64) (*Resource, error) {
 		pq.Array(&r.Certifications),
 		&r.Active,
 		&r.Sex,
+		&r.Email,
+		&r.CapacityHours,
 	)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrNotFound
 		default:
-			return nil, err
+			return nil, ctxError(ctx, err)
 		}
 	}
 
 	return &r, nil
 }
 
-func (m *ResourceModel) Update(r *Resource) error {
+func (m *ResourceModel) Update(ctx context.Context, r *Resource) error {
 	qry := `
 		UPDATE resources
-		SET first_name = $1, last_name = $2, position_id = (SELECT id FROM positions WHERE title = $3), clearance_id = (SELECT id FROM clearances WHERE description = $4), specialties = $5, certifications = $6, active = $7, sex = $8
-		WHERE id = $9`
+		SET first_name = $1, last_name = $2, position_id = (SELECT id FROM positions WHERE title = $3), clearance_id = (SELECT id FROM clearances WHERE description = $4), specialties = $5, certifications = $6, active = $7, sex = $8, email = $9, capacity_hours = $10, updated_at = now()
+		WHERE id = $11`
 
 	args := []interface{}{
 		r.FirstName,
@@ -160,25 +188,32 @@ func (m *ResourceModel) Update(r *Resource) error {
 		pq.Array(r.Certifications),
 		r.Active,
 		r.Sex,
+		r.Email,
+		r.CapacityHours,
 		r.ID,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, qry, args...).Scan()
+	result, err := m.DB.ExecContext(ctx, qry, args...)
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return ErrEditConflict
-		default:
-			return err
-		}
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
 	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
 	return nil
 }
 
-func (m *ResourceModel) Delete(id int64) error {
+func (m *ResourceModel) Delete(ctx context.Context, id int64) error {
 	if id < 1 {
 		return ErrNotFound
 	}
@@ -187,17 +222,17 @@ func (m *ResourceModel) Delete(id int64) error {
 		DELETE FROM resources
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, qry, id)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
@@ -207,36 +242,30 @@ func (m *ResourceModel) Delete(id int64) error {
 	return nil
 }
 
-func (m *ResourceModel) GetAll(specialties []string, certifications []string, active bool, filters Filters) ([]*Resource, Metadata, error) {
-	qry := fmt.Sprintf(`
-		SELECT count(*) OVER(), resources.id, resources.first_name, resources.last_name, positions.title, clearances.description, resources.specialties, resources.certifications, resources.active, resources.sex
+// GetByIDs returns the resources with the given ids in no particular order.
+// Ids that do not exist are skipped.
+func (m *ResourceModel) GetByIDs(ctx context.Context, ids []int64) ([]*Resource, error) {
+	qry := `
+		SELECT resources.id, resources.first_name, resources.last_name, positions.title, clearances.description, resources.specialties, resources.certifications, resources.active, resources.sex, resources.email, resources.capacity_hours
 		FROM ((resources
 			INNER JOIN positions ON positions.id = resources.position_id)
 			INNER JOIN clearances ON clearances.id = resources.clearance_id)
-		WHERE (specialties @> $1 OR $1 = '{}')
-		AND (certifications @> $2 OR $2 = '{}')
-		AND (active = $3 OR $3 = true)
-		ORDER BY %s %s, id ASC
-		LIMIT $4 OFFSET $5`, filters.sortColumn(), filters.sortDirection())
+		WHERE resources.id = ANY($1)`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.Timeouts.list(ctx)
 	defer cancel()
 
-	args := []interface{}{pq.Array(specialties), pq.Array(certifications), active, filters.limit(), filters.offset()}
-
-	rows, err := m.DB.QueryContext(ctx, qry, args...)
+	rows, err := m.DB.QueryContext(ctx, qry, pq.Array(ids))
 	if err != nil {
-		return nil, Metadata{}, err
+		return nil, ctxError(ctx, err)
 	}
 	defer rows.Close()
 
-	totalRecords := 0
 	resources := []*Resource{}
 
 	for rows.Next() {
 		var resource Resource
 		err := rows.Scan(
-			&totalRecords,
 			&resource.ID,
 			&resource.FirstName,
 			&resource.LastName,
@@ -246,18 +275,201 @@ func (m *ResourceModel) GetAll(specialties []string, certifications []string, ac
 			pq.Array(&resource.Certifications),
 			&resource.Active,
 			&resource.Sex,
+			&resource.Email,
+			&resource.CapacityHours,
 		)
 		if err != nil {
-			return nil, Metadata{}, err
+			return nil, ctxError(ctx, err)
 		}
 		resources = append(resources, &resource)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, Metadata{}, err
+		return nil, ctxError(ctx, err)
+	}
+
+	return resources, nil
+}
+
+// LockForUpdate locks the resources with the given ids until the transaction
+// the model runs in ends, so that transactions checking and changing their
+// assignments run one after another. Ids that do not exist are skipped.
+func (m *ResourceModel) LockForUpdate(ctx context.Context, ids []int64) error {
+	qry := `
+		SELECT id
+		FROM resources
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE`
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, pq.Array(ids))
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+
+	return ctxError(ctx, rows.Err())
+}
+
+// resourceColumns are the fields GetAll can narrow a resource list to.
+var resourceColumns = []column[Resource]{
+	{"resourceId", "resources.id", func(r *Resource) any { return &r.ID }},
+	{"firstName", "resources.first_name", func(r *Resource) any { return &r.FirstName }},
+	{"lastName", "resources.last_name", func(r *Resource) any { return &r.LastName }},
+	{"position", "positions.title", func(r *Resource) any { return &r.Position }},
+	{"clearance", "clearances.description", func(r *Resource) any { return &r.Clearance }},
+	{"specialties", "resources.specialties", func(r *Resource) any { return pq.Array(&r.Specialties) }},
+	{"certifications", "resources.certifications", func(r *Resource) any { return pq.Array(&r.Certifications) }},
+	{"active", "resources.active", func(r *Resource) any { return &r.Active }},
+	{"sex", "resources.sex", func(r *Resource) any { return &r.Sex }},
+	{"email", "resources.email", func(r *Resource) any { return &r.Email }},
+	{"capacityHours", "resources.capacity_hours", func(r *Resource) any { return &r.CapacityHours }},
+}
+
+// resourceFilterSchema holds the fields a resource filter can test.
+var resourceFilterSchema = filter.Schema{
+	"resourceId":     {Kind: filter.Int, Column: "resources.id"},
+	"firstName":      {Kind: filter.String, Column: "resources.first_name"},
+	"lastName":       {Kind: filter.String, Column: "resources.last_name"},
+	"position":       {Kind: filter.Enum, Column: "positions.title", Values: positions},
+	"clearance":      {Kind: filter.Enum, Column: "clearances.description", Values: clearances},
+	"specialties":    {Kind: filter.Set, Column: "resources.specialties"},
+	"certifications": {Kind: filter.Set, Column: "resources.certifications"},
+	"active":         {Kind: filter.Bool, Column: "resources.active"},
+	"sex":            {Kind: filter.String, Column: "resources.sex"},
+	"email":          {Kind: filter.String, Column: "resources.email"},
+}
+
+// ParseResourceFilter parses a filter expression over resources, such as
+// "clearance >= NV1 and specialties any of (NSX, vSAN)". A *filter.Error
+// gives the position of the token that could not be used.
+func ParseResourceFilter(src string) (*filter.Filter, error) {
+	return filter.Parse(src, resourceFilterSchema)
+}
+
+// resourceFilterValue gives a filter r's value for each field.
+func resourceFilterValue(r *Resource) func(field string) any {
+	return func(field string) any {
+		switch field {
+		case "resourceId":
+			return r.ID
+		case "firstName":
+			return r.FirstName
+		case "lastName":
+			return r.LastName
+		case "position":
+			return r.Position
+		case "clearance":
+			return r.Clearance
+		case "specialties":
+			return r.Specialties
+		case "certifications":
+			return r.Certifications
+		case "active":
+			return r.Active
+		case "sex":
+			return r.Sex
+		case "email":
+			return r.Email
+		default:
+			return nil
+		}
+	}
+}
+
+// GetAll returns a page of resources. where, when not nil, further narrows
+// the list. When filters.Fields is set only those columns, and the id, are
+// selected; the rest are left zero.
+func (m *ResourceModel) GetAll(ctx context.Context, specialties []string, certifications []string, active bool, where *filter.Filter, filters Filters) ([]*Resource, Metadata, error) {
+	columns, dests := selectColumns(filters, resourceColumns)
+
+	condition, whereArgs := "true", []any(nil)
+	if where != nil {
+		condition, whereArgs = where.SQL(6)
+	}
+
+	qry := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM ((resources
+			INNER JOIN positions ON positions.id = resources.position_id)
+			INNER JOIN clearances ON clearances.id = resources.clearance_id)
+		WHERE (specialties @> $1 OR $1 = '{}')
+		AND (certifications @> $2 OR $2 = '{}')
+		AND (active = $3 OR $3 = true)
+		AND %s
+		ORDER BY %s %s, id ASC
+		LIMIT $4 OFFSET $5`, columns, condition, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	args := []interface{}{pq.Array(specialties), pq.Array(certifications), active, filters.limit(), filters.offset()}
+	args = append(args, whereArgs...)
+
+	rows, err := m.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, Metadata{}, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	resources := []*Resource{}
+
+	for rows.Next() {
+		var resource Resource
+		err := rows.Scan(append([]any{&totalRecords}, dests(&resource)...)...)
+		if err != nil {
+			return nil, Metadata{}, ctxError(ctx, err)
+		}
+		resources = append(resources, &resource)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, ctxError(ctx, err)
 	}
 
 	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	return resources, metadata, nil
 }
+
+// CountUpdatedSince counts the resources GetAll would list for the same
+// arguments that have changed since the given time.
+func (m *ResourceModel) CountUpdatedSince(ctx context.Context, specialties []string, certifications []string, active bool, where *filter.Filter, since time.Time) (int, error) {
+	condition, whereArgs := "true", []any(nil)
+	if where != nil {
+		condition, whereArgs = where.SQL(5)
+	}
+
+	qry := fmt.Sprintf(`
+		SELECT count(*)
+		FROM ((resources
+			INNER JOIN positions ON positions.id = resources.position_id)
+			INNER JOIN clearances ON clearances.id = resources.clearance_id)
+		WHERE (specialties @> $1 OR $1 = '{}')
+		AND (certifications @> $2 OR $2 = '{}')
+		AND (active = $3 OR $3 = true)
+		AND resources.updated_at > $4
+		AND %s`, condition)
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	args := []interface{}{pq.Array(specialties), pq.Array(certifications), active, since}
+	args = append(args, whereArgs...)
+
+	var count int
+
+	err := m.DB.QueryRowContext(ctx, qry, args...).Scan(&count)
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return count, nil
+}
@@ -1,6 +1,18 @@
 package data
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+var (
+	ErrDuplicateAssignment = errors.New("duplicate assignment")
+)
 
 type ResourceAssignment struct {
 	ResourceRequestID int64     `json:"resourceRequestId"`
@@ -10,4 +22,250 @@ type ResourceAssignment struct {
 	UpdatedAt         time.Time `json:"updatedAt"`
 	Version           int64     `json:"version"`
 	Completed         bool      `json:"completed"`
+	Status            string    `json:"status"`
+	StatusChangedAt   time.Time `json:"statusChangedAt"`
+	// ProposedBy is the user who proposed the resource, or empty when the
+	// assignment was made anonymously.
+	ProposedBy string `json:"proposedBy,omitempty"`
+}
+
+// Counts reports whether the assignment counts towards its request's hours
+// and its resource's capacity: it has been accepted and is not completed.
+func (a *ResourceAssignment) Counts() bool {
+	return a.Status == AssignmentAccepted && !a.Completed
+}
+
+func ValidateResourceAssignment(v *validator.Validator, a ResourceAssignment) {
+	v.Check(a.ResourceID > 0, "resourceId", "must be provided")
+	ValidateHoursPerWeek(v, a.HoursPerWeek)
+	v.Check(validator.PermittedValue(a.Status, AssignmentStatuses...), "status", "must be a valid assignment status")
+}
+
+type ResourceAssignmentModel struct {
+	DB       Querier
+	Timeouts Timeouts
+}
+
+// Insert adds a and returns the transition its request made as a result, or
+// nil if the request's status stayed the same.
+func (m *ResourceAssignmentModel) Insert(ctx context.Context, a *ResourceAssignment) (*RequestTransition, error) {
+	qry := `
+		INSERT INTO resource_assignments (resource_request_id, resource_id, hours_per_week, status, proposed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at, version, completed, status_changed_at`
+
+	args := []interface{}{a.ResourceRequestID, a.ResourceID, a.HoursPerWeek, a.Status, a.ProposedBy}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	var moved *RequestTransition
+
+	err := inTx(ctx, m.DB, func(q Querier) error {
+		err := q.QueryRowContext(ctx, qry, args...).Scan(&a.CreatedAt, &a.UpdatedAt, &a.Version, &a.Completed, &a.StatusChangedAt)
+		if err != nil {
+			var pqErr *pq.Error
+			switch {
+			case errors.As(err, &pqErr) && pqErr.Code == "23505":
+				return ErrDuplicateAssignment
+			default:
+				return ctxError(ctx, err)
+			}
+		}
+
+		moved, err = syncFillStatus(ctx, q, a.ResourceRequestID, nil)
+		return err
+	})
+
+	return moved, err
+}
+
+func (m *ResourceAssignmentModel) Get(ctx context.Context, requestID, resourceID int64) (*ResourceAssignment, error) {
+	if requestID < 1 || resourceID < 1 {
+		return nil, ErrNotFound
+	}
+
+	qry := `
+		SELECT resource_request_id, resource_id, hours_per_week, created_at, updated_at, version, completed, status, status_changed_at, proposed_by
+		FROM resource_assignments
+		WHERE resource_request_id = $1 AND resource_id = $2`
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	var a ResourceAssignment
+
+	err := m.DB.QueryRowContext(ctx, qry, requestID, resourceID).Scan(
+		&a.ResourceRequestID,
+		&a.ResourceID,
+		&a.HoursPerWeek,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+		&a.Version,
+		&a.Completed,
+		&a.Status,
+		&a.StatusChangedAt,
+		&a.ProposedBy,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	return &a, nil
+}
+
+// Update saves a and returns the transition its request made as a result, or
+// nil if the request's status stayed the same.
+func (m *ResourceAssignmentModel) Update(ctx context.Context, a *ResourceAssignment) (*RequestTransition, error) {
+	qry := `
+		UPDATE resource_assignments
+		SET hours_per_week = $1, completed = $2, updated_at = now(), version = version + 1
+		WHERE resource_request_id = $3 AND resource_id = $4 AND version = $5
+		RETURNING updated_at, version`
+
+	args := []interface{}{
+		a.HoursPerWeek,
+		a.Completed,
+		a.ResourceRequestID,
+		a.ResourceID,
+		a.Version,
+	}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	var moved *RequestTransition
+
+	err := inTx(ctx, m.DB, func(q Querier) error {
+		err := q.QueryRowContext(ctx, qry, args...).Scan(&a.UpdatedAt, &a.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return ctxError(ctx, err)
+			}
+		}
+
+		moved, err = syncFillStatus(ctx, q, a.ResourceRequestID, nil)
+		return err
+	})
+
+	return moved, err
+}
+
+// Delete removes an assignment and returns the transition its request made
+// as a result, or nil if the request's status stayed the same.
+func (m *ResourceAssignmentModel) Delete(ctx context.Context, requestID, resourceID int64) (*RequestTransition, error) {
+	if requestID < 1 || resourceID < 1 {
+		return nil, ErrNotFound
+	}
+
+	qry := `
+		DELETE FROM resource_assignments
+		WHERE resource_request_id = $1 AND resource_id = $2`
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	var moved *RequestTransition
+
+	err := inTx(ctx, m.DB, func(q Querier) error {
+		result, err := q.ExecContext(ctx, qry, requestID, resourceID)
+		if err != nil {
+			return ctxError(ctx, err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return ctxError(ctx, err)
+		}
+
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		moved, err = syncFillStatus(ctx, q, requestID, nil)
+		return err
+	})
+
+	return moved, err
+}
+
+func (m *ResourceAssignmentModel) GetAllForRequest(ctx context.Context, requestID int64) ([]*ResourceAssignment, error) {
+	qry := `
+		SELECT resource_request_id, resource_id, hours_per_week, created_at, updated_at, version, completed, status, status_changed_at, proposed_by
+		FROM resource_assignments
+		WHERE resource_request_id = $1
+		ORDER BY resource_id`
+
+	return m.list(ctx, qry, requestID)
+}
+
+// GetAllForRequests returns the assignments of every request in requestIDs,
+// ordered by request and then resource.
+func (m *ResourceAssignmentModel) GetAllForRequests(ctx context.Context, requestIDs []int64) ([]*ResourceAssignment, error) {
+	qry := `
+		SELECT resource_request_id, resource_id, hours_per_week, created_at, updated_at, version, completed, status, status_changed_at, proposed_by
+		FROM resource_assignments
+		WHERE resource_request_id = ANY($1)
+		ORDER BY resource_request_id, resource_id`
+
+	return m.list(ctx, qry, pq.Array(requestIDs))
+}
+
+// GetAllForResources returns the assignments of every resource in
+// resourceIDs, ordered by resource and then request.
+func (m *ResourceAssignmentModel) GetAllForResources(ctx context.Context, resourceIDs []int64) ([]*ResourceAssignment, error) {
+	qry := `
+		SELECT resource_request_id, resource_id, hours_per_week, created_at, updated_at, version, completed, status, status_changed_at, proposed_by
+		FROM resource_assignments
+		WHERE resource_id = ANY($1)
+		ORDER BY resource_id, resource_request_id`
+
+	return m.list(ctx, qry, pq.Array(resourceIDs))
+}
+
+func (m *ResourceAssignmentModel) list(ctx context.Context, qry string, args ...any) ([]*ResourceAssignment, error) {
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	assignments := []*ResourceAssignment{}
+
+	for rows.Next() {
+		var a ResourceAssignment
+		err := rows.Scan(
+			&a.ResourceRequestID,
+			&a.ResourceID,
+			&a.HoursPerWeek,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+			&a.Version,
+			&a.Completed,
+			&a.Status,
+			&a.StatusChangedAt,
+			&a.ProposedBy,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		assignments = append(assignments, &a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return assignments, nil
 }
@@ -23,7 +23,21 @@ type ResourceRequest struct {
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
 	Version       int64     `json:"version"`
-	Closed        bool      `json:"closed"`
+	// Status is where the request is in its lifecycle. It only changes
+	// through Transition or, between open and filled, with its assignments.
+	Status          string    `json:"status"`
+	StatusChangedAt time.Time `json:"statusChangedAt"`
+}
+
+// Ended reports whether the request has been closed or cancelled.
+func (rr *ResourceRequest) Ended() bool {
+	return rr.Status == RequestClosed || rr.Status == RequestCancelled
+}
+
+// Staffing reports whether the request is being filled, the only time
+// resources can be assigned to it.
+func (rr *ResourceRequest) Staffing() bool {
+	return !rr.Ended() && validator.PermittedValue(rr.Status, fillStatuses...)
 }
 
 func ValidateCustomer(v *validator.Validator, customer string) {
@@ -34,21 +48,37 @@ func ValidateSkills(v *validator.Validator, skills []string) {
 	v.Check(len(skills) > 0, "skills", "at least one must be provided")
 }
 
+func ValidateHoursPerWeek(v *validator.Validator, hours int64) {
+	v.Check(hours > 0, "hoursPerWeek", "must be a positive integer")
+	v.Check(hours <= 168, "hoursPerWeek", "must not be more than 168")
+}
+
+// MaxRequestYears bounds how long a request can run, which also bounds the
+// weeks an allocation check walks through.
+const MaxRequestYears = 5
+
 func ValidateResourceRequest(v *validator.Validator, rr ResourceRequest) {
 	ValidateCustomer(v, rr.Customer)
 	ValidateSkills(v, rr.Skills)
+	ValidateHoursPerWeek(v, rr.HoursPerWeek)
+	v.Check(!rr.StartDate.IsZero(), "startDate", "must be provided")
+	v.Check(!rr.EndDate.IsZero(), "endDate", "must be provided")
+	v.Check(!rr.EndDate.Before(rr.StartDate), "endDate", "must not be before startDate")
+	v.Check(!rr.EndDate.After(rr.StartDate.AddDate(MaxRequestYears, 0, 0)), "endDate", fmt.Sprintf("must not be more than %d years after startDate", MaxRequestYears))
 	v.Check(validator.Unique(rr.Skills), "skills", "must not contain duplicate values")
+	v.Check(validator.PermittedValue(rr.Status, RequestStatuses...), "status", "must be a valid request status")
 }
 
 type ResourceRequestModel struct {
-	DB *sql.DB
+	DB       Querier
+	Timeouts Timeouts
 }
 
-func (m *ResourceRequestModel) Insert(rr *ResourceRequest) error {
+func (m *ResourceRequestModel) Insert(ctx context.Context, rr *ResourceRequest) error {
 	qry := `
-		INSERT INTO resource_requests(customer, start_date, end_date, hours_per_week, skills, opportunity_id, engagement_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at, updated_at, version, closed`
+		INSERT INTO resource_requests(customer, start_date, end_date, hours_per_week, skills, opportunity_id, engagement_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at, version, status_changed_at`
 
 	args := []interface{}{
 		rr.Customer,
@@ -58,25 +88,26 @@ func (m *ResourceRequestModel) Insert(rr *ResourceRequest) error {
 		pq.Array(rr.Skills),
 		rr.OpportunityID,
 		rr.EngagementID,
+		rr.Status,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, qry, args...).Scan(&rr.ID, &rr.CreatedAt, &rr.UpdatedAt, &rr.Version, &rr.Closed)
+	return ctxError(ctx, m.DB.QueryRowContext(ctx, qry, args...).Scan(&rr.ID, &rr.CreatedAt, &rr.UpdatedAt, &rr.Version, &rr.StatusChangedAt))
 }
 
-func (m *ResourceRequestModel) Get(id int64) (*ResourceRequest, error) {
+func (m *ResourceRequestModel) Get(ctx context.Context, id int64) (*ResourceRequest, error) {
 	if id < 1 {
 		return nil, ErrNotFound
 	}
 
 	qry := `
-		SELECT id, customer, start_date, end_data, hours_per_week, skills, opportunity_id, engagement_id, created_at, updated_at, version, closed
+		SELECT id, customer, start_date, end_date, hours_per_week, skills, opportunity_id, engagement_id, created_at, updated_at, version, status, status_changed_at
 		FROM resource_requests
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := m.Timeouts.read(ctx)
 	defer cancel()
 
 	var rr ResourceRequest
@@ -93,25 +124,28 @@ func (m *ResourceRequestModel) Get(id int64) (*ResourceRequest, error) {
 		&rr.CreatedAt,
 		&rr.UpdatedAt,
 		&rr.Version,
-		&rr.Closed,
+		&rr.Status,
+		&rr.StatusChangedAt,
 	)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrNotFound
 		default:
-			return nil, err
+			return nil, ctxError(ctx, err)
 		}
 	}
 	return &rr, nil
 }
 
-func (m *ResourceRequestModel) Update(rr *ResourceRequest) error {
+// Update saves rr and returns the transition it made if the new hours moved
+// it to another fill status, or nil if its status stayed the same.
+func (m *ResourceRequestModel) Update(ctx context.Context, rr *ResourceRequest) (*RequestTransition, error) {
 	qry := `
 		UPDATE resource_requests
-		SET customer=$1, start_date=$2, end_date=$3, hours_per_week=$4, skills=$5, updated_at=$6, version=version+1, closed=$7
-		WHERE id=$8 AND updated_at=$9
-		RETURNING updated_at`
+		SET customer=$1, start_date=$2, end_date=$3, hours_per_week=$4, skills=$5, updated_at=$6, version=version+1
+		WHERE id=$7 AND updated_at=$8
+		RETURNING updated_at, version`
 
 	args := []interface{}{
 		rr.Customer,
@@ -120,27 +154,35 @@ func (m *ResourceRequestModel) Update(rr *ResourceRequest) error {
 		rr.HoursPerWeek,
 		pq.Array(rr.Skills),
 		time.Now(),
-		rr.Closed,
 		rr.ID,
 		rr.UpdatedAt,
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, qry, args...).Scan(&rr.UpdatedAt)
-	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return ErrEditConflict
-		default:
-			return err
+	var moved *RequestTransition
+
+	err := inTx(ctx, m.DB, func(q Querier) error {
+		err := q.QueryRowContext(ctx, qry, args...).Scan(&rr.UpdatedAt, &rr.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrEditConflict
+			default:
+				return ctxError(ctx, err)
+			}
 		}
-	}
 
-	return nil
+		// The hours needed may have changed, and with them how filled the
+		// request is.
+		moved, err = syncFillStatus(ctx, q, rr.ID, rr)
+		return err
+	})
+
+	return moved, err
 }
 
-func (m *ResourceRequestModel) Delete(id int64) error {
+func (m *ResourceRequestModel) Delete(ctx context.Context, id int64) error {
 	if id < 1 {
 		return ErrNotFound
 	}
@@ -149,17 +191,17 @@ func (m *ResourceRequestModel) Delete(id int64) error {
 		DELETE FROM resource_requests
 		WHERE id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.Timeouts.write(ctx)
 	defer cancel()
 
 	result, err := m.DB.ExecContext(ctx, qry, id)
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return ctxError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
@@ -169,57 +211,205 @@ func (m *ResourceRequestModel) Delete(id int64) error {
 	return nil
 }
 
-func (m *ResourceRequestModel) GetAll(customer string, skills []string, closed bool, filters Filters) ([]*ResourceRequest, Metadata, error) {
+// GetByIDs returns the resource requests with the given ids in no particular
+// order. Ids that do not exist are skipped.
+func (m *ResourceRequestModel) GetByIDs(ctx context.Context, ids []int64) ([]*ResourceRequest, error) {
+	qry := `
+		SELECT id, customer, start_date, end_date, hours_per_week, skills, opportunity_id, engagement_id, created_at, updated_at, version, status, status_changed_at
+		FROM resource_requests
+		WHERE id = ANY($1)`
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, pq.Array(ids))
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	requests := []*ResourceRequest{}
+
+	for rows.Next() {
+		var rr ResourceRequest
+		err := rows.Scan(
+			&rr.ID,
+			&rr.Customer,
+			&rr.StartDate,
+			&rr.EndDate,
+			&rr.HoursPerWeek,
+			pq.Array(&rr.Skills),
+			&rr.OpportunityID,
+			&rr.EngagementID,
+			&rr.CreatedAt,
+			&rr.UpdatedAt,
+			&rr.Version,
+			&rr.Status,
+			&rr.StatusChangedAt,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		requests = append(requests, &rr)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return requests, nil
+}
+
+// resourceRequestColumns are the fields GetAll can narrow a request list to.
+var resourceRequestColumns = []column[ResourceRequest]{
+	{"id", "id", func(rr *ResourceRequest) any { return &rr.ID }},
+	{"customer", "customer", func(rr *ResourceRequest) any { return &rr.Customer }},
+	{"startDate", "start_date", func(rr *ResourceRequest) any { return &rr.StartDate }},
+	{"endDate", "end_date", func(rr *ResourceRequest) any { return &rr.EndDate }},
+	{"hoursPerWeek", "hours_per_week", func(rr *ResourceRequest) any { return &rr.HoursPerWeek }},
+	{"skills", "skills", func(rr *ResourceRequest) any { return pq.Array(&rr.Skills) }},
+	{"projectID", "opportunity_id", func(rr *ResourceRequest) any { return &rr.OpportunityID }},
+	{"engagementID", "engagement_id", func(rr *ResourceRequest) any { return &rr.EngagementID }},
+	{"createdAt", "created_at", func(rr *ResourceRequest) any { return &rr.CreatedAt }},
+	{"updatedAt", "updated_at", func(rr *ResourceRequest) any { return &rr.UpdatedAt }},
+	{"version", "version", func(rr *ResourceRequest) any { return &rr.Version }},
+	{"status", "status", func(rr *ResourceRequest) any { return &rr.Status }},
+	{"statusChangedAt", "status_changed_at", func(rr *ResourceRequest) any { return &rr.StatusChangedAt }},
+}
+
+// GetAll returns a page of requests, in any of statuses when it is not
+// empty. When filters.Fields is set only those columns, and the id, are
+// selected; the rest are left zero.
+func (m *ResourceRequestModel) GetAll(ctx context.Context, customer string, skills []string, statuses []string, filters Filters) ([]*ResourceRequest, Metadata, error) {
+	columns, dests := selectColumns(filters, resourceRequestColumns)
+
 	qry := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, customer, start_date, end_date, hours_per_week, skills, opportunity_id, engagement_id, created_at, updated_at, version
+		SELECT count(*) OVER(), %s
 		FROM resource_requests
 		WHERE (to_tsvector('simple', customer) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		AND (closed = $2 OR $2 = false)
+		AND (status = ANY($2) OR $2 = '{}')
 		AND (skills @> $3 OR $3 = '{}')
-		ORDER BY %s %s, id ASC`, filters.sortColumn(), filters.sortDirection())
+		ORDER BY %s %s, id ASC
+		LIMIT $4 OFFSET $5`, columns, filters.sortColumn(), filters.sortDirection())
 
-	args := []interface{}{customer, closed, pq.Array(skills), filters.limit(), filters.offset()}
+	args := []interface{}{customer, pq.Array(statuses), pq.Array(skills), filters.limit(), filters.offset()}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.Timeouts.list(ctx)
 	defer cancel()
 
 	rows, err := m.DB.QueryContext(ctx, qry, args...)
 	if err != nil {
-		return nil, Metadata{}, err
+		return nil, Metadata{}, ctxError(ctx, err)
 	}
 	defer rows.Close()
 
 	totalRecords := 0
 	resourceRequests := []*ResourceRequest{}
 
+	for rows.Next() {
+		var rr ResourceRequest
+		err := rows.Scan(append([]any{&totalRecords}, dests(&rr)...)...)
+		if err != nil {
+			return nil, Metadata{}, ctxError(ctx, err)
+		}
+		resourceRequests = append(resourceRequests, &rr)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, ctxError(ctx, err)
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return resourceRequests, metadata, nil
+}
+
+// CountUpdatedSince counts the requests GetAll would list for the same
+// arguments that have changed since the given time.
+func (m *ResourceRequestModel) CountUpdatedSince(ctx context.Context, customer string, skills []string, statuses []string, since time.Time) (int, error) {
+	qry := `
+		SELECT count(*)
+		FROM resource_requests
+		WHERE (to_tsvector('simple', customer) @@ plainto_tsquery('simple', $1) OR $1 = '')
+		AND (status = ANY($2) OR $2 = '{}')
+		AND (skills @> $3 OR $3 = '{}')
+		AND updated_at > $4`
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	var count int
+
+	err := m.DB.QueryRowContext(ctx, qry, customer, pq.Array(statuses), pq.Array(skills), since).Scan(&count)
+	if err != nil {
+		return 0, ctxError(ctx, err)
+	}
+
+	return count, nil
+}
+
+// GetUnfilledStartingBefore returns open requests, which have nothing
+// assigned, that start between now and the given time and have not yet
+// triggered an unfilled notification.
+func (m *ResourceRequestModel) GetUnfilledStartingBefore(ctx context.Context, before time.Time) ([]*ResourceRequest, error) {
+	qry := `
+		SELECT id, customer, start_date, end_date, hours_per_week, skills, opportunity_id, engagement_id, created_at, updated_at, version, status, status_changed_at
+		FROM resource_requests
+		WHERE status = $2
+		AND unfilled_notified_at IS NULL
+		AND start_date >= current_date AND start_date <= $1
+		ORDER BY start_date, id`
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, before, RequestOpen)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	resourceRequests := []*ResourceRequest{}
+
 	for rows.Next() {
 		var rr ResourceRequest
 		err := rows.Scan(
-			&totalRecords,
 			&rr.ID,
 			&rr.Customer,
 			&rr.StartDate,
 			&rr.EndDate,
 			&rr.HoursPerWeek,
-			&rr.Skills,
+			pq.Array(&rr.Skills),
 			&rr.OpportunityID,
 			&rr.EngagementID,
 			&rr.CreatedAt,
 			&rr.UpdatedAt,
 			&rr.Version,
-			&rr.Closed,
+			&rr.Status,
+			&rr.StatusChangedAt,
 		)
 		if err != nil {
-			return nil, Metadata{}, err
+			return nil, ctxError(ctx, err)
 		}
 		resourceRequests = append(resourceRequests, &rr)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, Metadata{}, err
+		return nil, ctxError(ctx, err)
 	}
 
-	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return resourceRequests, nil
+}
 
-	return resourceRequests, metadata, nil
+func (m *ResourceRequestModel) MarkUnfilledNotified(ctx context.Context, id int64) error {
+	qry := `
+		UPDATE resource_requests
+		SET unfilled_notified_at = now()
+		WHERE id = $1`
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, qry, id)
+	return ctxError(ctx, err)
 }
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion is the migration version this build of the code expects.
+// Bump it alongside every new file in migrations/.
+const SchemaVersion = 8
+
+var ErrSchemaDirty = errors.New("database schema is dirty after a failed migration")
+
+// CheckSchemaVersion compares the version recorded by the migrate tool with
+// SchemaVersion.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	qry := `
+		SELECT version, dirty
+		FROM schema_migrations
+		LIMIT 1`
+
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := db.QueryRowContext(ctx, qry).Scan(&version, &dirty)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return errors.New("database schema has no migrations applied")
+		default:
+			return ctxError(ctx, err)
+		}
+	}
+
+	if dirty {
+		return ErrSchemaDirty
+	}
+
+	if version != SchemaVersion {
+		return fmt.Errorf("database schema is at version %d, expected %d", version, SchemaVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+var (
+	ErrDuplicateView = errors.New("duplicate view")
+)
+
+// The lists a view can be saved for.
+const (
+	ViewListResources = "resources"
+	ViewListRequests  = "requests"
+)
+
+// View is a named set of list query parameters, such as filters, sort and
+// fields, that its owner can reapply and share with other users.
+type View struct {
+	ID         int64             `json:"id"`
+	Owner      string            `json:"owner"`
+	Name       string            `json:"name"`
+	List       string            `json:"list"`
+	Query      map[string]string `json:"query"`
+	SharedWith []string          `json:"sharedWith"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+	Version    int64             `json:"version"`
+}
+
+// VisibleTo reports whether user owns the view or has had it shared with
+// them.
+func (view *View) VisibleTo(user string) bool {
+	return user == view.Owner || validator.PermittedValue(user, view.SharedWith...)
+}
+
+// ViewVisit records when a user last looked at a view's results.
+type ViewVisit struct {
+	ViewID   int64     `json:"viewId"`
+	User     string    `json:"user"`
+	ViewedAt time.Time `json:"viewedAt"`
+}
+
+func ValidateView(v *validator.Validator, view View) {
+	v.Check(view.Name != "", "name", "must be provided")
+	v.Check(len(view.Name) <= 100, "name", "must not be more than 100 bytes")
+	v.Check(validator.PermittedValue(view.List, ViewListResources, ViewListRequests), "list", "must be one of ('resources', 'requests')")
+	v.Check(validator.Unique(view.SharedWith), "sharedWith", "must not contain duplicate values")
+	v.Check(!validator.PermittedValue(view.Owner, view.SharedWith...), "sharedWith", "must not contain the owner")
+}
+
+type ViewModel struct {
+	DB       Querier
+	Timeouts Timeouts
+}
+
+func (m *ViewModel) Insert(ctx context.Context, view *View) error {
+	qry := `
+		INSERT INTO views (owner, name, list, query, shared_with)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at, version`
+
+	query, err := json.Marshal(view.Query)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{view.Owner, view.Name, view.List, query, pq.Array(view.SharedWith)}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, qry, args...).Scan(&view.ID, &view.CreatedAt, &view.UpdatedAt, &view.Version)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateView
+		default:
+			return ctxError(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *ViewModel) Get(ctx context.Context, id int64) (*View, error) {
+	if id < 1 {
+		return nil, ErrNotFound
+	}
+
+	qry := `
+		SELECT id, owner, name, list, query, shared_with, created_at, updated_at, version
+		FROM views
+		WHERE id = $1`
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	view, err := scanView(m.DB.QueryRowContext(ctx, qry, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	return view, nil
+}
+
+func (m *ViewModel) Update(ctx context.Context, view *View) error {
+	qry := `
+		UPDATE views
+		SET name = $1, query = $2, shared_with = $3, updated_at = now(), version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING updated_at, version`
+
+	query, err := json.Marshal(view.Query)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{view.Name, query, pq.Array(view.SharedWith), view.ID, view.Version}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, qry, args...).Scan(&view.UpdatedAt, &view.Version)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateView
+		default:
+			return ctxError(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *ViewModel) Delete(ctx context.Context, id int64) error {
+	if id < 1 {
+		return ErrNotFound
+	}
+
+	qry := `
+		DELETE FROM views
+		WHERE id = $1`
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, qry, id)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetAllForUser returns the views user owns or has had shared with them,
+// for the given list or, when list is "", for every list.
+func (m *ViewModel) GetAllForUser(ctx context.Context, user, list string) ([]*View, error) {
+	qry := `
+		SELECT id, owner, name, list, query, shared_with, created_at, updated_at, version
+		FROM views
+		WHERE (owner = $1 OR $1 = ANY(shared_with))
+		AND (list = $2 OR $2 = '')
+		ORDER BY id`
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, user, list)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	views := []*View{}
+
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		views = append(views, view)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return views, nil
+}
+
+// GetVisit returns when user last looked at the view, or ErrNotFound if
+// they have not recorded a visit yet.
+func (m *ViewModel) GetVisit(ctx context.Context, viewID int64, user string) (*ViewVisit, error) {
+	qry := `
+		SELECT view_id, user_name, viewed_at
+		FROM view_visits
+		WHERE view_id = $1 AND user_name = $2`
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	var visit ViewVisit
+
+	err := m.DB.QueryRowContext(ctx, qry, viewID, user).Scan(&visit.ViewID, &visit.User, &visit.ViewedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	return &visit, nil
+}
+
+// SaveVisit records now as the user's latest visit to the view.
+func (m *ViewModel) SaveVisit(ctx context.Context, visit *ViewVisit) error {
+	qry := `
+		INSERT INTO view_visits (view_id, user_name)
+		VALUES ($1, $2)
+		ON CONFLICT (view_id, user_name) DO UPDATE
+		SET viewed_at = now()
+		RETURNING viewed_at`
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	return ctxError(ctx, m.DB.QueryRowContext(ctx, qry, visit.ViewID, visit.User).Scan(&visit.ViewedAt))
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanView(row rowScanner) (*View, error) {
+	var (
+		view  View
+		query []byte
+	)
+
+	err := row.Scan(
+		&view.ID,
+		&view.Owner,
+		&view.Name,
+		&view.List,
+		&query,
+		pq.Array(&view.SharedWith),
+		&view.CreatedAt,
+		&view.UpdatedAt,
+		&view.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(query, &view.Query); err != nil {
+		return nil, err
+	}
+
+	return &view, nil
+}
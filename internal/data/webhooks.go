@@ -0,0 +1,446 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/validator"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Version   int64     `json:"version"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
+
+func ValidateWebhookURL(v *validator.Validator, rawURL string) {
+	v.Check(rawURL != "", "url", "must be provided")
+
+	u, err := url.Parse(rawURL)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "url", "must be an absolute http or https URL")
+}
+
+func ValidateWebhookSecret(v *validator.Validator, secret string) {
+	v.Check(secret != "", "secret", "must be provided")
+	v.Check(len(secret) >= 16, "secret", "must be at least 16 bytes long")
+	v.Check(len(secret) <= 256, "secret", "must not be more than 256 bytes")
+}
+
+func ValidateWebhookEvents(v *validator.Validator, events []string) {
+	v.Check(len(events) > 0, "events", "at least one must be provided")
+	v.Check(validator.Unique(events), "events", "must not contain duplicate values")
+
+	for _, event := range events {
+		v.Check(validator.PermittedValue(event, WebhookEvents...), "events", "contains an unknown event type")
+	}
+}
+
+func ValidateWebhook(v *validator.Validator, w Webhook) {
+	ValidateWebhookURL(v, w.URL)
+	ValidateWebhookSecret(v, w.Secret)
+	ValidateWebhookEvents(v, w.Events)
+}
+
+type WebhookModel struct {
+	DB       Querier
+	Timeouts Timeouts
+}
+
+func (m *WebhookModel) Insert(ctx context.Context, w *Webhook) error {
+	qry := `
+		INSERT INTO webhooks (url, secret, events, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at, version`
+
+	args := []interface{}{w.URL, w.Secret, pq.Array(w.Events), w.Active}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	return ctxError(ctx, m.DB.QueryRowContext(ctx, qry, args...).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt, &w.Version))
+}
+
+func (m *WebhookModel) Get(ctx context.Context, id int64) (*Webhook, error) {
+	if id < 1 {
+		return nil, ErrNotFound
+	}
+
+	qry := `
+		SELECT id, url, secret, events, active, created_at, updated_at, version
+		FROM webhooks
+		WHERE id = $1`
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	var w Webhook
+
+	err := m.DB.QueryRowContext(ctx, qry, id).Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		pq.Array(&w.Events),
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+		&w.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	return &w, nil
+}
+
+func (m *WebhookModel) Update(ctx context.Context, w *Webhook) error {
+	qry := `
+		UPDATE webhooks
+		SET url = $1, secret = $2, events = $3, active = $4, updated_at = now(), version = version + 1
+		WHERE id = $5 AND version = $6
+		RETURNING updated_at, version`
+
+	args := []interface{}{
+		w.URL,
+		w.Secret,
+		pq.Array(w.Events),
+		w.Active,
+		w.ID,
+		w.Version,
+	}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, qry, args...).Scan(&w.UpdatedAt, &w.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return ctxError(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *WebhookModel) Delete(ctx context.Context, id int64) error {
+	if id < 1 {
+		return ErrNotFound
+	}
+
+	qry := `
+		DELETE FROM webhooks
+		WHERE id = $1`
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, qry, id)
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return ctxError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (m *WebhookModel) GetAll(ctx context.Context) ([]*Webhook, error) {
+	qry := `
+		SELECT id, url, secret, events, active, created_at, updated_at, version
+		FROM webhooks
+		ORDER BY id`
+
+	return m.query(ctx, qry)
+}
+
+// GetAllForEvent returns the active webhooks subscribed to the given event type.
+func (m *WebhookModel) GetAllForEvent(ctx context.Context, event string) ([]*Webhook, error) {
+	qry := `
+		SELECT id, url, secret, events, active, created_at, updated_at, version
+		FROM webhooks
+		WHERE active = true AND $1 = ANY(events)
+		ORDER BY id`
+
+	return m.query(ctx, qry, event)
+}
+
+func (m *WebhookModel) query(ctx context.Context, qry string, args ...interface{}) ([]*Webhook, error) {
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+
+	for rows.Next() {
+		var w Webhook
+		err := rows.Scan(
+			&w.ID,
+			&w.URL,
+			&w.Secret,
+			pq.Array(&w.Events),
+			&w.Active,
+			&w.CreatedAt,
+			&w.UpdatedAt,
+			&w.Version,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		webhooks = append(webhooks, &w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return webhooks, nil
+}
+
+type WebhookDeliveryModel struct {
+	DB       Querier
+	Timeouts Timeouts
+}
+
+func (m *WebhookDeliveryModel) Insert(ctx context.Context, d *WebhookDelivery) error {
+	qry := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, attempts, created_at, updated_at`
+
+	if d.Status == "" {
+		d.Status = DeliveryPending
+	}
+
+	args := []interface{}{d.WebhookID, d.Event, []byte(d.Payload), d.Status}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	return ctxError(ctx, m.DB.QueryRowContext(ctx, qry, args...).Scan(&d.ID, &d.Attempts, &d.CreatedAt, &d.UpdatedAt))
+}
+
+func (m *WebhookDeliveryModel) Get(ctx context.Context, webhookID, id int64) (*WebhookDelivery, error) {
+	if webhookID < 1 || id < 1 {
+		return nil, ErrNotFound
+	}
+
+	qry := `
+		SELECT id, webhook_id, event, payload, status, attempts, response_status, last_error, created_at, updated_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1 AND id = $2`
+
+	ctx, cancel := m.Timeouts.read(ctx)
+	defer cancel()
+
+	var d WebhookDelivery
+
+	err := m.DB.QueryRowContext(ctx, qry, webhookID, id).Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.Event,
+		(*[]byte)(&d.Payload),
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseStatus,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+		&d.DeliveredAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxError(ctx, err)
+		}
+	}
+
+	return &d, nil
+}
+
+// Update records the outcome of a delivery attempt.
+func (m *WebhookDeliveryModel) Update(ctx context.Context, d *WebhookDelivery) error {
+	qry := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4, delivered_at = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING updated_at`
+
+	args := []interface{}{
+		d.Status,
+		d.Attempts,
+		d.ResponseStatus,
+		d.LastError,
+		d.DeliveredAt,
+		d.ID,
+	}
+
+	ctx, cancel := m.Timeouts.write(ctx)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, qry, args...).Scan(&d.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		default:
+			return ctxError(ctx, err)
+		}
+	}
+
+	return nil
+}
+
+// GetAllUnfinished returns the deliveries still pending, and the failed
+// deliveries that made fewer than maxAttempts attempts, oldest first.
+func (m *WebhookDeliveryModel) GetAllUnfinished(ctx context.Context, maxAttempts int) ([]*WebhookDelivery, error) {
+	qry := `
+		SELECT id, webhook_id, event, payload, status, attempts, response_status, last_error, created_at, updated_at, delivered_at
+		FROM webhook_deliveries
+		WHERE status = $1 OR (status = $2 AND attempts < $3)
+		ORDER BY id`
+
+	args := []interface{}{DeliveryPending, DeliveryFailed, maxAttempts}
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.Event,
+			(*[]byte)(&d.Payload),
+			&d.Status,
+			&d.Attempts,
+			&d.ResponseStatus,
+			&d.LastError,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+			&d.DeliveredAt,
+		)
+		if err != nil {
+			return nil, ctxError(ctx, err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, ctxError(ctx, err)
+	}
+
+	return deliveries, nil
+}
+
+func (m *WebhookDeliveryModel) GetAllForWebhook(ctx context.Context, webhookID int64, status string, filters Filters) ([]*WebhookDelivery, Metadata, error) {
+	qry := `
+		SELECT count(*) OVER(), id, webhook_id, event, payload, status, attempts, response_status, last_error, created_at, updated_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		AND (status = $2 OR $2 = '')
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`
+
+	args := []interface{}{webhookID, status, filters.limit(), filters.offset()}
+
+	ctx, cancel := m.Timeouts.list(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, Metadata{}, ctxError(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	deliveries := []*WebhookDelivery{}
+
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(
+			&totalRecords,
+			&d.ID,
+			&d.WebhookID,
+			&d.Event,
+			(*[]byte)(&d.Payload),
+			&d.Status,
+			&d.Attempts,
+			&d.ResponseStatus,
+			&d.LastError,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+			&d.DeliveredAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, ctxError(ctx, err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, ctxError(ctx, err)
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return deliveries, metadata, nil
+}
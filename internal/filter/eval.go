@@ -0,0 +1,168 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// SQL compiles the filter to a PostgreSQL condition whose placeholders
+// start at $next, and returns it with the arguments they take.
+func (f *Filter) SQL(next int) (string, []any) {
+	c := &compiler{next: next}
+	return f.root.sql(c), c.args
+}
+
+// Match reports whether a record passes the filter. value returns the
+// record's value for a field: a string for String and Enum fields, an int64,
+// a bool or a []string for Set fields. A nil value counts as the kind's zero
+// value, as NULL does in SQL.
+func (f *Filter) Match(value func(field string) any) bool {
+	return f.root.match(value)
+}
+
+type compiler struct {
+	next int
+	args []any
+}
+
+// arg adds an argument and returns its placeholder.
+func (c *compiler) arg(v any) string {
+	c.args = append(c.args, v)
+	c.next++
+	return fmt.Sprintf("$%d", c.next-1)
+}
+
+func (n andNode) sql(c *compiler) string {
+	return "(" + n.left.sql(c) + " AND " + n.right.sql(c) + ")"
+}
+
+func (n orNode) sql(c *compiler) string {
+	return "(" + n.left.sql(c) + " OR " + n.right.sql(c) + ")"
+}
+
+func (n notNode) sql(c *compiler) string {
+	return "NOT " + n.operand.sql(c)
+}
+
+var sqlOperators = map[string]string{opEq: "=", opNe: "<>", opLt: "<", opLe: "<=", opGt: ">", opGe: ">="}
+
+// likeEscaper escapes LIKE wildcards so that contains matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// column returns the SQL for the field's value. NULL becomes the kind's zero
+// value, which is what Match sees for a missing value; left as NULL, "not"
+// and "!=" would drop rows that Match keeps. Strings are compared as text so
+// that enumerated column types support contains.
+func (n *comparison) column() string {
+	switch n.def.Kind {
+	case Int:
+		return fmt.Sprintf("coalesce(%s, 0)", n.def.Column)
+	case Bool:
+		return fmt.Sprintf("coalesce(%s, false)", n.def.Column)
+	case Set:
+		return fmt.Sprintf("coalesce(%s, '{}')", n.def.Column)
+	default:
+		return fmt.Sprintf("coalesce((%s)::text, '')", n.def.Column)
+	}
+}
+
+func (n *comparison) sql(c *compiler) string {
+	col := n.column()
+
+	switch n.def.Kind {
+	case Int:
+		if n.op == opIn {
+			return fmt.Sprintf("(%s = ANY(%s))", col, c.arg(pq.Array(n.ints)))
+		}
+		return fmt.Sprintf("(%s %s %s)", col, sqlOperators[n.op], c.arg(n.ints[0]))
+
+	case Bool:
+		return fmt.Sprintf("(%s %s %s)", col, sqlOperators[n.op], c.arg(n.values[0] == "true"))
+
+	case Set:
+		switch n.op {
+		case opAny:
+			return fmt.Sprintf("(%s && %s)", col, c.arg(pq.Array(n.values)))
+		default:
+			return fmt.Sprintf("(%s @> %s)", col, c.arg(pq.Array(n.values)))
+		}
+
+	default:
+		switch n.op {
+		case opEq, opNe:
+			return fmt.Sprintf("(%s %s %s)", col, sqlOperators[n.op], c.arg(n.values[0]))
+		case opIn:
+			return fmt.Sprintf("(%s = ANY(%s))", col, c.arg(pq.Array(n.values)))
+		case opContains:
+			return fmt.Sprintf("(%s ILIKE '%%' || %s::text || '%%')", col, c.arg(likeEscaper.Replace(n.values[0])))
+		default:
+			// Ordering comparisons on an Enum become membership in the values
+			// at or beyond the limit, so the column needs no rank.
+			return fmt.Sprintf("(%s = ANY(%s))", col, c.arg(pq.Array(n.ordered())))
+		}
+	}
+}
+
+func (n andNode) match(value func(string) any) bool {
+	return n.left.match(value) && n.right.match(value)
+}
+
+func (n orNode) match(value func(string) any) bool {
+	return n.left.match(value) || n.right.match(value)
+}
+
+func (n notNode) match(value func(string) any) bool {
+	return !n.operand.match(value)
+}
+
+func (n *comparison) match(value func(string) any) bool {
+	v := value(n.field)
+
+	switch n.def.Kind {
+	case Int:
+		i, _ := v.(int64)
+		if n.op == opIn {
+			for _, want := range n.ints {
+				if i == want {
+					return true
+				}
+			}
+			return false
+		}
+		return compareInts(i, n.ints[0], n.op)
+
+	case Bool:
+		b, _ := v.(bool)
+		return (b == (n.values[0] == "true")) == (n.op == opEq)
+
+	case Set:
+		set, _ := v.([]string)
+		for _, want := range n.values {
+			found := permitted(want, set)
+			if n.op == opAny && found {
+				return true
+			}
+			if n.op != opAny && !found {
+				return false
+			}
+		}
+		return n.op != opAny
+
+	default:
+		s, _ := v.(string)
+		switch n.op {
+		case opEq:
+			return s == n.values[0]
+		case opNe:
+			return s != n.values[0]
+		case opIn:
+			return permitted(s, n.values)
+		case opContains:
+			return strings.Contains(strings.ToLower(s), strings.ToLower(n.values[0]))
+		default:
+			return permitted(s, n.ordered())
+		}
+	}
+}
@@ -0,0 +1,506 @@
+// Package filter parses small boolean filter expressions such as
+//
+//	position in (Senior Consultant, Staff Consultant) and clearance >= NV1
+//	and (specialties any of NSX, vSAN) and not certifications contains VCDX
+//
+// checks them against a Schema of the fields a client may filter on and
+// compiles them to parameterised PostgreSQL or evaluates them in memory.
+// Values never reach the SQL text: only a field's Column does.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a field, which decides the operators it supports.
+type Kind int
+
+const (
+	// String fields support =, !=, in and contains, a case-insensitive
+	// substring match.
+	String Kind = iota
+	// Int fields support =, !=, <, <=, >, >= and in.
+	Int
+	// Bool fields support = and !=.
+	Bool
+	// Enum fields take one of Field.Values and support =, !=, <, <=, >, >=
+	// and in, ordering values as they are listed.
+	Enum
+	// Set fields are lists of strings and support contains, any of and all
+	// of.
+	Set
+)
+
+// Field is a field a filter may refer to.
+type Field struct {
+	Kind Kind
+	// Column is the SQL expression for the field. SQL treats its NULLs as
+	// the kind's zero value.
+	Column string
+	// Values are an Enum's permitted values in ascending order.
+	Values []string
+}
+
+// Schema maps field names to the fields they refer to.
+type Schema map[string]Field
+
+// Error reports the part of a filter that could not be parsed or checked.
+// Pos is the 1-based position of the offending token.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// Filter is a parsed and checked filter expression.
+type Filter struct {
+	root node
+}
+
+// Parse parses src and checks every comparison against schema.
+func Parse(src string, schema Schema) (*Filter, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, schema: schema}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+
+	return &Filter{root: root}, nil
+}
+
+// node is a parsed expression: and, or, not or a comparison.
+type node interface {
+	sql(c *compiler) string
+	match(value func(field string) any) bool
+}
+
+type andNode struct{ left, right node }
+
+type orNode struct{ left, right node }
+
+type notNode struct{ operand node }
+
+type comparison struct {
+	field  string
+	def    Field
+	op     string
+	values []string
+	// ints holds the values of an Int comparison.
+	ints []int64
+}
+
+// Operators, named as they are written except for the list forms.
+const (
+	opEq       = "="
+	opNe       = "!="
+	opLt       = "<"
+	opLe       = "<="
+	opGt       = ">"
+	opGe       = ">="
+	opIn       = "in"
+	opContains = "contains"
+	opAny      = "any of"
+	opAll      = "all of"
+)
+
+var kindOperators = map[Kind][]string{
+	String: {opEq, opNe, opIn, opContains},
+	Int:    {opEq, opNe, opLt, opLe, opGt, opGe, opIn},
+	Bool:   {opEq, opNe},
+	Enum:   {opEq, opNe, opLt, opLe, opGt, opGe, opIn},
+	Set:    {opContains, opAny, opAll},
+}
+
+// tokens
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// is reports whether t is the keyword word, ignoring case.
+func (t token) is(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", pos})
+			i++
+
+		case r == '=':
+			tokens = append(tokens, token{tokenOperator, "=", pos})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &Error{Pos: pos, Msg: `unexpected "!", did you mean "!="?`}
+			}
+			tokens = append(tokens, token{tokenOperator, op, pos})
+			i += len(op)
+
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, &Error{Pos: pos, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, token{tokenString, b.String(), pos})
+			i = j + 1
+
+		default:
+			j := i
+			for j < len(runes) && !strings.ContainsRune(" \t\r\n(),=!<>\"'", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[i:j]), pos})
+			i = j
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes) + 1}), nil
+}
+
+// parser
+
+type parser struct {
+	tokens []token
+	i      int
+	schema Schema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token) error {
+	return &Error{Pos: t.pos, Msg: "unexpected " + t.String()}
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+
+	switch {
+	case t.is("not"):
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+
+	case t.kind == tokenLParen:
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, &Error{Pos: t.pos, Msg: "expected \")\" but found " + t.String()}
+		}
+		return n, nil
+
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, &Error{Pos: t.pos, Msg: "expected a field name but found " + t.String()}
+	}
+
+	def, ok := p.schema[t.text]
+	if !ok {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", t.text)}
+	}
+
+	c := &comparison{field: t.text, def: def}
+
+	negate := false
+	opToken := p.next()
+	switch {
+	case opToken.kind == tokenOperator:
+		c.op = opToken.text
+	case opToken.is("not") && (p.peek().is("in") || p.peek().is("contains")):
+		// "x not in (...)" and "x not contains y" read better than
+		// "not x in (...)".
+		negate = true
+		c.op = strings.ToLower(p.next().text)
+	case opToken.is("in"), opToken.is("contains"):
+		c.op = strings.ToLower(opToken.text)
+	case opToken.is("any"), opToken.is("all"):
+		c.op = strings.ToLower(opToken.text) + " of"
+		if p.peek().is("of") {
+			p.next()
+		}
+	default:
+		return nil, &Error{Pos: opToken.pos, Msg: "expected an operator after " + strconv.Quote(c.field) + " but found " + opToken.String()}
+	}
+
+	if ops := kindOperators[def.Kind]; !permitted(c.op, ops) {
+		return nil, &Error{Pos: opToken.pos, Msg: fmt.Sprintf("%s does not support %q; use %s or %s", c.field, c.op, strings.Join(ops[:len(ops)-1], ", "), ops[len(ops)-1])}
+	}
+
+	var values []token
+	var err error
+	switch c.op {
+	case opIn, opAny, opAll:
+		values, err = p.parseList()
+	default:
+		var v token
+		v, err = p.parseValue()
+		values = []token{v}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range values {
+		if err := c.addValue(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if negate {
+		return notNode{c}, nil
+	}
+	return c, nil
+}
+
+// parseList reads comma-separated values, optionally in parentheses.
+func (p *parser) parseList() ([]token, error) {
+	parens := p.peek().kind == tokenLParen
+	if parens {
+		p.next()
+	}
+
+	var values []token
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+
+	if parens {
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, &Error{Pos: t.pos, Msg: "expected \",\" or \")\" but found " + t.String()}
+		}
+	}
+
+	return values, nil
+}
+
+// parseValue reads a quoted string or one or more words, which are joined
+// by single spaces so that "Senior Consultant" needs no quotes. The words
+// "and" and "or" end a value; quote values that contain them.
+func (p *parser) parseValue() (token, error) {
+	t := p.peek()
+	if t.kind == tokenString {
+		return p.next(), nil
+	}
+
+	var words []string
+	for t := p.peek(); t.kind == tokenWord && !t.is("and") && !t.is("or"); t = p.peek() {
+		words = append(words, p.next().text)
+	}
+	if len(words) == 0 {
+		return token{}, &Error{Pos: t.pos, Msg: "expected a value but found " + t.String()}
+	}
+
+	return token{tokenWord, strings.Join(words, " "), t.pos}, nil
+}
+
+func (c *comparison) addValue(v token) error {
+	switch c.def.Kind {
+	case Int:
+		i, err := strconv.ParseInt(v.text, 10, 64)
+		if err != nil {
+			return &Error{Pos: v.pos, Msg: fmt.Sprintf("%s must be an integer, not %s", c.field, v)}
+		}
+		c.ints = append(c.ints, i)
+	case Bool:
+		b, err := strconv.ParseBool(v.text)
+		if err != nil {
+			return &Error{Pos: v.pos, Msg: fmt.Sprintf("%s must be true or false, not %s", c.field, v)}
+		}
+		v.text = strconv.FormatBool(b)
+	case Enum:
+		// Match case-insensitively but keep the value as the schema spells
+		// it, which is how the column holds it.
+		i := c.rank(v.text)
+		if i < 0 {
+			return &Error{Pos: v.pos, Msg: fmt.Sprintf("%s must be one of (%s), not %s", c.field, strings.Join(c.def.Values, ", "), v)}
+		}
+		v.text = c.def.Values[i]
+	}
+
+	c.values = append(c.values, v.text)
+	return nil
+}
+
+func permitted(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// rank returns value's place in an Enum's ordering.
+func (c *comparison) rank(value string) int {
+	for i, v := range c.def.Values {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ordered returns the Enum values that satisfy an ordering comparison.
+func (c *comparison) ordered() []string {
+	limit := c.rank(c.values[0])
+
+	var values []string
+	for i, v := range c.def.Values {
+		if compareInts(int64(i), int64(limit), c.op) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func compareInts(a, b int64, op string) bool {
+	switch op {
+	case opEq:
+		return a == b
+	case opNe:
+		return a != b
+	case opLt:
+		return a < b
+	case opLe:
+		return a <= b
+	case opGt:
+		return a > b
+	case opGe:
+		return a >= b
+	}
+	return false
+}
@@ -0,0 +1,353 @@
+package filter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+var testSchema = Schema{
+	"name":      {Kind: String, Column: "name"},
+	"position":  {Kind: Enum, Column: "position", Values: []string{"Consultant", "Senior Consultant", "Staff Consultant"}},
+	"clearance": {Kind: Enum, Column: "clearance", Values: []string{"None", "Baseline", "NV1", "NV2", "PV"}},
+	"hours":     {Kind: Int, Column: "hours"},
+	"active":    {Kind: Bool, Column: "active"},
+	"skills":    {Kind: Set, Column: "skills"},
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{"", 1, "expected a field name but found end of filter"},
+		{"colour = red", 1, `unknown field "colour"`},
+		{"name = Ada and colour = red", 16, `unknown field "colour"`},
+		{"name", 5, `expected an operator after "name" but found end of filter`},
+		{"name ~ Ada", 6, `expected an operator after "name" but found "~"`},
+		{"name ! Ada", 6, `unexpected "!", did you mean "!="?`},
+		{"name =", 7, "expected a value but found end of filter"},
+		{"name = and", 8, `expected a value but found "and"`},
+		{`name = "Ada`, 8, "unterminated string"},
+		{"name < Ada", 6, `name does not support "<"; use =, !=, in or contains`},
+		{"active contains true", 8, `active does not support "contains"`},
+		{"skills = NSX", 8, `skills does not support "="`},
+		{"hours > forty", 9, `hours must be an integer, not "forty"`},
+		{"hours in (20, x)", 15, `hours must be an integer, not "x"`},
+		{"active = maybe", 10, `active must be true or false, not "maybe"`},
+		{"clearance >= NV3", 14, "clearance must be one of (None, Baseline, NV1, NV2, PV), not \"NV3\""},
+		{"position in (Consultant, Principal Consultant)", 26, `not "Principal Consultant"`},
+		{"(name = Ada", 12, `expected ")" but found end of filter`},
+		{"name = Ada)", 11, `unexpected ")"`},
+		{"name in (Ada, Bob", 18, `expected "," or ")" but found end of filter`},
+		{"name = Ada or", 14, "expected a field name but found end of filter"},
+		{"not", 4, "expected a field name but found end of filter"},
+		{"name = Ada hours = 1", 18, `unexpected "="`},
+		{"naïve = x", 1, `unknown field "naïve"`},
+		{"name = café and hours ? 1", 23, `expected an operator after "hours" but found "?"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src, testSchema)
+
+			var ferr *Error
+			if !errors.As(err, &ferr) {
+				t.Fatalf("Parse error = %v, want a *filter.Error", err)
+			}
+			if ferr.Pos != tt.pos {
+				t.Errorf("Pos = %d, want %d (%s)", ferr.Pos, tt.pos, ferr.Msg)
+			}
+			if !strings.Contains(ferr.Msg, tt.msg) {
+				t.Errorf("Msg = %q, want it to contain %q", ferr.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+		args []any
+	}{
+		{
+			"name = Ada",
+			"(coalesce((name)::text, '') = $3)",
+			[]any{"Ada"},
+		},
+		{
+			"name != 'Ada Lovelace'",
+			"(coalesce((name)::text, '') <> $3)",
+			[]any{"Ada Lovelace"},
+		},
+		{
+			"position in (Senior Consultant, Staff Consultant)",
+			"(coalesce((position)::text, '') = ANY($3))",
+			[]any{pq.Array([]string{"Senior Consultant", "Staff Consultant"})},
+		},
+		{
+			"position not in (senior consultant)",
+			"NOT (coalesce((position)::text, '') = ANY($3))",
+			[]any{pq.Array([]string{"Senior Consultant"})},
+		},
+		{
+			"name contains 50%_off",
+			`(coalesce((name)::text, '') ILIKE '%' || $3::text || '%')`,
+			[]any{`50\%\_off`},
+		},
+		{
+			"name not contains lace",
+			`NOT (coalesce((name)::text, '') ILIKE '%' || $3::text || '%')`,
+			[]any{"lace"},
+		},
+		{
+			"clearance >= NV1",
+			"(coalesce((clearance)::text, '') = ANY($3))",
+			[]any{pq.Array([]string{"NV1", "NV2", "PV"})},
+		},
+		{
+			"clearance < nv1",
+			"(coalesce((clearance)::text, '') = ANY($3))",
+			[]any{pq.Array([]string{"None", "Baseline"})},
+		},
+		{
+			"clearance != PV",
+			"(coalesce((clearance)::text, '') <> $3)",
+			[]any{"PV"},
+		},
+		{
+			"hours >= 20",
+			"(coalesce(hours, 0) >= $3)",
+			[]any{int64(20)},
+		},
+		{
+			"hours in (8, 16)",
+			"(coalesce(hours, 0) = ANY($3))",
+			[]any{pq.Array([]int64{8, 16})},
+		},
+		{
+			"active = TRUE",
+			"(coalesce(active, false) = $3)",
+			[]any{true},
+		},
+		{
+			"skills contains NSX",
+			"(coalesce(skills, '{}') @> $3)",
+			[]any{pq.Array([]string{"NSX"})},
+		},
+		{
+			"skills any of NSX, vSAN",
+			"(coalesce(skills, '{}') && $3)",
+			[]any{pq.Array([]string{"NSX", "vSAN"})},
+		},
+		{
+			"skills all (NSX, vSAN)",
+			"(coalesce(skills, '{}') @> $3)",
+			[]any{pq.Array([]string{"NSX", "vSAN"})},
+		},
+		{
+			"name = a or name = b and not hours = 1",
+			"((coalesce((name)::text, '') = $3) OR ((coalesce((name)::text, '') = $4) AND NOT (coalesce(hours, 0) = $5)))",
+			[]any{"a", "b", int64(1)},
+		},
+		{
+			"(name = a or name = b) and hours = 1",
+			"(((coalesce((name)::text, '') = $3) OR (coalesce((name)::text, '') = $4)) AND (coalesce(hours, 0) = $5))",
+			[]any{"a", "b", int64(1)},
+		},
+		{
+			`name = "and" AND hours = 1`,
+			"((coalesce((name)::text, '') = $3) AND (coalesce(hours, 0) = $4))",
+			[]any{"and", int64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			f, err := Parse(tt.src, testSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, args := f.SQL(3)
+			if got != tt.want {
+				t.Errorf("SQL =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+// record is a row for the agreement tests. A missing field is NULL.
+type record struct {
+	id     int
+	values map[string]any
+}
+
+var records = []record{
+	{1, map[string]any{"name": "Ada Lovelace", "position": "Senior Consultant", "clearance": "NV1", "hours": int64(40), "active": true, "skills": []string{"NSX", "vSAN"}}},
+	{2, map[string]any{"name": "Bob", "position": "Consultant", "clearance": "None", "hours": int64(20), "active": false, "skills": []string{}}},
+	{3, map[string]any{"name": "Carol Staff", "position": "Staff Consultant", "clearance": "PV", "hours": int64(0), "active": true, "skills": []string{"vSAN"}}},
+	{4, map[string]any{}},
+}
+
+func (r record) value(field string) any {
+	return r.values[field]
+}
+
+func TestMatchAgreesWithSQL(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []int
+	}{
+		{"name = Bob", []int{2}},
+		{"name != Bob", []int{1, 3, 4}},
+		{"not name = Bob", []int{1, 3, 4}},
+		{"name = ''", []int{4}},
+		{"name contains LACE", []int{1}},
+		{"name not contains lace", []int{2, 3, 4}},
+		{"not name contains lace", []int{2, 3, 4}},
+		{"name contains ''", []int{1, 2, 3, 4}},
+		{"name in (Bob, Carol Staff)", []int{2, 3}},
+		{"name not in (Bob, Carol Staff)", []int{1, 4}},
+		{"position = Senior Consultant", []int{1}},
+		{"position in (Senior Consultant, Staff Consultant)", []int{1, 3}},
+		{"position not in (Senior Consultant, Staff Consultant)", []int{2, 4}},
+		{"clearance >= NV1", []int{1, 3}},
+		{"clearance > NV1", []int{3}},
+		{"clearance <= Baseline", []int{2}},
+		{"not clearance >= NV1", []int{2, 4}},
+		{"clearance != PV", []int{1, 2, 4}},
+		{"hours >= 20", []int{1, 2}},
+		{"hours < 20", []int{3, 4}},
+		{"hours = 0", []int{3, 4}},
+		{"hours not in (0, 40)", []int{2}},
+		{"active = true", []int{1, 3}},
+		{"active = false", []int{2, 4}},
+		{"active != true", []int{2, 4}},
+		{"skills contains vSAN", []int{1, 3}},
+		{"not skills contains vSAN", []int{2, 4}},
+		{"skills not contains NSX", []int{2, 3, 4}},
+		{"skills any of (NSX, vSAN)", []int{1, 3}},
+		{"skills all of (NSX, vSAN)", []int{1}},
+		{"active = true and not (clearance >= NV2 or skills contains NSX)", nil},
+		{"hours >= 20 or clearance = PV", []int{1, 2, 3}},
+	}
+
+	db := openTestDB(t)
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			f, err := Parse(tt.src, testSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var matched []int
+			for _, r := range records {
+				if f.Match(r.value) {
+					matched = append(matched, r.id)
+				}
+			}
+			if !reflect.DeepEqual(matched, tt.want) {
+				t.Errorf("Match kept %v, want %v", matched, tt.want)
+			}
+
+			if db == nil {
+				return
+			}
+
+			selected := selectRecords(t, db, f)
+			if !reflect.DeepEqual(selected, tt.want) {
+				t.Errorf("SQL selected %v, want %v", selected, tt.want)
+			}
+		})
+	}
+}
+
+// openTestDB connects to the PostgreSQL database named by
+// DASHBOARD_TEST_DSN. Without one, only Match is checked.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("DASHBOARD_TEST_DSN")
+	if dsn == "" {
+		t.Log("DASHBOARD_TEST_DSN not set; checking Match only")
+		return nil
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// selectRecords runs f against records as a VALUES list, with NULL for
+// every missing field, and returns the ids of the rows it selects.
+func selectRecords(t *testing.T, db *sql.DB, f *Filter) []int {
+	t.Helper()
+
+	var rows []string
+	var args []any
+
+	for _, r := range records {
+		n := len(args)
+		rows = append(rows, fmt.Sprintf("($%d::int, $%d::text, $%d::text, $%d::text, $%d::bigint, $%d::boolean, $%d::text[])", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+
+		args = append(args, r.id)
+		for _, field := range []string{"name", "position", "clearance", "hours", "active"} {
+			args = append(args, r.values[field])
+		}
+		if skills, ok := r.values["skills"].([]string); ok {
+			args = append(args, pq.Array(skills))
+		} else {
+			args = append(args, nil)
+		}
+	}
+
+	condition, whereArgs := f.SQL(len(args) + 1)
+	args = append(args, whereArgs...)
+
+	qry := fmt.Sprintf(`
+		SELECT id
+		FROM (VALUES %s) AS t (id, name, position, clearance, hours, active, skills)
+		WHERE %s
+		ORDER BY id`, strings.Join(rows, ", "), condition)
+
+	result, err := db.Query(qry, args...)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, qry)
+	}
+	defer result.Close()
+
+	var ids []int
+	for result.Next() {
+		var id int
+		if err := result.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return ids
+}
@@ -2,8 +2,10 @@ package jsonlog
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,7 +13,9 @@ import (
 type Level int8
 
 const (
-	LevelInfo Level = iota
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
 	LevelError
 	LevelFatal
 	LevelOff
@@ -19,53 +23,132 @@ const (
 
 func (l Level) String() string {
 	switch l {
+	case LevelDebug:
+		return "DEBUG"
 	case LevelInfo:
 		return "INFO"
+	case LevelWarn:
+		return "WARN"
 	case LevelError:
 		return "ERROR"
 	case LevelFatal:
 		return "FATAL"
+	case LevelOff:
+		return "OFF"
 	default:
 		return ""
 	}
 }
 
+// ParseLevel converts a level name such as "debug" or "WARN" into a Level.
+func ParseLevel(s string) (Level, error) {
+	for l := LevelDebug; l <= LevelOff; l++ {
+		if strings.EqualFold(s, l.String()) {
+			return l, nil
+		}
+	}
+	return LevelOff, fmt.Errorf("unknown log level %q", s)
+}
+
 type Logger struct {
-	out      io.Writer
-	minLevel Level
-	mu       sync.Mutex
+	out        io.Writer
+	minLevel   Level
+	traceLevel Level
+	fields     map[string]any
+	mu         *sync.Mutex
 }
 
+// New returns a logger writing entries at or above minLevel to out. Stack
+// traces are attached to entries at LevelError and above; use
+// SetTraceLevel to change that.
 func New(out io.Writer, minLevel Level) *Logger {
 	return &Logger{
-		out:      out,
-		minLevel: minLevel,
+		out:        out,
+		minLevel:   minLevel,
+		traceLevel: LevelError,
+		mu:         &sync.Mutex{},
+	}
+}
+
+// SetLevel sets the minimum level written by the logger. Like SetTraceLevel
+// it should be called before the logger is in use.
+func (l *Logger) SetLevel(level Level) {
+	l.minLevel = level
+}
+
+// SetTraceLevel sets the lowest level at which a stack trace is captured.
+// LevelOff disables stack traces entirely. It should be called before the
+// logger, or any child created from it, is in use.
+func (l *Logger) SetTraceLevel(level Level) {
+	l.traceLevel = level
+}
+
+// With returns a child logger that adds fields to every entry it writes. The
+// child shares its parent's output and lock.
+func (l *Logger) With(fields map[string]any) *Logger {
+	merged := make(map[string]any, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &Logger{
+		out:        l.out,
+		minLevel:   l.minLevel,
+		traceLevel: l.traceLevel,
+		fields:     merged,
+		mu:         l.mu,
 	}
 }
 
-func (l *Logger) PrintInfo(message string, properties map[string]string) {
+func (l *Logger) PrintDebug(message string, properties map[string]any) {
+	l.print(LevelDebug, message, properties)
+}
+
+func (l *Logger) PrintInfo(message string, properties map[string]any) {
 	l.print(LevelInfo, message, properties)
 }
 
-func (l *Logger) PrintError(err error, properties map[string]string) {
+func (l *Logger) PrintWarn(message string, properties map[string]any) {
+	l.print(LevelWarn, message, properties)
+}
+
+func (l *Logger) PrintError(err error, properties map[string]any) {
 	l.print(LevelError, err.Error(), properties)
 }
 
-func (l *Logger) PrintFatal(err error, properties map[string]string) {
+func (l *Logger) PrintFatal(err error, properties map[string]any) {
 	l.print(LevelFatal, err.Error(), properties)
 }
 
-func (l *Logger) print(level Level, message string, properties map[string]string) (int, error) {
+func (l *Logger) print(level Level, message string, properties map[string]any) (int, error) {
 	if level < l.minLevel {
 		return 0, nil
 	}
 
+	if len(l.fields)+len(properties) > 0 {
+		merged := make(map[string]any, len(l.fields)+len(properties))
+		for k, v := range l.fields {
+			merged[k] = v
+		}
+		for k, v := range properties {
+			// Errors don't marshal to anything useful, so log their message.
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			merged[k] = v
+		}
+		properties = merged
+	}
+
 	aux := struct {
-		Level      string            `json:"level"`
-		Time       string            `json:"time"`
-		Message    string            `json:"message"`
-		Properties map[string]string `json:"properties,omitempty"`
-		Trace      string            `json:"trace,omitempty"`
+		Level      string         `json:"level"`
+		Time       string         `json:"time"`
+		Message    string         `json:"message"`
+		Properties map[string]any `json:"properties,omitempty"`
+		Trace      string         `json:"trace,omitempty"`
 	}{
 		Level:      level.String(),
 		Time:       time.Now().UTC().Format(time.RFC3339),
@@ -73,7 +156,7 @@ func (l *Logger) print(level Level, message string, properties map[string]string
 		Properties: properties,
 	}
 
-	if level >= LevelError {
+	if level >= l.traceLevel {
 		aux.Trace = string(debug.Stack())
 	}
 
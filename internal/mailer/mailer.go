@@ -0,0 +1,233 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	ttemplate "text/template"
+	"time"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+// Mailer sends templated multipart emails through an SMTP server. Servers that
+// advertise STARTTLS are upgraded automatically; authentication is only
+// attempted when a username is configured, so a local SMTP sink such as
+// MailHog works without any credentials.
+type Mailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	sender   string
+	timeout  time.Duration
+}
+
+func New(host string, port int, username, password, sender string, timeout time.Duration) *Mailer {
+	return &Mailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		sender:   sender,
+		timeout:  timeout,
+	}
+}
+
+// Send renders the "subject", "plainBody" and "htmlBody" templates defined in
+// templateFile with data and delivers the result to every recipient.
+func (m *Mailer) Send(recipients []string, templateFile string, data any) error {
+	if len(recipients) == 0 {
+		return errors.New("mailer: no recipients")
+	}
+
+	msg, err := m.render(recipients, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(m.sender)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid sender: %w", err)
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err = client.Mail(from.Address); err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		if err = client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// Ping connects to the SMTP server and completes the greeting without
+// sending a message.
+func (m *Mailer) Ping() error {
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Quit()
+}
+
+func (m *Mailer) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+
+	conn, err := net.DialTimeout("tcp", addr, m.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.SetDeadline(time.Now().Add(m.timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if m.username != "" {
+		auth := smtp.PlainAuth("", m.username, m.password, m.host)
+		if err = client.Auth(auth); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (m *Mailer) render(recipients []string, templateFile string, data any) ([]byte, error) {
+	textTmpl, err := ttemplate.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := new(bytes.Buffer)
+	if err = textTmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	plainBody := new(bytes.Buffer)
+	if err = textTmpl.ExecuteTemplate(plainBody, "plainBody", data); err != nil {
+		return nil, err
+	}
+
+	htmlTmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlBody := new(bytes.Buffer)
+	if err = htmlTmpl.ExecuteTemplate(htmlBody, "htmlBody", data); err != nil {
+		return nil, err
+	}
+
+	msg := new(bytes.Buffer)
+	mw := multipart.NewWriter(msg)
+
+	fmt.Fprintf(msg, "From: %s\r\n", m.sender)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Message-ID: %s\r\n", m.messageID())
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+
+	if err = writePart(mw, "text/plain; charset=utf-8", plainBody.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err = writePart(mw, "text/html; charset=utf-8", htmlBody.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+func (m *Mailer) messageID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	domain := "localhost"
+	if from, err := mail.ParseAddress(m.sender); err == nil {
+		if i := strings.LastIndex(from.Address, "@"); i >= 0 {
+			domain = from.Address[i+1:]
+		}
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
+
+func writePart(mw *multipart.Writer, contentType string, body []byte) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err = io.Copy(qp, bytes.NewReader(body)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
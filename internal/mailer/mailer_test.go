@@ -0,0 +1,231 @@
+package mailer_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/mailer"
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/mailer/mailertest"
+)
+
+const sender = "Delivery Dashboard <no-reply@example.com>"
+
+// email is a message received by the sink, decoded into its parts.
+type email struct {
+	header mail.Header
+	parts  map[string]string
+}
+
+func newSink(t *testing.T) (*mailertest.Server, *mailer.Mailer) {
+	t.Helper()
+
+	sink, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sink.Close() })
+
+	return sink, mailer.New(sink.Host(), sink.Port(), "", "", sender, time.Second)
+}
+
+func decode(t *testing.T, data []byte) email {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", mediaType)
+	}
+
+	e := email{header: msg.Header, parts: make(map[string]string)}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		e.parts[partType] = string(body)
+	}
+
+	return e
+}
+
+func TestSend(t *testing.T) {
+	start := time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, time.December, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		data     map[string]any
+		subject  string
+		plain    []string
+		html     []string
+	}{
+		{
+			name:     "assignment created",
+			template: "assignment_created.tmpl",
+			data: map[string]any{
+				"Resource":   map[string]any{"FirstName": "Ada", "LastName": "Lovelace"},
+				"Request":    map[string]any{"ID": 7, "Customer": "Acme", "StartDate": start, "EndDate": end},
+				"Assignment": map[string]any{"HoursPerWeek": 24},
+			},
+			subject: "Assigned to Acme: Ada Lovelace",
+			plain: []string{
+				"Ada Lovelace has been assigned to the Acme request (#7).",
+				"Start date:     2 Nov 2026",
+				"End date:       18 Dec 2026",
+				"Hours per week: 24",
+			},
+			html: []string{
+				"<p>Ada Lovelace has been assigned to the Acme request (#7).</p>",
+				"<tr><td>Hours per week</td><td>24</td></tr>",
+			},
+		},
+		{
+			name:     "request unfilled",
+			template: "request_unfilled.tmpl",
+			data: map[string]any{
+				"Request":        map[string]any{"ID": 9, "Customer": "Smith & Sons", "StartDate": start, "HoursPerWeek": 40, "Skills": []string{"go", "k8s"}},
+				"DaysUntilStart": 3,
+			},
+			subject: "Unfilled request for Smith & Sons starts in 3 days",
+			plain: []string{
+				"The Smith & Sons request (#9) starts on 2 Nov 2026 and nobody has been assigned to it yet.",
+				"Skills:         go, k8s",
+			},
+			html: []string{
+				"<p>The Smith &amp; Sons request (#9) starts on 2 Nov 2026",
+				"<tr><td>Skills</td><td>go, k8s</td></tr>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, m := newSink(t)
+
+			recipients := []string{"staffing@example.com", "ada@example.com"}
+
+			err := m.Send(recipients, tt.template, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			messages := sink.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+
+			if messages[0].From != "no-reply@example.com" {
+				t.Errorf("MAIL FROM = %q, want no-reply@example.com", messages[0].From)
+			}
+			if strings.Join(messages[0].To, ",") != strings.Join(recipients, ",") {
+				t.Errorf("RCPT TO = %v, want %v", messages[0].To, recipients)
+			}
+
+			e := decode(t, messages[0].Data)
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(e.header.Get("Subject"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", subject, tt.subject)
+			}
+
+			if e.header.Get("To") != strings.Join(recipients, ", ") {
+				t.Errorf("To = %q", e.header.Get("To"))
+			}
+			if e.header.Get("Message-Id") == "" || !strings.HasSuffix(e.header.Get("Message-Id"), "@example.com>") {
+				t.Errorf("Message-ID = %q", e.header.Get("Message-Id"))
+			}
+
+			for _, want := range tt.plain {
+				if !strings.Contains(e.parts["text/plain"], want) {
+					t.Errorf("plain body does not contain %q:\n%s", want, e.parts["text/plain"])
+				}
+			}
+			for _, want := range tt.html {
+				if !strings.Contains(e.parts["text/html"], want) {
+					t.Errorf("HTML body does not contain %q:\n%s", want, e.parts["text/html"])
+				}
+			}
+		})
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	sink, m := newSink(t)
+
+	data := map[string]any{"Request": map[string]any{}, "DaysUntilStart": 1}
+
+	tests := []struct {
+		name       string
+		mailer     *mailer.Mailer
+		recipients []string
+		template   string
+	}{
+		{"no recipients", m, nil, "request_unfilled.tmpl"},
+		{"unknown template", m, []string{"a@example.com"}, "missing.tmpl"},
+		{"invalid sender", mailer.New(sink.Host(), sink.Port(), "", "", "not an address", time.Second), []string{"a@example.com"}, "request_unfilled.tmpl"},
+		{"rejected", m, []string{"a@example.com"}, "request_unfilled.tmpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "rejected" {
+				sink.FailNext(1)
+			}
+
+			if err := tt.mailer.Send(tt.recipients, tt.template, data); err == nil {
+				t.Fatal("Send succeeded, want an error")
+			}
+		})
+	}
+
+	if n := len(sink.Messages()); n != 0 {
+		t.Errorf("sink received %d messages, want 0", n)
+	}
+	if n := sink.Rejected(); n != 1 {
+		t.Errorf("sink rejected %d messages, want 1", n)
+	}
+}
+
+func TestPing(t *testing.T) {
+	sink, m := newSink(t)
+
+	if err := m.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	sink.Close()
+
+	if err := m.Ping(); err == nil {
+		t.Fatal("Ping succeeded against a closed server")
+	}
+}
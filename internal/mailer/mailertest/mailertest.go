@@ -0,0 +1,199 @@
+// Package mailertest provides a local SMTP sink for testing code that sends
+// email through the mailer package.
+package mailertest
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message is an email accepted by a Server.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Server is a minimal SMTP server listening on a loopback address. It accepts
+// every message without authentication or STARTTLS and keeps it in memory.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	failures int
+	rejected int
+}
+
+// NewServer starts a Server on a random loopback port. Callers should Close
+// it when done.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{listener: listener}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Host returns the host the server listens on.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// FailNext makes the server reject the next n messages with a temporary
+// failure when the client starts them.
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = n
+}
+
+// Messages returns the messages accepted so far, oldest first.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message{}, s.messages...)
+}
+
+// Rejected returns how many messages the server has rejected because of
+// FailNext.
+func (s *Server) Rejected() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rejected
+}
+
+// Close stops the server and waits for its open connections to finish.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+func (s *Server) handle(conn *textproto.Conn) {
+	reply := func(code int, text string) bool {
+		return conn.PrintfLine("%d %s", code, text) == nil
+	}
+
+	if !reply(220, "localhost mailertest") {
+		return
+	}
+
+	var msg *Message
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply(250, "localhost")
+		case "MAIL":
+			if s.reject() {
+				reply(451, "4.3.0 try again later")
+				continue
+			}
+			msg = &Message{From: address(arg)}
+			reply(250, "OK")
+		case "RCPT":
+			if msg == nil {
+				reply(503, "5.5.1 MAIL first")
+				continue
+			}
+			msg.To = append(msg.To, address(arg))
+			reply(250, "OK")
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				reply(503, "5.5.1 RCPT first")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+
+			data, err := io.ReadAll(bufio.NewReader(conn.DotReader()))
+			if err != nil {
+				return
+			}
+			msg.Data = data
+
+			s.mu.Lock()
+			s.messages = append(s.messages, *msg)
+			s.mu.Unlock()
+
+			msg = nil
+			reply(250, "OK: queued as "+strconv.Itoa(len(s.Messages())))
+		case "RSET":
+			msg = nil
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "5.5.2 command not recognised")
+		}
+	}
+}
+
+func (s *Server) reject() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures == 0 {
+		return false
+	}
+
+	s.failures--
+	s.rejected++
+	return true
+}
+
+// address returns the address in a MAIL FROM:<...> or RCPT TO:<...>
+// argument.
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	return strings.Trim(strings.TrimSpace(addr), "<>")
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "/v1/resources/:id", `/v1/resources/:id`},
+		{"backslash", `C:\tmp`, `C:\\tmp`},
+		{"quote", `say "hi"`, `say \"hi\"`},
+		{"newline", "a\nb", `a\nb`},
+		{"tab kept", "a\tb", "a\tb"},
+		{"control kept", "a\x01b", "a\x01b"},
+		{"unicode kept", "café ☕", "café ☕"},
+		{"invalid utf-8", "a\xffb", "a�b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeLabel(tt.value); got != tt.want {
+				t.Errorf("escapeLabel(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteToEscapesLabels(t *testing.T) {
+	r := NewRegistry()
+
+	c := NewCounterVec(r, "test_total", "A help text with a \\ and\na newline.", "path")
+	c.Inc("/a\"b\\c\td\ne")
+
+	var out strings.Builder
+	if _, err := r.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# HELP test_total A help text with a \\\\ and\\na newline.\n" +
+		"# TYPE test_total counter\n" +
+		"test_total{path=\"/a\\\"b\\\\c\td\\ne\"} 1\n"
+
+	if out.String() != want {
+		t.Errorf("WriteTo wrote\n%s\nwant\n%s", out.String(), want)
+	}
+}
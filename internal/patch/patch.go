@@ -0,0 +1,427 @@
+// Package patch applies JSON Merge Patch (RFC 7386) and JSON Patch
+// (RFC 6902) documents to decoded JSON values: maps, slices, strings,
+// json.Numbers, booleans and nil.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTestFailed is returned when a test operation's value does not
+	// match the document.
+	ErrTestFailed = errors.New("test failed")
+	// ErrPathNotFound is returned when an operation refers to a location
+	// the document does not have.
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// Decode reads a JSON value for patching, keeping numbers as json.Number so
+// that integers survive the round trip exactly.
+func Decode(js []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("must only contain a single JSON value")
+	}
+
+	return v, nil
+}
+
+// Merge applies an RFC 7386 merge patch to doc and returns the result. A null
+// member removes the member it names; objects merge recursively and anything
+// else, arrays included, replaces the value it patches. doc is not modified.
+func Merge(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	target := make(map[string]any)
+	if d, ok := doc.(map[string]any); ok {
+		for k, v := range d {
+			target[k] = v
+		}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		target[k] = Merge(target[k], v)
+	}
+
+	return target
+}
+
+// Operation is one step of an RFC 6902 JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Error reports the operation that stopped a JSON Patch from applying.
+type Error struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// DecodeOperations reads a JSON Patch document.
+func DecodeOperations(js []byte) ([]Operation, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+
+	var ops []Operation
+	if err := dec.Decode(&ops); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("must only contain a single JSON value")
+	}
+
+	return ops, nil
+}
+
+// Apply applies ops to doc in order and returns the result. If any operation
+// fails, none of them take effect and the error is an *Error. doc is not
+// modified.
+func Apply(doc any, ops []Operation) (any, error) {
+	doc = deepCopy(doc)
+
+	for i, op := range ops {
+		var err error
+		doc, err = apply(doc, op)
+		if err != nil {
+			return nil, &Error{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	return doc, nil
+}
+
+func apply(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := func() (any, error) {
+		if len(op.Value) == 0 {
+			return nil, errors.New("value is required")
+		}
+		return Decode(op.Value)
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		doc, _, err = remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !Equal(current, v) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+
+		var v any
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("cannot move a value into itself")
+			}
+			doc, v, err = remove(doc, from)
+		} else {
+			v, err = get(doc, from)
+			v = deepCopy(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		return add(doc, path, v)
+
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must be empty or start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// index parses an array index token. end allows "-" and len(arr), which
+// name the position after the last element.
+func index(token string, length int, end bool) (int, error) {
+	if end && token == "-" {
+		return length, nil
+	}
+
+	// RFC 6901 indexes have no sign and no leading zeros.
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.ContainsAny(token, "+-") {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPathNotFound, token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > length || (!end && i == length) {
+		return 0, fmt.Errorf("%w: array index %s out of range", ErrPathNotFound, token)
+	}
+
+	return i, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: no member %q", ErrPathNotFound, token)
+			}
+			doc = v
+		case []any:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or array", ErrPathNotFound, token)
+		}
+	}
+
+	return doc, nil
+}
+
+// add sets the value at path, inserting into arrays, and returns the new
+// document.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+	case []any:
+		i, err := index(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+		return set(doc, path[:len(path)-1], node)
+	default:
+		return nil, fmt.Errorf("%w: parent of %q is not an object or array", ErrPathNotFound, last)
+	}
+}
+
+// remove deletes the value at path and returns the new document and the
+// value removed.
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		v, ok := node[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: no member %q", ErrPathNotFound, last)
+		}
+		delete(node, last)
+		return doc, v, nil
+	case []any:
+		i, err := index(last, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := node[i]
+		node = append(node[:i:i], node[i+1:]...)
+		doc, err = set(doc, path[:len(path)-1], node)
+		return doc, v, err
+	default:
+		return nil, nil, fmt.Errorf("%w: parent of %q is not an object or array", ErrPathNotFound, last)
+	}
+}
+
+// set replaces the value at an existing path. Arrays change length when
+// elements are added or removed, so the new slice must be stored back in
+// its parent.
+func set(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+	case []any:
+		i, err := index(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+	}
+
+	return doc, nil
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for k, e := range v {
+			c[k] = deepCopy(e)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = deepCopy(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// Equal compares JSON values as RFC 6902's test does: numbers by value,
+// objects regardless of member order.
+func Equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !Equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	default:
+		return a == b
+	}
+}
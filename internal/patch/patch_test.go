@@ -0,0 +1,314 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func mustDecode(t *testing.T, js string) any {
+	t.Helper()
+
+	v, err := Decode([]byte(js))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", js, err)
+	}
+	return v
+}
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(js)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		ops  string
+		want string
+	}{
+		// Objects.
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add replaces existing member", `{"a":1}`, `[{"op":"add","path":"/a","value":[1]}]`, `{"a":[1]}`},
+		{"add null member", `{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+		{"add nested member", `{"a":{"b":1}}`, `[{"op":"add","path":"/a/c","value":"x"}]`, `{"a":{"b":1,"c":"x"}}`},
+		{"add whole document", `{"a":1}`, `[{"op":"add","path":"","value":[1,2]}]`, `[1,2]`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"replace member", `{"a":1}`, `[{"op":"replace","path":"/a","value":{"x":true}}]`, `{"a":{"x":true}}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+
+		// Array elements.
+		{"add at start", `{"s":["b","c"]}`, `[{"op":"add","path":"/s/0","value":"a"}]`, `{"s":["a","b","c"]}`},
+		{"add in middle", `{"s":["a","c"]}`, `[{"op":"add","path":"/s/1","value":"b"}]`, `{"s":["a","b","c"]}`},
+		{"add at length", `{"s":["a"]}`, `[{"op":"add","path":"/s/1","value":"b"}]`, `{"s":["a","b"]}`},
+		{"add with dash appends", `{"s":["a"]}`, `[{"op":"add","path":"/s/-","value":"b"},{"op":"add","path":"/s/-","value":"c"}]`, `{"s":["a","b","c"]}`},
+		{"add with dash to empty array", `{"s":[]}`, `[{"op":"add","path":"/s/-","value":{"k":1}}]`, `{"s":[{"k":1}]}`},
+		{"add into nested array", `{"s":[[1],[2]]}`, `[{"op":"add","path":"/s/1/0","value":0}]`, `{"s":[[1],[0,2]]}`},
+		{"remove first", `{"s":["a","b","c"]}`, `[{"op":"remove","path":"/s/0"}]`, `{"s":["b","c"]}`},
+		{"remove last", `{"s":["a","b","c"]}`, `[{"op":"remove","path":"/s/2"}]`, `{"s":["a","b"]}`},
+		{"remove only", `{"s":["a"]}`, `[{"op":"remove","path":"/s/0"}]`, `{"s":[]}`},
+		{"replace element", `{"s":["a","b","c"]}`, `[{"op":"replace","path":"/s/1","value":"B"}]`, `{"s":["a","B","c"]}`},
+		{"replace last element", `{"s":["a","b"]}`, `[{"op":"replace","path":"/s/1","value":"B"}]`, `{"s":["a","B"]}`},
+		{"replace in root array", `[1,2,3]`, `[{"op":"replace","path":"/0","value":9}]`, `[9,2,3]`},
+
+		// test.
+		{"test member", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"test element", `{"s":["a","b"]}`, `[{"op":"test","path":"/s/1","value":"b"}]`, `{"s":["a","b"]}`},
+		{"test numbers by value", `{"n":1}`, `[{"op":"test","path":"/n","value":1.0}]`, `{"n":1}`},
+		{"test objects in any order", `{"o":{"a":1,"b":2}}`, `[{"op":"test","path":"/o","value":{"b":2,"a":1}}]`, `{"o":{"a":1,"b":2}}`},
+		{"test null", `{"a":null}`, `[{"op":"test","path":"/a","value":null}]`, `{"a":null}`},
+		{"test then replace", `{"v":1,"a":"x"}`, `[{"op":"test","path":"/v","value":1},{"op":"replace","path":"/a","value":"y"}]`, `{"v":1,"a":"y"}`},
+
+		// move and copy.
+		{"move member", `{"a":1,"b":{}}`, `[{"op":"move","from":"/a","path":"/b/a"}]`, `{"b":{"a":1}}`},
+		{"move element", `{"s":["a","b","c"]}`, `[{"op":"move","from":"/s/0","path":"/s/-"}]`, `{"s":["b","c","a"]}`},
+		{"copy element", `{"s":["a"],"t":[]}`, `[{"op":"copy","from":"/s/0","path":"/t/0"}]`, `{"s":["a"],"t":["a"]}`},
+		{"copy is independent", `{"a":{"x":1}}`, `[{"op":"copy","from":"/a","path":"/b"},{"op":"replace","path":"/b/x","value":2}]`, `{"a":{"x":1},"b":{"x":2}}`},
+
+		{"no operations", `{"a":1}`, `[]`, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodeOperations([]byte(tt.ops))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			doc := mustDecode(t, tt.doc)
+			before := encode(t, doc)
+
+			got, err := Apply(doc, ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := mustDecode(t, tt.want); !Equal(got, want) {
+				t.Errorf("Apply = %s, want %s", encode(t, got), tt.want)
+			}
+
+			if after := encode(t, doc); after != before {
+				t.Errorf("Apply modified its input: %s, was %s", after, before)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		ops     string
+		index   int
+		wantErr error
+	}{
+		{"test value differs", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"y"}]`, 0, ErrTestFailed},
+		{"test type differs", `{"a":1}`, `[{"op":"test","path":"/a","value":"1"}]`, 0, ErrTestFailed},
+		{"test null against absent", `{}`, `[{"op":"test","path":"/a","value":null}]`, 0, ErrPathNotFound},
+		{"test array length differs", `{"s":[1,2]}`, `[{"op":"test","path":"/s","value":[1]}]`, 0, ErrTestFailed},
+		{"test fails after other ops", `{"a":1}`, `[{"op":"add","path":"/b","value":2},{"op":"test","path":"/b","value":3}]`, 1, ErrTestFailed},
+
+		{"remove missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, 0, ErrPathNotFound},
+		{"replace missing member", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, 0, ErrPathNotFound},
+		{"add under missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, 0, ErrPathNotFound},
+		{"add under scalar", `{"a":1}`, `[{"op":"add","path":"/a/b","value":1}]`, 0, ErrPathNotFound},
+
+		{"add past end", `{"s":["a"]}`, `[{"op":"add","path":"/s/2","value":"c"}]`, 0, ErrPathNotFound},
+		{"remove at length", `{"s":["a"]}`, `[{"op":"remove","path":"/s/1"}]`, 0, ErrPathNotFound},
+		{"remove dash", `{"s":["a"]}`, `[{"op":"remove","path":"/s/-"}]`, 0, ErrPathNotFound},
+		{"replace dash", `{"s":["a"]}`, `[{"op":"replace","path":"/s/-","value":"b"}]`, 0, ErrPathNotFound},
+		{"replace in empty array", `{"s":[]}`, `[{"op":"replace","path":"/s/0","value":"b"}]`, 0, ErrPathNotFound},
+		{"test dash", `{"s":["a"]}`, `[{"op":"test","path":"/s/-","value":"a"}]`, 0, ErrPathNotFound},
+		{"negative index", `{"s":["a"]}`, `[{"op":"remove","path":"/s/-1"}]`, 0, ErrPathNotFound},
+		{"signed index", `{"s":["a","b"]}`, `[{"op":"remove","path":"/s/+1"}]`, 0, ErrPathNotFound},
+		{"leading zero", `{"s":["a","b"]}`, `[{"op":"remove","path":"/s/01"}]`, 0, ErrPathNotFound},
+		{"non-numeric index", `{"s":["a"]}`, `[{"op":"remove","path":"/s/x"}]`, 0, ErrPathNotFound},
+		{"empty index", `{"s":["a"]}`, `[{"op":"add","path":"/s/","value":"b"}]`, 0, ErrPathNotFound},
+		{"huge index", `{"s":["a"]}`, `[{"op":"add","path":"/s/99999999999999999999","value":"b"}]`, 0, ErrPathNotFound},
+		{"out of range in later op", `{"s":["a"]}`, `[{"op":"remove","path":"/s/0"},{"op":"remove","path":"/s/0"}]`, 1, ErrPathNotFound},
+
+		{"move from missing", `{}`, `[{"op":"move","from":"/a","path":"/b"}]`, 0, ErrPathNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodeOperations([]byte(tt.ops))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			doc := mustDecode(t, tt.doc)
+			before := encode(t, doc)
+
+			got, err := Apply(doc, ops)
+			if got != nil {
+				t.Errorf("Apply returned %s with an error", encode(t, got))
+			}
+
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("Apply error = %v, want an *Error", err)
+			}
+			if perr.Index != tt.index {
+				t.Errorf("failed at operation %d, want %d", perr.Index, tt.index)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Apply error = %v, want %v", err, tt.wantErr)
+			}
+
+			if after := encode(t, doc); after != before {
+				t.Errorf("a failed Apply modified its input: %s, was %s", after, before)
+			}
+		})
+	}
+}
+
+func TestApplyInvalidOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  string
+	}{
+		{"unknown op", `[{"op":"increment","path":"/a"}]`},
+		{"missing value", `[{"op":"add","path":"/a"}]`},
+		{"relative path", `[{"op":"add","path":"a","value":1}]`},
+		{"relative from", `[{"op":"copy","from":"a","path":"/b"}]`},
+		{"move into itself", `[{"op":"move","from":"/a","path":"/a/b"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodeOperations([]byte(tt.ops))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = Apply(mustDecode(t, `{"a":{}}`), ops)
+
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("Apply error = %v, want an *Error", err)
+			}
+		})
+	}
+}
+
+func TestDecodeOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		js      string
+		wantErr bool
+	}{
+		{"valid", `[{"op":"add","path":"/a","value":1}]`, false},
+		{"unknown field", `[{"op":"add","path":"/a","value":1,"extra":true}]`, true},
+		{"not an array", `{"op":"add","path":"/a","value":1}`, true},
+		{"trailing value", `[] []`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeOperations([]byte(tt.js))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeOperations error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	// The examples from RFC 7386 appendix A, plus the cases the API relies
+	// on to tell a cleared field from one left alone.
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null removes member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"null removes only its member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaces array", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"scalar replaces array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested null removes nested member", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays are not merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"array document", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"object replaces array document", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"null patch", `{"a":"foo"}`, `null`, `null`},
+		{"scalar patch", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null member in new object", `{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{"object onto scalar", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"nested null in new object", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+
+		{"absent member left alone", `{"a":"x","b":"y"}`, `{}`, `{"a":"x","b":"y"}`},
+		{"null removes member that is absent", `{"a":"x"}`, `{"b":null}`, `{"a":"x"}`},
+		{"empty object is not null", `{"a":{"b":1}}`, `{"a":{}}`, `{"a":{"b":1}}`},
+		{"empty array replaces", `{"a":[1,2]}`, `{"a":[]}`, `{"a":[]}`},
+		{"empty string replaces", `{"a":"x"}`, `{"a":""}`, `{"a":""}`},
+		{"false replaces", `{"a":true}`, `{"a":false}`, `{"a":false}`},
+		{"zero replaces", `{"a":5}`, `{"a":0}`, `{"a":0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustDecode(t, tt.doc)
+			before := encode(t, doc)
+
+			got := Merge(doc, mustDecode(t, tt.patch))
+
+			if want := mustDecode(t, tt.want); !Equal(got, want) {
+				t.Errorf("Merge = %s, want %s", encode(t, got), tt.want)
+			}
+
+			if after := encode(t, doc); after != before {
+				t.Errorf("Merge modified its input: %s, was %s", after, before)
+			}
+		})
+	}
+}
+
+func TestMergeNullAndAbsent(t *testing.T) {
+	doc := mustDecode(t, `{"a":1,"b":2}`)
+
+	got, ok := Merge(doc, mustDecode(t, `{"a":null}`)).(map[string]any)
+	if !ok {
+		t.Fatal("Merge did not return an object")
+	}
+
+	if _, ok := got["a"]; ok {
+		t.Error(`a null member was kept; it should remove "a"`)
+	}
+	if v, ok := got["b"]; !ok || !Equal(v, json.Number("2")) {
+		t.Errorf(`an absent member changed "b" to %v`, v)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`1`, `1.0`, true},
+		{`1e2`, `100`, true},
+		{`1`, `2`, false},
+		{`"1"`, `1`, false},
+		{`null`, `null`, true},
+		{`null`, `false`, false},
+		{`[1,2]`, `[2,1]`, false},
+		{`{"a":1,"b":[true]}`, `{"b":[true],"a":1}`, true},
+		{`{"a":1}`, `{"a":1,"b":null}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := Equal(mustDecode(t, tt.a), mustDecode(t, tt.b)); got != tt.want {
+				t.Errorf("Equal(%s, %s) = %t, want %t", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS "webhooks" (
+  "id" bigserial PRIMARY KEY,
+  "url" text NOT NULL,
+  "secret" text NOT NULL,
+  "events" text[] NOT NULL,
+  "active" boolean NOT NULL DEFAULT TRUE,
+  "created_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "updated_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "version" int NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "webhook_id" bigint NOT NULL REFERENCES webhooks ON DELETE CASCADE,
+  "event" text NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" text NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "response_status" int NOT NULL DEFAULT 0,
+  "last_error" text NOT NULL DEFAULT '',
+  "created_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "updated_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "delivered_at" timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
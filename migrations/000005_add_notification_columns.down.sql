@@ -0,0 +1,3 @@
+ALTER TABLE resource_requests DROP COLUMN unfilled_notified_at;
+
+ALTER TABLE resources DROP COLUMN email;
@@ -0,0 +1,3 @@
+ALTER TABLE resources ADD COLUMN email varchar NOT NULL DEFAULT '';
+
+ALTER TABLE resource_requests ADD COLUMN unfilled_notified_at timestamp(0) with time zone;
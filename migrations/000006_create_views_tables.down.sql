@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS view_visits;
+DROP TABLE IF EXISTS views;
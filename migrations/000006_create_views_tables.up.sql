@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "views" (
+  "id" bigserial PRIMARY KEY,
+  "owner" text NOT NULL,
+  "name" text NOT NULL,
+  "list" text NOT NULL,
+  "query" jsonb NOT NULL DEFAULT '{}',
+  "shared_with" text[] NOT NULL DEFAULT '{}',
+  "created_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "updated_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "version" int NOT NULL DEFAULT 1,
+  UNIQUE ("owner", "list", "name")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_views_shared_with" ON "views" USING GIN ("shared_with");
+
+CREATE TABLE IF NOT EXISTS "view_visits" (
+  "view_id" bigint NOT NULL REFERENCES views ON DELETE CASCADE,
+  "user_name" text NOT NULL,
+  "viewed_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  "results" jsonb NOT NULL DEFAULT '{}',
+  PRIMARY KEY ("view_id", "user_name")
+);
@@ -0,0 +1,12 @@
+UPDATE views
+SET query = (query - 'status') || CASE WHEN query->>'status' = 'closed' THEN '{"closed": "true"}'::jsonb ELSE '{}'::jsonb END
+WHERE list = 'requests' AND query ? 'status';
+
+DROP TABLE IF EXISTS request_transitions;
+
+ALTER TABLE resource_requests ADD COLUMN closed BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE resource_requests SET closed = status IN ('closed', 'cancelled');
+
+DROP INDEX IF EXISTS idx_resource_requests_status;
+ALTER TABLE resource_requests DROP COLUMN status_changed_at;
+ALTER TABLE resource_requests DROP COLUMN status;
@@ -0,0 +1,39 @@
+ALTER TABLE resource_requests ADD COLUMN "status" text NOT NULL DEFAULT 'open';
+ALTER TABLE resource_requests ADD COLUMN "status_changed_at" timestamp(0) with time zone NOT NULL DEFAULT (now());
+
+UPDATE resource_requests SET status = 'closed', status_changed_at = updated_at WHERE closed;
+
+UPDATE resource_requests SET status = CASE
+    WHEN fill.hours <= 0 THEN 'open'
+    WHEN fill.hours < resource_requests.hours_per_week THEN 'partially_filled'
+    ELSE 'filled'
+  END
+FROM (
+  SELECT resource_request_id, sum(hours_per_week) AS hours
+  FROM resource_assignments
+  WHERE NOT completed
+  GROUP BY resource_request_id
+) AS fill
+WHERE fill.resource_request_id = resource_requests.id AND NOT resource_requests.closed;
+
+ALTER TABLE resource_requests ADD CONSTRAINT "resource_requests_status_check" CHECK (status IN ('draft', 'open', 'partially_filled', 'filled', 'in_delivery', 'closed', 'cancelled', 'on_hold'));
+ALTER TABLE resource_requests DROP COLUMN closed;
+
+CREATE INDEX IF NOT EXISTS "idx_resource_requests_status" ON "resource_requests" ("status");
+
+CREATE TABLE IF NOT EXISTS "request_transitions" (
+  "id" bigserial PRIMARY KEY,
+  "request_id" bigint NOT NULL REFERENCES resource_requests ON DELETE CASCADE,
+  "from_status" text NOT NULL,
+  "to_status" text NOT NULL,
+  "reason" text NOT NULL DEFAULT '',
+  "actor" text NOT NULL DEFAULT '',
+  "created_at" timestamp(0) with time zone NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "idx_request_transitions_request_id" ON "request_transitions" ("request_id", "id");
+
+-- Saved views of closed requests now filter on the status.
+UPDATE views
+SET query = (query - 'closed') || CASE WHEN query->>'closed' = 'true' THEN '{"status": "closed"}'::jsonb ELSE '{}'::jsonb END
+WHERE list = 'requests' AND query ? 'closed';
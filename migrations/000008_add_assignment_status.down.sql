@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS assignment_transitions;
+
+-- Without a status every assignment would count as staffed, so drop the
+-- proposals that were never accepted.
+DELETE FROM resource_assignments WHERE status <> 'accepted';
+
+ALTER TABLE resource_assignments DROP COLUMN proposed_by;
+ALTER TABLE resource_assignments DROP COLUMN status_changed_at;
+ALTER TABLE resource_assignments DROP COLUMN status;
@@ -0,0 +1,24 @@
+-- Assignments made before proposals existed were instant, so they start out
+-- accepted; new ones start as proposals.
+ALTER TABLE resource_assignments ADD COLUMN "status" text NOT NULL DEFAULT 'accepted';
+ALTER TABLE resource_assignments ALTER COLUMN "status" SET DEFAULT 'proposed';
+ALTER TABLE resource_assignments ADD COLUMN "status_changed_at" timestamp(0) with time zone NOT NULL DEFAULT (now());
+ALTER TABLE resource_assignments ADD COLUMN "proposed_by" text NOT NULL DEFAULT '';
+
+UPDATE resource_assignments SET status_changed_at = created_at WHERE created_at IS NOT NULL;
+
+ALTER TABLE resource_assignments ADD CONSTRAINT "resource_assignments_status_check" CHECK (status IN ('proposed', 'shortlisted', 'customer-interview', 'accepted', 'rejected', 'withdrawn'));
+
+CREATE TABLE IF NOT EXISTS "assignment_transitions" (
+  "id" bigserial PRIMARY KEY,
+  "resource_request_id" int NOT NULL,
+  "resource_id" int NOT NULL,
+  "from_status" text NOT NULL,
+  "to_status" text NOT NULL,
+  "comment" text NOT NULL DEFAULT '',
+  "actor" text NOT NULL DEFAULT '',
+  "created_at" timestamp(0) with time zone NOT NULL DEFAULT (now()),
+  FOREIGN KEY ("resource_request_id", "resource_id") REFERENCES resource_assignments ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_assignment_transitions_assignment" ON "assignment_transitions" ("resource_request_id", "resource_id", "id");
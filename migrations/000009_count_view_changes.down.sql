@@ -0,0 +1,3 @@
+ALTER TABLE view_visits ADD COLUMN "results" jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE resources DROP COLUMN "updated_at";
@@ -0,0 +1,6 @@
+-- Views count the records updated since a user's last visit rather than
+-- comparing a fingerprint of every result, so resources need to record when
+-- they change and visits no longer keep their results.
+ALTER TABLE resources ADD COLUMN "updated_at" timestamp(0) with time zone NOT NULL DEFAULT (now());
+
+ALTER TABLE view_visits DROP COLUMN "results";
@@ -0,0 +1 @@
+ALTER TABLE resources DROP COLUMN "capacity_hours";
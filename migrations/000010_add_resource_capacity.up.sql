@@ -0,0 +1,6 @@
+-- Each resource has its own weekly capacity for the allocation checks. The
+-- default matches the API's -allocation-capacity default, which the API sets
+-- on resources created without one.
+ALTER TABLE resources ADD COLUMN "capacity_hours" int NOT NULL DEFAULT 40;
+
+ALTER TABLE resources ADD CONSTRAINT "resources_capacity_hours_check" CHECK (capacity_hours BETWEEN 1 AND 168);
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// AssignmentUpdate holds the fields to change on an assignment; nil fields
+// are left as they are.
+type AssignmentUpdate struct {
+	HoursPerWeek *int64 `json:"hoursPerWeek,omitempty"`
+	Completed    *bool  `json:"completed,omitempty"`
+}
+
+type assignmentEnvelope struct {
+	Assignment *ResourceAssignment `json:"assignment"`
+}
+
+func (c *Client) ListAssignments(ctx context.Context, requestID int64) ([]*ResourceAssignment, error) {
+	var out struct {
+		Assignments []*ResourceAssignment `json:"assignments"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/requests/%d/assignments", requestID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Assignments, nil
+}
+
+func (c *Client) GetAssignment(ctx context.Context, requestID, resourceID int64) (*ResourceAssignment, error) {
+	var out assignmentEnvelope
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/requests/%d/assignments/%d", requestID, resourceID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Assignment, nil
+}
+
+// CreateAssignment assigns a resource to a request. A nil hoursPerWeek takes
+// the request's hours per week. Status may be "proposed" or "accepted"; an
+// empty status proposes the resource.
+func (c *Client) CreateAssignment(ctx context.Context, requestID, resourceID int64, hoursPerWeek *int64, status string) (*ResourceAssignment, error) {
+	in := struct {
+		ResourceID   int64  `json:"resourceId"`
+		HoursPerWeek *int64 `json:"hoursPerWeek,omitempty"`
+		Status       string `json:"status,omitempty"`
+	}{resourceID, hoursPerWeek, status}
+
+	var out assignmentEnvelope
+
+	if err := c.do(ctx, http.MethodPost, pathf("/v1/requests/%d/assignments", requestID), nil, in, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Assignment, nil
+}
+
+func (c *Client) UpdateAssignment(ctx context.Context, requestID, resourceID int64, update AssignmentUpdate) (*ResourceAssignment, error) {
+	var out assignmentEnvelope
+
+	if err := c.do(ctx, http.MethodPatch, pathf("/v1/requests/%d/assignments/%d", requestID, resourceID), nil, update, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Assignment, nil
+}
+
+func (c *Client) DeleteAssignment(ctx context.Context, requestID, resourceID int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/v1/requests/%d/assignments/%d", requestID, resourceID), nil, nil, nil)
+}
+
+// TransitionAssignment moves an assignment to status with a comment. It
+// returns the updated assignment and the recorded transition.
+func (c *Client) TransitionAssignment(ctx context.Context, requestID, resourceID int64, status, comment string) (*ResourceAssignment, *AssignmentTransition, error) {
+	in := struct {
+		Status  string `json:"status"`
+		Comment string `json:"comment,omitempty"`
+	}{status, comment}
+
+	var out struct {
+		Assignment *ResourceAssignment   `json:"assignment"`
+		Transition *AssignmentTransition `json:"transition"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, pathf("/v1/requests/%d/assignments/%d/transitions", requestID, resourceID), nil, in, &out); err != nil {
+		return nil, nil, err
+	}
+
+	return out.Assignment, out.Transition, nil
+}
+
+// ListAssignmentTransitions returns the status changes of an assignment,
+// oldest first.
+func (c *Client) ListAssignmentTransitions(ctx context.Context, requestID, resourceID int64) ([]*AssignmentTransition, error) {
+	var out struct {
+		Transitions []*AssignmentTransition `json:"transitions"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/requests/%d/assignments/%d/transitions", requestID, resourceID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Transitions, nil
+}
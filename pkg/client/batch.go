@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// BatchOperation is one change in a batch. ID is a resource's or request's
+// id, or an assignment's request id, with ResourceID naming the assignment's
+// resource on update and delete. Either may be an int64 or "$ref", the Ref of
+// a resource or request created earlier in the batch. Data is the body the
+// single create or update call takes, such as a ResourceUpdate.
+type BatchOperation struct {
+	Op         string `json:"op"`
+	Type       string `json:"type"`
+	Ref        string `json:"ref,omitempty"`
+	ID         any    `json:"id,omitempty"`
+	ResourceID any    `json:"resourceId,omitempty"`
+	Data       any    `json:"data,omitempty"`
+}
+
+// BatchResult is the outcome of one operation: the record it created or
+// updated, or the ids it deleted.
+type BatchResult struct {
+	Op         string              `json:"op"`
+	Type       string              `json:"type"`
+	Status     int                 `json:"status"`
+	Resource   *Resource           `json:"resource,omitempty"`
+	Request    *ResourceRequest    `json:"request,omitempty"`
+	Assignment *ResourceAssignment `json:"assignment,omitempty"`
+	ResourceID int64               `json:"resourceId,omitempty"`
+	RequestID  int64               `json:"requestId,omitempty"`
+}
+
+// Batch applies ops in one transaction: every operation succeeds or none
+// does. A failure is an *Error whose validation errors are keyed by the
+// failing operation, such as "operations[2].data.resourceId".
+func (c *Client) Batch(ctx context.Context, ops []BatchOperation) ([]BatchResult, error) {
+	in := struct {
+		Operations []BatchOperation `json:"operations"`
+	}{ops}
+
+	var out struct {
+		Results []BatchResult `json:"results"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, "/v1/batch", nil, in, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Results, nil
+}
+
+// BatchRef refers to the record created by the operation with this ref.
+func BatchRef(ref string) string {
+	return "$" + ref
+}
@@ -0,0 +1,257 @@
+// Package client is a Go client for the delivery dashboard API.
+//
+// It reuses the API's own record types, unwraps response envelopes, turns
+// problem details into *Error values and retries requests the server asks
+// to be retried (429 and 503) with exponential backoff.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmw-pso/delivery-dashboard/back-end/internal/data"
+)
+
+// The record types are those of the API itself. They are aliased here so
+// that code outside this module can name them.
+type (
+	Position             = data.Position
+	Clearance            = data.Clearance
+	Resource             = data.Resource
+	ResourceRequest      = data.ResourceRequest
+	RequestTransition    = data.RequestTransition
+	ResourceAssignment   = data.ResourceAssignment
+	AssignmentTransition = data.AssignmentTransition
+	Webhook              = data.Webhook
+	WebhookDelivery      = data.WebhookDelivery
+	View                 = data.View
+	Metadata             = data.Metadata
+)
+
+// Client calls the dashboard API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	userAgent  string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithToken sends token as a bearer token with every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetries sets how many times a request answered with 429 or 503 is
+// retried. Zero disables retries.
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the cap it doubles
+// up to. A Retry-After header from the server takes precedence.
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// New returns a client for the API served at baseURL, for example
+// "https://dashboard.example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL must be http or https, got %q", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		userAgent:  "dashboard-go-client",
+		maxRetries: 3,
+		minBackoff: 250 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// do sends a request and decodes a successful JSON response into out, which
+// may be nil. body, when not nil, is marshalled to JSON.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	res, err := c.send(ctx, method, path, query, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, res.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decoding %s %s response: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// send performs the request, retrying on 429 and 503, and returns the
+// response if its status is below 400. Otherwise the body is decoded into an
+// *Error. The caller must close the returned body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body any, accept string) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("client: encoding request body: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := c.newRequest(ctx, method, path, query, payload, accept)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode < 400 {
+			return res, nil
+		}
+
+		apiErr := decodeError(res)
+		res.Body.Close()
+
+		retryable := res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable
+		if !retryable || attempt >= c.maxRetries {
+			return nil, apiErr
+		}
+
+		timer := time.NewTimer(c.backoff(attempt, res.Header.Get("Retry-After")))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, payload []byte, accept string) (*http.Request, error) {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return req, nil
+}
+
+// backoff returns the delay before retry number attempt+1.
+func (c *Client) backoff(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(retryAfter); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	d := c.minBackoff << attempt
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+
+	// Jitter keeps many clients from retrying in lockstep.
+	if d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+
+	return d
+}
+
+func pathf(format string, ids ...int64) string {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func setInt(q url.Values, key string, value int) {
+	if value != 0 {
+		q.Set(key, strconv.Itoa(value))
+	}
+}
+
+func setString(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func setList(q url.Values, key string, values []string) {
+	if len(values) > 0 {
+		q.Set(key, strings.Join(values, ","))
+	}
+}
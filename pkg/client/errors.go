@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Problem types the API uses beyond "about:blank".
+const (
+	ProblemValidationFailed = "urn:dashboard:problem:validation-failed"
+	ProblemEditConflict     = "urn:dashboard:problem:edit-conflict"
+	ProblemPatchTestFailed  = "urn:dashboard:problem:patch-test-failed"
+)
+
+// Error is an error response from the API, decoded from its RFC 7807 problem
+// details.
+type Error struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+	// Errors maps each invalid field to a message when validation failed.
+	Errors    map[string]string `json:"errors"`
+	RequestID string            `json:"requestId"`
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "dashboard: %d %s", e.Status, e.Title)
+	if e.Detail != "" {
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	}
+
+	if len(e.Errors) > 0 {
+		fields := make([]string, 0, len(e.Errors))
+		for field, message := range e.Errors {
+			fields = append(fields, field+" "+message)
+		}
+		sort.Strings(fields)
+		fmt.Fprintf(&b, " (%s)", strings.Join(fields, "; "))
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request %s]", e.RequestID)
+	}
+
+	return b.String()
+}
+
+func decodeError(res *http.Response) *Error {
+	e := &Error{Status: res.StatusCode}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err == nil && json.Unmarshal(body, e) == nil {
+		// The body's status is authoritative only if the server sent one.
+		if e.Status == 0 {
+			e.Status = res.StatusCode
+		}
+	} else {
+		e.Detail = strings.TrimSpace(string(body))
+	}
+
+	if e.Title == "" {
+		e.Title = http.StatusText(res.StatusCode)
+	}
+	if e.RequestID == "" {
+		e.RequestID = res.Header.Get("X-Request-ID")
+	}
+
+	return e
+}
+
+func hasStatus(err error, status int) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Status == status
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsEditConflict reports whether err means the record changed since it was
+// read. Fetch it again and retry the update.
+func IsEditConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// ValidationErrors returns the per-field messages if err is a validation
+// failure, and nil otherwise.
+func ValidationErrors(err error) map[string]string {
+	var e *Error
+	if errors.As(err, &e) && e.Status == http.StatusUnprocessableEntity {
+		return e.Errors
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventResync is sent when the server no longer holds every event after the
+// requested id. Clients should refetch the state they mirror.
+const EventResync = "resync"
+
+// Event is one server-sent event. Data holds the record the event is about,
+// such as {"resource": {...}}.
+type Event struct {
+	ID         uint64
+	Type       string
+	OccurredAt time.Time
+	Data       json.RawMessage
+}
+
+// EventOptions selects the events to stream.
+type EventOptions struct {
+	// Types holds event types such as "resource.updated" or topic wildcards
+	// such as "request.*". Empty streams every event.
+	Types []string
+	// LastEventID resumes the stream after this event.
+	LastEventID uint64
+}
+
+// EventStream reads events from GET /v1/events. It is not safe for
+// concurrent use.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	lastID  uint64
+}
+
+// Events opens the event stream. Cancelling ctx or calling Close ends it.
+func (c *Client) Events(ctx context.Context, opts EventOptions) (*EventStream, error) {
+	q := url.Values{}
+	setList(q, "types", opts.Types)
+	if opts.LastEventID > 0 {
+		q.Set("lastEventId", strconv.FormatUint(opts.LastEventID, 10))
+	}
+
+	res, err := c.send(ctx, http.MethodGet, "/v1/events", q, nil, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventStream{
+		body:    res.Body,
+		scanner: bufio.NewScanner(res.Body),
+		lastID:  opts.LastEventID,
+	}, nil
+}
+
+// Next blocks until the next event arrives. It returns io.EOF when the server
+// closes the stream; reconnect with LastEventID to resume.
+func (s *EventStream) Next() (Event, error) {
+	var (
+		e    Event
+		data strings.Builder
+		seen bool
+	)
+
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+
+		if line == "" {
+			if !seen {
+				continue
+			}
+			return s.finish(e, data.String())
+		}
+
+		// Comments carry heartbeats.
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "id":
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return Event{}, fmt.Errorf("client: invalid event id %q", value)
+			}
+			e.ID = id
+			seen = true
+		case "event":
+			e.Type = value
+			seen = true
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			seen = true
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+
+	return Event{}, io.EOF
+}
+
+func (s *EventStream) finish(e Event, data string) (Event, error) {
+	if e.ID > 0 {
+		s.lastID = e.ID
+	}
+
+	if e.Type == EventResync {
+		return e, nil
+	}
+
+	var payload struct {
+		Event      string          `json:"event"`
+		OccurredAt time.Time       `json:"occurredAt"`
+		Data       json.RawMessage `json:"data"`
+	}
+
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return Event{}, fmt.Errorf("client: decoding event %d: %w", e.ID, err)
+	}
+
+	e.OccurredAt = payload.OccurredAt
+	e.Data = payload.Data
+
+	return e, nil
+}
+
+// LastEventID is the id of the last event read, for resuming the stream.
+func (s *EventStream) LastEventID() uint64 {
+	return s.lastID
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Health is the liveness report from GET /v1/healthz.
+type Health struct {
+	Status     string            `json:"status"`
+	SystemInfo map[string]string `json:"system_info"`
+}
+
+// Readiness is the report from GET /v1/readyz. Ready is false when any
+// dependency check failed or the server is shutting down.
+type Readiness struct {
+	Ready  bool                   `json:"-"`
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the outcome of one readiness check.
+type HealthCheck struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func (c *Client) Healthz(ctx context.Context) (*Health, error) {
+	var out Health
+
+	if err := c.do(ctx, http.MethodGet, "/v1/healthz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Readyz fetches the readiness report. An unready server is not an error:
+// the report is returned with Ready set to false. It is never retried.
+func (c *Client) Readyz(ctx context.Context) (*Readiness, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/readyz", nil, nil, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusServiceUnavailable {
+		return nil, decodeError(res)
+	}
+
+	var out Readiness
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("client: decoding readiness response: %w", err)
+	}
+	out.Ready = res.StatusCode == http.StatusOK
+
+	return &out, nil
+}
+
+// Metrics returns the server's metrics in the Prometheus text format.
+func (c *Client) Metrics(ctx context.Context) ([]byte, error) {
+	return c.raw(ctx, "/metrics", "text/plain")
+}
+
+// OpenAPI returns the server's OpenAPI document.
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	return c.raw(ctx, "/v1/openapi.json", "application/json")
+}
+
+func (c *Client) raw(ctx context.Context, path, accept string) ([]byte, error) {
+	res, err := c.send(ctx, http.MethodGet, path, nil, nil, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return io.ReadAll(res.Body)
+}
@@ -0,0 +1,70 @@
+package client
+
+import "context"
+
+// Iterator walks every record of a paginated list, fetching pages as needed.
+//
+//	it := c.Resources(ctx, client.ResourceListOptions{})
+//	for it.Next() {
+//		r := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, page int) ([]*T, Metadata, error)
+
+	page     int
+	items    []*T
+	current  *T
+	metadata Metadata
+	err      error
+	done     bool
+}
+
+func newIterator[T any](ctx context.Context, firstPage int, fetch func(ctx context.Context, page int) ([]*T, Metadata, error)) *Iterator[T] {
+	if firstPage < 1 {
+		firstPage = 1
+	}
+	return &Iterator[T]{ctx: ctx, fetch: fetch, page: firstPage}
+}
+
+// Next advances to the next record, fetching the next page when the current
+// one is exhausted. It returns false at the end of the list or on error.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		items, metadata, err := it.fetch(it.ctx, it.page)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.items = items
+		it.metadata = metadata
+		it.done = len(items) == 0 || it.page >= metadata.LastPage
+		it.page++
+	}
+
+	it.current, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Value returns the current record.
+func (it *Iterator[T]) Value() *T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Metadata returns the pagination details of the last page fetched.
+func (it *Iterator[T]) Metadata() Metadata {
+	return it.metadata
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type positionEnvelope struct {
+	Position *Position `json:"position"`
+}
+
+type positionInput struct {
+	Title string `json:"title"`
+}
+
+func (c *Client) ListPositions(ctx context.Context) ([]*Position, error) {
+	var out struct {
+		Positions []*Position `json:"positions"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/positions", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Positions, nil
+}
+
+func (c *Client) GetPosition(ctx context.Context, id int64) (*Position, error) {
+	var out positionEnvelope
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/positions/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Position, nil
+}
+
+func (c *Client) CreatePosition(ctx context.Context, title string) (*Position, error) {
+	var out positionEnvelope
+
+	if err := c.do(ctx, http.MethodPost, "/v1/positions", nil, positionInput{title}, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Position, nil
+}
+
+func (c *Client) UpdatePosition(ctx context.Context, id int64, title string) (*Position, error) {
+	var out positionEnvelope
+
+	if err := c.do(ctx, http.MethodPatch, pathf("/v1/positions/%d", id), nil, positionInput{title}, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Position, nil
+}
+
+func (c *Client) DeletePosition(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/v1/positions/%d", id), nil, nil, nil)
+}
+
+type clearanceEnvelope struct {
+	Clearance *Clearance `json:"clearance"`
+}
+
+type clearanceInput struct {
+	Description string `json:"description"`
+}
+
+func (c *Client) ListClearances(ctx context.Context) ([]*Clearance, error) {
+	var out struct {
+		Clearances []*Clearance `json:"clearances"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/clearances", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Clearances, nil
+}
+
+func (c *Client) GetClearance(ctx context.Context, id int64) (*Clearance, error) {
+	var out clearanceEnvelope
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/clearances/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Clearance, nil
+}
+
+func (c *Client) CreateClearance(ctx context.Context, description string) (*Clearance, error) {
+	var out clearanceEnvelope
+
+	if err := c.do(ctx, http.MethodPost, "/v1/clearances", nil, clearanceInput{description}, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Clearance, nil
+}
+
+func (c *Client) UpdateClearance(ctx context.Context, id int64, description string) (*Clearance, error) {
+	var out clearanceEnvelope
+
+	if err := c.do(ctx, http.MethodPatch, pathf("/v1/clearances/%d", id), nil, clearanceInput{description}, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Clearance, nil
+}
+
+func (c *Client) DeleteClearance(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/v1/clearances/%d", id), nil, nil, nil)
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RequestListOptions filters and pages GET /v1/requests. Zero values leave
+// the server defaults in place.
+type RequestListOptions struct {
+	// Customer matches requests whose customer name contains every word.
+	Customer string
+	Skills   []string
+	// Status lists only requests in these statuses, such as "open"; by
+	// default every request is listed.
+	Status   []string
+	Page     int
+	PageSize int
+	// Sort is a column such as "start_date", prefixed with "-" to descend.
+	Sort string
+	// Fields narrows the records to these JSON fields, such as "customer";
+	// the rest are left zero. "id" is always returned.
+	Fields []string
+	// View applies a saved view of requests; the options set here override its
+	// saved parameters.
+	View int64
+}
+
+func (o RequestListOptions) query() url.Values {
+	q := url.Values{}
+	setString(q, "customer", o.Customer)
+	setList(q, "skills", o.Skills)
+	setList(q, "status", o.Status)
+	setInt(q, "page", o.Page)
+	setInt(q, "page_size", o.PageSize)
+	setString(q, "sort", o.Sort)
+	setList(q, "fields", o.Fields)
+	if o.View != 0 {
+		q.Set("view", strconv.FormatInt(o.View, 10))
+	}
+	return q
+}
+
+// RequestUpdate holds the fields to change on a resource request; nil fields
+// are left as they are.
+type RequestUpdate struct {
+	Customer     *string    `json:"customer,omitempty"`
+	StartDate    *time.Time `json:"startDate,omitempty"`
+	EndDate      *time.Time `json:"endDate,omitempty"`
+	HoursPerWeek *int64     `json:"hoursPerWeek,omitempty"`
+	Skills       []string   `json:"skills,omitempty"`
+}
+
+type requestEnvelope struct {
+	Request *ResourceRequest `json:"request"`
+}
+
+func (c *Client) ListRequests(ctx context.Context, opts RequestListOptions) ([]*ResourceRequest, Metadata, error) {
+	var out struct {
+		Requests []*ResourceRequest `json:"requests"`
+		Metadata Metadata           `json:"metadata"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/requests", opts.query(), nil, &out); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return out.Requests, out.Metadata, nil
+}
+
+// Requests iterates over every resource request matching opts, starting at
+// opts.Page.
+func (c *Client) Requests(ctx context.Context, opts RequestListOptions) *Iterator[ResourceRequest] {
+	return newIterator(ctx, opts.Page, func(ctx context.Context, page int) ([]*ResourceRequest, Metadata, error) {
+		opts.Page = page
+		return c.ListRequests(ctx, opts)
+	})
+}
+
+func (c *Client) GetRequest(ctx context.Context, id int64) (*ResourceRequest, error) {
+	var out requestEnvelope
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/requests/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Request, nil
+}
+
+// CreateRequest creates rr. Server-assigned fields such as ID and Version
+// are ignored; Status may be "draft" or "open", and defaults to "open".
+func (c *Client) CreateRequest(ctx context.Context, rr *ResourceRequest) (*ResourceRequest, error) {
+	in := struct {
+		Customer      string    `json:"customer"`
+		StartDate     time.Time `json:"startDate"`
+		EndDate       time.Time `json:"endDate"`
+		HoursPerWeek  int64     `json:"hoursPerWeek"`
+		Skills        []string  `json:"skills"`
+		OpportunityID string    `json:"projectID,omitempty"`
+		EngagementID  string    `json:"engagementID,omitempty"`
+		Status        string    `json:"status,omitempty"`
+	}{rr.Customer, rr.StartDate, rr.EndDate, rr.HoursPerWeek, rr.Skills, rr.OpportunityID, rr.EngagementID, rr.Status}
+
+	var out requestEnvelope
+
+	if err := c.do(ctx, http.MethodPost, "/v1/requests", nil, in, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Request, nil
+}
+
+func (c *Client) UpdateRequest(ctx context.Context, id int64, update RequestUpdate) (*ResourceRequest, error) {
+	var out requestEnvelope
+
+	if err := c.do(ctx, http.MethodPatch, pathf("/v1/requests/%d", id), nil, update, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Request, nil
+}
+
+func (c *Client) DeleteRequest(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/v1/requests/%d", id), nil, nil, nil)
+}
+
+// TransitionRequest moves a request by hand to status, giving the reason.
+// It returns the updated request and the recorded transition.
+func (c *Client) TransitionRequest(ctx context.Context, id int64, status, reason string) (*ResourceRequest, *RequestTransition, error) {
+	in := struct {
+		Status string `json:"status"`
+		Reason string `json:"reason,omitempty"`
+	}{status, reason}
+
+	var out struct {
+		Request    *ResourceRequest   `json:"request"`
+		Transition *RequestTransition `json:"transition"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, pathf("/v1/requests/%d/transitions", id), nil, in, &out); err != nil {
+		return nil, nil, err
+	}
+
+	return out.Request, out.Transition, nil
+}
+
+// ListRequestTransitions returns the status changes of a request, oldest
+// first.
+func (c *Client) ListRequestTransitions(ctx context.Context, id int64) ([]*RequestTransition, error) {
+	var out struct {
+		Transitions []*RequestTransition `json:"transitions"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/requests/%d/transitions", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Transitions, nil
+}
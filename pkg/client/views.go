@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ViewInput saves a view. List is "resources" or "requests"; Query holds the
+// list parameters to save, keyed as in the query string.
+type ViewInput struct {
+	Name       string            `json:"name"`
+	List       string            `json:"list"`
+	Query      map[string]string `json:"query,omitempty"`
+	SharedWith []string          `json:"sharedWith,omitempty"`
+}
+
+// ViewUpdate holds the fields to change on a view; nil fields are left as
+// they are. Query and SharedWith replace the saved values.
+type ViewUpdate struct {
+	Name       *string           `json:"name,omitempty"`
+	Query      map[string]string `json:"query,omitempty"`
+	SharedWith []string          `json:"sharedWith,omitempty"`
+}
+
+type viewEnvelope struct {
+	View *View `json:"view"`
+}
+
+// ListViews returns the views the caller owns or has had shared with them,
+// for list or, when list is "", for every list.
+func (c *Client) ListViews(ctx context.Context, list string) ([]*View, error) {
+	var out struct {
+		Views []*View `json:"views"`
+	}
+
+	q := url.Values{}
+	setString(q, "list", list)
+
+	if err := c.do(ctx, http.MethodGet, "/v1/views", q, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Views, nil
+}
+
+func (c *Client) GetView(ctx context.Context, id int64) (*View, error) {
+	var out viewEnvelope
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/views/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.View, nil
+}
+
+func (c *Client) CreateView(ctx context.Context, in ViewInput) (*View, error) {
+	var out viewEnvelope
+
+	if err := c.do(ctx, http.MethodPost, "/v1/views", nil, in, &out); err != nil {
+		return nil, err
+	}
+
+	return out.View, nil
+}
+
+func (c *Client) UpdateView(ctx context.Context, id int64, update ViewUpdate) (*View, error) {
+	var out viewEnvelope
+
+	if err := c.do(ctx, http.MethodPatch, pathf("/v1/views/%d", id), nil, update, &out); err != nil {
+		return nil, err
+	}
+
+	return out.View, nil
+}
+
+func (c *Client) DeleteView(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/v1/views/%d", id), nil, nil, nil)
+}
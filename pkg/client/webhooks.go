@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// WebhookInput registers a webhook. A nil Active registers it active.
+type WebhookInput struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// WebhookUpdate holds the fields to change on a webhook; nil fields are left
+// as they are.
+type WebhookUpdate struct {
+	URL    *string  `json:"url,omitempty"`
+	Secret *string  `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// DeliveryListOptions filters and pages a webhook's deliveries.
+type DeliveryListOptions struct {
+	// Status is one of "pending", "succeeded" or "failed"; empty lists all.
+	Status   string
+	Page     int
+	PageSize int
+}
+
+func (o DeliveryListOptions) query() url.Values {
+	q := url.Values{}
+	setString(q, "status", o.Status)
+	setInt(q, "page", o.Page)
+	setInt(q, "page_size", o.PageSize)
+	return q
+}
+
+type webhookEnvelope struct {
+	Webhook *Webhook `json:"webhook"`
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var out struct {
+		Webhooks []*Webhook `json:"webhooks"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/webhooks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Webhooks, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id int64) (*Webhook, error) {
+	var out webhookEnvelope
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/webhooks/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Webhook, nil
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, in WebhookInput) (*Webhook, error) {
+	var out webhookEnvelope
+
+	if err := c.do(ctx, http.MethodPost, "/v1/webhooks", nil, in, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Webhook, nil
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, id int64, update WebhookUpdate) (*Webhook, error) {
+	var out webhookEnvelope
+
+	if err := c.do(ctx, http.MethodPatch, pathf("/v1/webhooks/%d", id), nil, update, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Webhook, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, pathf("/v1/webhooks/%d", id), nil, nil, nil)
+}
+
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID int64, opts DeliveryListOptions) ([]*WebhookDelivery, Metadata, error) {
+	var out struct {
+		Deliveries []*WebhookDelivery `json:"deliveries"`
+		Metadata   Metadata           `json:"metadata"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, pathf("/v1/webhooks/%d/deliveries", webhookID), opts.query(), nil, &out); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return out.Deliveries, out.Metadata, nil
+}
+
+// WebhookDeliveries iterates over a webhook's deliveries, newest first,
+// starting at opts.Page.
+func (c *Client) WebhookDeliveries(ctx context.Context, webhookID int64, opts DeliveryListOptions) *Iterator[WebhookDelivery] {
+	return newIterator(ctx, opts.Page, func(ctx context.Context, page int) ([]*WebhookDelivery, Metadata, error) {
+		opts.Page = page
+		return c.ListWebhookDeliveries(ctx, webhookID, opts)
+	})
+}
+
+// RedeliverWebhook queues a new delivery carrying the payload of an earlier
+// one and returns it.
+func (c *Client) RedeliverWebhook(ctx context.Context, webhookID, deliveryID int64) (*WebhookDelivery, error) {
+	var out struct {
+		Delivery *WebhookDelivery `json:"delivery"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, pathf("/v1/webhooks/%d/deliveries/%d/redeliver", webhookID, deliveryID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Delivery, nil
+}